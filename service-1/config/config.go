@@ -1,13 +1,93 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Database Database `yaml:"database"`
-	Server   Server   `yaml:"server"`
-	Nats     Nats     `yaml:"nats"`
+	Database  Database  `yaml:"database"`
+	Server    Server    `yaml:"server"`
+	GRPC      GRPC      `yaml:"grpc"`
+	Feed      Feed      `yaml:"feed"`
+	Webhooks  Webhooks  `yaml:"webhooks"`
+	Schedule  Schedule  `yaml:"schedule"`
+	Import    Import    `yaml:"import"`
+	Nats      Nats      `yaml:"nats"`
+	Tracing   Tracing   `yaml:"tracing"`
+	Log       Log       `yaml:"log"`
+	Goods     Goods     `yaml:"goods"`
+	Search    Search    `yaml:"search"`
+	Auth      Auth      `yaml:"auth"`
+	RateLimit RateLimit `yaml:"ratelimit"`
+}
+
+type RateLimit struct {
+	Enabled bool `yaml:"enabled"`
+	// PerIP limits every client IP before authentication, so that failed
+	// credentials are limited too. Route is ignored.
+	PerIP RouteLimit `yaml:"perip"`
+	// Default applies to routes without an entry in Routes
+	Default RouteLimit   `yaml:"default"`
+	Routes  []RouteLimit `yaml:"routes"`
+}
+
+type RouteLimit struct {
+	Route string  `yaml:"route"`
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+	// PerProject keeps a separate bucket per projectID query parameter
+	PerProject bool `yaml:"perproject"`
+}
+
+type Auth struct {
+	// APIKeys requires an X-API-Key header on every goods route
+	APIKeys bool `yaml:"apikeys"`
+	// AdminToken guards the /admin routes, which are disabled when it is
+	// empty. ADMIN_TOKEN in the environment takes precedence.
+	AdminToken string `yaml:"admintoken"`
+	JWT        JWT    `yaml:"jwt"`
+}
+
+type JWT struct {
+	Enabled bool `yaml:"enabled"`
+	// JWKS is a local file path or an http(s) URL of the identity provider keys
+	JWKS     string        `yaml:"jwks"`
+	Issuer   string        `yaml:"issuer"`
+	Audience string        `yaml:"audience"`
+	Refresh  time.Duration `yaml:"refresh"`
+	// RolesClaim names the claim mapping project ids (or "*") to roles
+	RolesClaim string `yaml:"rolesclaim"`
+	// TenantClaim names the claim holding the caller's organization id.
+	// When set, tokens without it are rejected.
+	TenantClaim string `yaml:"tenantclaim"`
+}
+
+type Goods struct {
+	UniqueNames bool `yaml:"uniquenames"`
+}
+
+type Search struct {
+	// Similarity is the trigram word similarity at which a name matches a
+	// misspelled query, from 0 to 1
+	Similarity float64 `yaml:"similarity"`
+	MaxLimit   int     `yaml:"maxlimit"`
+}
+
+type Log struct {
+	// Format is either "json" or "console"
+	Format string `yaml:"format"`
+	Level  string `yaml:"level"`
+}
+
+type Tracing struct {
+	// Exporter is one of "otlp", "stdout" or "none"
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	ServiceName string  `yaml:"servicename"`
+	SampleRatio float64 `yaml:"sampleratio"`
 }
 
 type Nats struct {
@@ -38,6 +118,50 @@ type Redis struct {
 type Server struct {
 	Host string `yaml:"host"`
 	Port int    `yaml:"port"`
+	// SwaggerUI serves an interactive API browser at /docs
+	SwaggerUI bool `yaml:"swaggerui"`
+	// TrustedProxies lists the addresses or CIDRs of the proxies whose
+	// X-Forwarded-For is believed. Without any the client IP, which rate
+	// limits and audit entries use, is the peer address.
+	TrustedProxies []string `yaml:"trustedproxies"`
+}
+
+type Import struct {
+	// MaxBytes caps the size of an uploaded file
+	MaxBytes int64 `yaml:"maxbytes"`
+	// ChunkSize is how many rows are written per transaction by default
+	ChunkSize int `yaml:"chunksize"`
+}
+
+type Webhooks struct {
+	Enabled bool `yaml:"enabled"`
+	// Interval is how often due deliveries are sent
+	Interval    time.Duration `yaml:"interval"`
+	Timeout     time.Duration `yaml:"timeout"`
+	Batch       int           `yaml:"batch"`
+	MaxAttempts int           `yaml:"maxattempts"`
+	BackoffBase time.Duration `yaml:"backoffbase"`
+	BackoffMax  time.Duration `yaml:"backoffmax"`
+	// AllowedNetworks lists CIDRs deliveries may reach even though they are
+	// internal, e.g. a receiver on the local network in tests
+	AllowedNetworks []string `yaml:"allowednetworks"`
+}
+
+type Schedule struct {
+	Enabled bool `yaml:"enabled"`
+	// Interval is how often crossed visibility boundaries are announced
+	Interval time.Duration `yaml:"interval"`
+}
+
+type Feed struct {
+	// Buffer is how many recent change events are kept for Last-Event-ID replay
+	Buffer    int           `yaml:"buffer"`
+	Heartbeat time.Duration `yaml:"heartbeat"`
+}
+
+type GRPC struct {
+	Enabled bool `yaml:"enabled"`
+	Port    int  `yaml:"port"`
 }
 
 func Load(path string) (Config, error) {
@@ -49,6 +173,11 @@ func Load(path string) (Config, error) {
 		return config, err
 	}
 
+	// Secrets are better kept out of the config file
+	if err := viper.BindEnv("auth.admintoken", "ADMIN_TOKEN"); err != nil {
+		return config, err
+	}
+
 	if err := viper.Unmarshal(&config); err != nil {
 		return config, err
 	}
@@ -0,0 +1,10 @@
+-- Run once by the postgres image when it creates the database, as the
+-- superuser. The service connects as its own role, which row level security
+-- applies to: superusers and BYPASSRLS roles skip every policy.
+CREATE ROLE service LOGIN PASSWORD 'pass' NOSUPERUSER NOBYPASSRLS NOCREATEDB NOCREATEROLE;
+
+ALTER DATABASE domain OWNER TO service;
+ALTER SCHEMA public OWNER TO service;
+
+-- Migrations create it too, but only a superuser may in older versions
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
@@ -3,8 +3,11 @@ package app
 import (
 	"context"
 	"fmt"
+	"net/netip"
 	"os"
 	"os/signal"
+	"reflect"
+	"strings"
 	"syscall"
 	"time"
 
@@ -12,12 +15,19 @@ import (
 	"github.com/skantay/hezzl/config"
 	"github.com/skantay/hezzl/internal/controller/api"
 	"github.com/skantay/hezzl/internal/controller/mq/nats/v"
+	"github.com/skantay/hezzl/internal/controller/rpc"
 	"github.com/skantay/hezzl/internal/repository/postgres"
 	cache "github.com/skantay/hezzl/internal/repository/redis"
 	"github.com/skantay/hezzl/internal/usecase"
+	"github.com/skantay/hezzl/pkg/jwtauth"
+	"github.com/skantay/hezzl/pkg/logger"
+	"github.com/skantay/hezzl/pkg/metrics"
 	"github.com/skantay/hezzl/pkg/migrate"
 	psql "github.com/skantay/hezzl/pkg/postgres"
+	"github.com/skantay/hezzl/pkg/ratelimit"
 	rds "github.com/skantay/hezzl/pkg/redis"
+	"github.com/skantay/hezzl/pkg/tracing"
+	"github.com/skantay/hezzl/pkg/webhook"
 
 	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
@@ -31,14 +41,19 @@ func Run() error {
 	}
 
 	// Zap logger setup
-	configLog := zap.NewDevelopmentConfig()
-	configLog.DisableStacktrace = true
-	log, err := configLog.Build()
+	log, err := logger.New(cfg)
 	if err != nil {
 		return fmt.Errorf("zap logger error: %w", err)
 	}
 	defer log.Sync()
 
+	// Tracing setup
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		return fmt.Errorf("tracing setup error: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	// Connecting to postgres
 	db, err := psql.ConnectPostgres(cfg)
 	if err != nil {
@@ -46,11 +61,29 @@ func Run() error {
 	}
 	defer db.Close()
 
+	// Exposing connection pool stats
+	if err := metrics.RegisterDB(db, "postgres"); err != nil {
+		return fmt.Errorf("metrics register error: %w", err)
+	}
+
 	// Migrating up
 	if err := migrate.MigrateUp(db); err != nil {
 		return fmt.Errorf("migration up error: %w", err)
 	}
 
+	if err := postgres.UniqueNames(context.Background(), db, cfg.Goods.UniqueNames); err != nil {
+		return fmt.Errorf("unique names error: %w", err)
+	}
+
+	bypasses, err := postgres.BypassesRowSecurity(context.Background(), db)
+	if err != nil {
+		return fmt.Errorf("role check error: %w", err)
+	}
+
+	if bypasses {
+		log.Warn("postgres role bypasses row level security, connect as a role without SUPERUSER and BYPASSRLS")
+	}
+
 	/*
 
 
@@ -83,16 +116,109 @@ func Run() error {
 
 	natsI := v.New(nc)
 
+	// Change feed for the streaming endpoints
+	feed, err := v.NewFeed(nc, cfg.Feed.Buffer, log)
+	if err != nil {
+		return fmt.Errorf("change feed error: %w", err)
+	}
+	defer feed.Close()
+
+	projectRepo := postgres.NewProjectRepository(db, natsI)
+
+	revisionRepo := postgres.NewRevisionRepository(db)
+
+	goodRepo := postgres.New(db, natsI)
+
+	goodCache := cache.New(client)
+
 	goodUsecase := usecase.NewGoodUsecase(
-		postgres.New(db, natsI),
-		cache.New(client))
+		goodRepo,
+		projectRepo,
+		revisionRepo,
+		goodCache,
+		usecase.Rules{UniqueNames: cfg.Goods.UniqueNames},
+		log)
+
+	apiKeyUsecase := usecase.NewAPIKeyUsecase(postgres.NewAPIKeyRepository(db))
+
+	auditUsecase := usecase.NewAuditUsecase(postgres.NewAuditRepository(db))
+
+	// JWT verifier for bearer tokens
+	var verifier *jwtauth.Verifier
+	if cfg.Auth.JWT.Enabled {
+		verifier, err = jwtauth.NewVerifier(context.Background(), jwtauth.Options{
+			JWKS:     cfg.Auth.JWT.JWKS,
+			Issuer:   cfg.Auth.JWT.Issuer,
+			Audience: cfg.Auth.JWT.Audience,
+			Refresh:  cfg.Auth.JWT.Refresh,
+		})
+		if err != nil {
+			return fmt.Errorf("jwt verifier error: %w", err)
+		}
+	}
+
+	allowedNetworks := make([]netip.Prefix, 0, len(cfg.Webhooks.AllowedNetworks))
+	for _, network := range cfg.Webhooks.AllowedNetworks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return fmt.Errorf("webhook allowed network error: %w", err)
+		}
+
+		allowedNetworks = append(allowedNetworks, prefix)
+	}
 
-	service := usecase.NewService(goodUsecase)
+	webhookUsecase := usecase.NewWebhookUsecase(
+		postgres.NewWebhookRepository(db),
+		webhook.NewSender(cfg.Webhooks.Timeout, allowedNetworks...),
+		usecase.WebhookOptions{
+			Interval:    cfg.Webhooks.Interval,
+			Timeout:     cfg.Webhooks.Timeout,
+			Batch:       cfg.Webhooks.Batch,
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			BackoffBase: cfg.Webhooks.BackoffBase,
+			BackoffMax:  cfg.Webhooks.BackoffMax,
+		},
+		log)
+
+	organizationRepo := postgres.NewOrganizationRepository(db)
+	organizationUsecase := usecase.NewOrganizationUsecase(organizationRepo, goodCache, log)
+
+	authUsecase := usecase.NewAuthUsecase(apiKeyUsecase, cfg.Auth.APIKeys, verifier, cfg.Auth.JWT.RolesClaim, cfg.Auth.JWT.TenantClaim, organizationRepo)
+
+	taxonomyUsecase := usecase.NewTaxonomyUsecase(postgres.NewTaxonomyRepository(db), goodCache, log)
+
+	projectUsecase := usecase.NewProjectUsecase(projectRepo)
+
+	searchUsecase := usecase.NewSearchUsecase(
+		postgres.NewSearchRepository(db),
+		usecase.SearchOptions{Similarity: cfg.Search.Similarity, MaxLimit: cfg.Search.MaxLimit})
+
+	revisionUsecase := usecase.NewRevisionUsecase(revisionRepo)
+
+	changesetUsecase := usecase.NewChangesetUsecase(postgres.NewChangesetRepository(db, natsI), goodUsecase, goodCache, log)
+
+	service := usecase.NewService(goodUsecase, apiKeyUsecase, auditUsecase, authUsecase, webhookUsecase, taxonomyUsecase, projectUsecase, searchUsecase, revisionUsecase, changesetUsecase, organizationUsecase)
 
 	validate := validator.New()
 
+	// Report fields by their JSON names
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+
+		return name
+	})
+
+	if cfg.Auth.AdminToken == "" {
+		log.Warn("admin routes are disabled, set ADMIN_TOKEN to enable them")
+	}
+
 	// Init controller
-	ctrl := api.New(service, log, cfg, validate)
+	limiter := ratelimit.NewRedis(client)
+
+	ctrl := api.New(service, log, cfg, validate, limiter, feed)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -108,11 +234,49 @@ func Run() error {
 		return nil
 	}()
 
+	// The gRPC server drains its calls when serving is cancelled
+	serving, stopServing := context.WithCancel(context.Background())
+	defer stopServing()
+
+	grpcDone := make(chan struct{})
+
+	if cfg.GRPC.Enabled {
+		grpcCtrl := rpc.New(service, log, cfg, feed, limiter)
+
+		go func() {
+			defer close(grpcDone)
+
+			// Run gRPC controller
+			if err := grpcCtrl.Serve(serving); err != nil {
+				log.Error("gRPC controller error", zap.Error(err))
+			}
+		}()
+	} else {
+		close(grpcDone)
+	}
+
+	// Background workers stop at shutdown
+	workers, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	if cfg.Webhooks.Enabled {
+		// Deliveries are queued by the writes themselves; this only sends them
+		go webhookUsecase.Run(workers)
+	}
+
+	if cfg.Schedule.Enabled {
+		// Announce goods whose visibility window opened or closed
+		go usecase.NewScheduleUsecase(goodRepo, goodCache, cfg.Schedule.Interval, log).Run(workers)
+	}
+
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	<-shutdown
 
+	stopServing()
+	<-grpcDone
+
 	log.Info("Server shut down")
 	return nil
 }
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/schemas"
+)
+
+func (g ginController) createAPIKeyHandler(c *gin.Context) {
+	var request schemas.CreateAPIKeyRequest
+
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	key, raw, err := g.service.APIKey.Issue(c.Request.Context(), request.Name, request.ProjectIDs, request.Role, request.ExpiresAt)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, schemas.CreateAPIKeyResponse{APIKey: key, Key: raw})
+}
+
+func (g ginController) apiKeysListHandler(c *gin.Context) {
+	keys, err := g.service.APIKey.List(c.Request.Context())
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (g ginController) revokeAPIKeyHandler(c *gin.Context) {
+	id, err := parseQueryParamAtoi(c, "id", -1)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if id < 0 {
+		handleError(c, entity.NewQueryError(entity.FieldError{Field: "id", Rule: "required"}), "")
+
+		return
+	}
+
+	key, err := g.service.APIKey.Revoke(c.Request.Context(), id)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+func (g ginController) createOrganizationHandler(c *gin.Context) {
+	var request schemas.CreateOrganizationRequest
+
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	organization, err := g.service.Organization.Create(c.Request.Context(), request.Name)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, organization)
+}
+
+func (g ginController) organizationsListHandler(c *gin.Context) {
+	organizations, err := g.service.Organization.List(c.Request.Context())
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, organizations)
+}
+
+func (g ginController) assignProjectHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var request schemas.AssignProjectRequest
+
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if err := g.service.Organization.AssignProject(c.Request.Context(), projectID, request.OrganizationID); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+// attributePrefix marks the query parameters that filter on attributes.
+const attributePrefix = "attr."
+
+// parseAttributeFilter turns attr.<path>=<value> query parameters into the
+// JSON object the attributes of listed goods must contain, e.g.
+// attr.size.width=10 into {"size":{"width":10}}. Values that are JSON
+// numbers, booleans, null or quoted strings keep their type; anything else
+// is matched as a string.
+func parseAttributeFilter(c *gin.Context) (json.RawMessage, error) {
+	query := c.Request.URL.Query()
+
+	keys := make([]string, 0)
+	for key := range query {
+		if strings.HasPrefix(key, attributePrefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	// Shorter paths first, so conflicts are reported the same way every time
+	sort.Strings(keys)
+
+	doc := make(map[string]any)
+
+	for _, key := range keys {
+		path := strings.Split(strings.TrimPrefix(key, attributePrefix), ".")
+
+		if !setPath(doc, path, attributeValue(query.Get(key))) {
+			return nil, entity.NewQueryError(entity.FieldError{Field: key, Rule: "path"})
+		}
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("trouble encoding attribute filter: %w", err)
+	}
+
+	return data, nil
+}
+
+// setPath stores value at path, reporting false for empty segments and for
+// paths that run through another filter's value.
+func setPath(doc map[string]any, path []string, value any) bool {
+	for i, segment := range path {
+		if segment == "" {
+			return false
+		}
+
+		if i == len(path)-1 {
+			if _, ok := doc[segment]; ok {
+				return false
+			}
+
+			doc[segment] = value
+
+			return true
+		}
+
+		next, ok := doc[segment]
+		if !ok {
+			next = make(map[string]any)
+			doc[segment] = next
+		}
+
+		if doc, ok = next.(map[string]any); !ok {
+			return false
+		}
+	}
+
+	return false
+}
+
+func attributeValue(raw string) any {
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err == nil {
+		switch value.(type) {
+		case float64, bool, string, nil:
+			return json.RawMessage(raw)
+		}
+	}
+
+	return raw
+}
+
+func (g ginController) attributesSchemaHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	schema, err := g.service.Project.AttributesSchema(c.Request.Context(), projectID)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if schema == nil {
+		c.Status(http.StatusNoContent)
+
+		return
+	}
+
+	c.Data(http.StatusOK, "application/schema+json", schema)
+}
+
+func (g ginController) setAttributesSchemaHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	schema, err := c.GetRawData()
+	if err != nil {
+		handleError(c, fmt.Errorf("%w: %v", entity.ErrMalformedBody, err), "")
+
+		return
+	}
+
+	if !json.Valid(schema) {
+		handleError(c, entity.ErrMalformedBody, "schema is not valid JSON")
+
+		return
+	}
+
+	if err := g.service.Project.SetAttributesSchema(c.Request.Context(), projectID, schema); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.Data(http.StatusOK, "application/schema+json", schema)
+}
+
+func (g ginController) deleteAttributesSchemaHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if err := g.service.Project.SetAttributesSchema(c.Request.Context(), projectID, nil); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/schemas"
+)
+
+func (g ginController) auditListHandler(c *gin.Context) {
+	var (
+		filter entity.AuditFilter
+		err    error
+	)
+
+	if filter.ProjectID, err = parseQueryParamAtoi(c, "projectID", 0); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if filter.GoodID, err = parseQueryParamAtoi(c, "goodID", 0); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if filter.From, err = parseQueryParamTime(c, "from"); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if filter.To, err = parseQueryParamTime(c, "to"); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if filter.Limit, err = parseQueryParamAtoi(c, "limit", 50); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if filter.Offset, err = parseQueryParamAtoi(c, "offset", 0); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	// Without a project the query spans every project, which only a global
+	// admin may see
+	if principal, ok := principalFrom(c); ok && filter.ProjectID == 0 && principal.GlobalRole < entity.RoleAdmin {
+		handleError(c, entity.ErrForbidden, "projectID is required")
+
+		return
+	}
+
+	entries, total, err := g.service.Audit.List(c.Request.Context(), filter)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var response schemas.AuditListResponse
+
+	response.Meta.Total = total
+	response.Meta.Limit = filter.Limit
+	response.Meta.Offset = filter.Offset
+	response.Entries = entries
+
+	c.JSON(http.StatusOK, response)
+}
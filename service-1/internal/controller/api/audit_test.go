@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/config"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/usecase"
+	"github.com/skantay/hezzl/pkg/requestid"
+	"go.uber.org/zap"
+)
+
+// fixedKeys accepts every API key as principal.
+type fixedKeys struct {
+	usecase.APIKeyUsecase
+
+	principal entity.Principal
+}
+
+func (f fixedKeys) Authenticate(ctx context.Context, raw string) (entity.Principal, error) {
+	return f.principal, nil
+}
+
+// recordingAudit keeps the filter and client IP of the last List.
+type recordingAudit struct {
+	filter   *entity.AuditFilter
+	clientIP *string
+}
+
+func (r recordingAudit) List(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, int, error) {
+	*r.filter = filter
+	*r.clientIP = requestid.ClientIPFromContext(ctx)
+
+	return []entity.AuditEntry{}, 0, nil
+}
+
+func TestAuditList(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin := entity.Principal{Subject: "admin", GlobalRole: entity.RoleAdmin}
+	projectAdmin := entity.Principal{Subject: "owner", Roles: map[int]entity.Role{2: entity.RoleAdmin}}
+
+	tests := []struct {
+		name           string
+		principal      entity.Principal
+		trustedProxies []string
+		query          string
+		wantStatus     int
+		wantFilter     entity.AuditFilter
+		// wantClientIP is what the audit entries would record
+		wantClientIP string
+	}{
+		{
+			name:         "default page",
+			principal:    admin,
+			wantStatus:   http.StatusOK,
+			wantFilter:   entity.AuditFilter{Limit: 50},
+			wantClientIP: "192.0.2.1",
+		},
+		{
+			name:         "project and limit",
+			principal:    projectAdmin,
+			query:        "?projectID=2&limit=10&offset=20",
+			wantStatus:   http.StatusOK,
+			wantFilter:   entity.AuditFilter{ProjectID: 2, Limit: 10, Offset: 20},
+			wantClientIP: "192.0.2.1",
+		},
+		{
+			name:       "project admin across projects",
+			principal:  projectAdmin,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "project of another admin",
+			principal:  projectAdmin,
+			query:      "?projectID=3",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "malformed limit",
+			principal:  admin,
+			query:      "?limit=ten",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			// httptest requests come from 192.0.2.1
+			name:           "forwarded by a trusted proxy",
+			principal:      admin,
+			trustedProxies: []string{"192.0.2.0/24"},
+			wantStatus:     http.StatusOK,
+			wantFilter:     entity.AuditFilter{Limit: 50},
+			wantClientIP:   "203.0.113.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				filter   entity.AuditFilter
+				clientIP string
+			)
+
+			var cfg config.Config
+			cfg.Server.TrustedProxies = tt.trustedProxies
+
+			service := usecase.Service{
+				Auth:  usecase.NewAuthUsecase(fixedKeys{principal: tt.principal}, true, nil, "", "", nil),
+				Audit: recordingAudit{filter: &filter, clientIP: &clientIP},
+			}
+
+			g := ginController{service: service, cfg: cfg, log: zap.NewNop()}
+
+			router, err := g.router()
+			if err != nil {
+				t.Fatalf("router() error = %v", err)
+			}
+
+			request := httptest.NewRequest(http.MethodGet, "/audit"+tt.query, nil)
+			request.Header.Set(apiKeyHeader, "key")
+			request.Header.Set("X-Forwarded-For", "203.0.113.9")
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+
+			if filter != tt.wantFilter {
+				t.Errorf("listed with %+v, want %+v", filter, tt.wantFilter)
+			}
+
+			if clientIP != tt.wantClientIP {
+				t.Errorf("client IP = %q, want %q", clientIP, tt.wantClientIP)
+			}
+		})
+	}
+}
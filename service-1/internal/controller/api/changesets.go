@@ -0,0 +1,165 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/schemas"
+)
+
+// parseChangesetQuery reads the optional changesetID query parameter that
+// stages a write instead of making it. Zero means none.
+func parseChangesetQuery(c *gin.Context) (int, error) {
+	changesetID, err := parseQueryParamAtoi(c, "changesetID", 0)
+	if err != nil {
+		return 0, err
+	}
+
+	if changesetID < 0 {
+		return 0, entity.NewQueryError(entity.FieldError{Field: "changesetID", Rule: "min", Param: "1"})
+	}
+
+	return changesetID, nil
+}
+
+// parseChangesetQueries reads the required projectID and changesetID query
+// parameters of the changeset routes.
+func parseChangesetQueries(c *gin.Context) (int, int, error) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	changesetID, err := parseChangesetQuery(c)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if changesetID == 0 {
+		return 0, 0, entity.NewQueryError(entity.FieldError{Field: "changesetID", Rule: "required"})
+	}
+
+	return changesetID, projectID, nil
+}
+
+// respondStaged answers a write that was staged in a changeset.
+func respondStaged(c *gin.Context, change entity.Change, err error) {
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusAccepted, change)
+}
+
+func (g ginController) createChangesetHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	changeset, err := g.service.Changeset.Create(c.Request.Context(), projectID)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, changeset)
+}
+
+func (g ginController) changesetsListHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	changesets, err := g.service.Changeset.List(c.Request.Context(), projectID)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, changesets)
+}
+
+func (g ginController) previewChangesetHandler(c *gin.Context) {
+	changesetID, projectID, err := parseChangesetQueries(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	limit, err := parseQueryParamAtoi(c, "limit", 100)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	offset, err := parseQueryParamAtoi(c, "offset", 0)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	goods, changed, err := g.service.Changeset.Preview(c.Request.Context(), changesetID, projectID, limit, offset)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var response schemas.ChangesetPreviewResponse
+
+	response.Meta.Limit = limit
+	response.Meta.Offset = offset
+	response.Goods = goods
+	response.Changed = changed
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (g ginController) publishChangesetHandler(c *gin.Context) {
+	changesetID, projectID, err := parseChangesetQueries(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	goods, err := g.service.Changeset.Publish(c.Request.Context(), changesetID, projectID)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, schemas.ChangesetPublishResponse{Goods: goods})
+}
+
+func (g ginController) discardChangesetHandler(c *gin.Context) {
+	changesetID, projectID, err := parseChangesetQueries(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if err := g.service.Changeset.Discard(c.Request.Context(), changesetID, projectID); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator"
+	"github.com/skantay/hezzl/internal/entity"
+	"go.uber.org/zap"
+)
+
+const problemJSON = "application/problem+json"
+
+// statusCodes maps the entity error catalogue to HTTP statuses. Anything
+// not listed is reported as errors.internal with status 500.
+var statusCodes = []struct {
+	err    error
+	status int
+}{
+	{entity.ErrGoodNotFound, http.StatusNotFound},
+	{entity.ErrProjectNotFound, http.StatusNotFound},
+	{entity.ErrOrganizationNotFound, http.StatusNotFound},
+	{entity.ErrGoodNameTaken, http.StatusConflict},
+	{entity.ErrRevisionNotFound, http.StatusNotFound},
+	{entity.ErrChangesetNotFound, http.StatusNotFound},
+	{entity.ErrChangesetConflict, http.StatusConflict},
+	{entity.ErrCategoryNotFound, http.StatusNotFound},
+	{entity.ErrCategoryNameTaken, http.StatusConflict},
+	{entity.ErrAPIKeyNotFound, http.StatusNotFound},
+	{entity.ErrWebhookNotFound, http.StatusNotFound},
+	{entity.ErrDeliveryNotFound, http.StatusNotFound},
+	{entity.ErrUnauthorized, http.StatusUnauthorized},
+	{entity.ErrForbidden, http.StatusForbidden},
+	{entity.ErrRateLimited, http.StatusTooManyRequests},
+	{entity.ErrValidation, http.StatusBadRequest},
+	{entity.ErrMalformedBody, http.StatusBadRequest},
+	{entity.ErrInvalidQuery, http.StatusBadRequest},
+	{entity.ErrBodyTooLarge, http.StatusRequestEntityTooLarge},
+}
+
+type responseError struct {
+	Code    int                 `json:"code"`
+	Message string              `json:"message"`
+	Details string              `json:"details"`
+	Fields  []entity.FieldError `json:"fields,omitempty"`
+}
+
+// problem is an RFC 7807 problem details document.
+type problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Errors   []entity.FieldError `json:"errors,omitempty"`
+}
+
+// classify returns the catalogue error and HTTP status for err.
+func classify(err error) (error, int) {
+	for _, sc := range statusCodes {
+		if errors.Is(err, sc.err) {
+			return sc.err, sc.status
+		}
+	}
+
+	return entity.ErrInternal, http.StatusInternalServerError
+}
+
+// handleError logs err and renders it as JSON, or as problem+json when the
+// client asks for it.
+func handleError(c *gin.Context, err error, details string) {
+	catalogued, status := classify(err)
+
+	if status >= http.StatusInternalServerError {
+		requestLogger(c).Error("request failed", zap.Error(err))
+	} else {
+		requestLogger(c).Info("request rejected", zap.Error(err))
+	}
+
+	var fields []entity.FieldError
+
+	var validationErr *entity.ValidationError
+	if errors.As(err, &validationErr) {
+		fields = validationErr.Fields
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), problemJSON) {
+		c.Header("Content-Type", problemJSON)
+		c.AbortWithStatusJSON(status, problem{
+			Type:     catalogued.Error(),
+			Title:    http.StatusText(status),
+			Status:   status,
+			Detail:   details,
+			Instance: c.Request.URL.Path,
+			Errors:   fields,
+		})
+
+		return
+	}
+
+	c.AbortWithStatusJSON(status, responseError{
+		Code:    status,
+		Message: catalogued.Error(),
+		Details: details,
+		Fields:  fields,
+	})
+}
+
+// validationError converts validator output into the entity representation.
+func validationError(err error) error {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+
+	fields := make([]entity.FieldError, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, entity.FieldError{
+			Field: e.Field(),
+			Rule:  e.Tag(),
+			Param: e.Param(),
+		})
+	}
+
+	return entity.NewValidationError(fields...)
+}
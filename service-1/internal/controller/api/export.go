@@ -0,0 +1,307 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/pkg/xlsx"
+	"go.uber.org/zap"
+)
+
+const (
+	exportRowsTrailer  = "X-Export-Rows"
+	exportErrorTrailer = "X-Export-Error"
+	// exportFlushEvery bounds how many rows sit in buffers between flushes
+	exportFlushEvery = 500
+)
+
+var exportHeader = []string{
+	"id", "project_id", "organization_id", "name", "description", "priority", "removed", "created_at",
+	"tags", "category_id", "attributes", "visible_from", "visible_until",
+}
+
+// exportRow lays out a good in exportHeader order. Tags and attributes are
+// JSON; unset optional fields are empty strings.
+func exportRow(good entity.Good) []any {
+	tags := good.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	tagsJSON, _ := json.Marshal(tags)
+
+	attributes := string(good.Attributes)
+	if attributes == "" {
+		attributes = "{}"
+	}
+
+	var categoryID any = ""
+	if good.CategoryID != nil {
+		categoryID = *good.CategoryID
+	}
+
+	return []any{
+		good.ID,
+		good.ProjectID,
+		good.OrganizationID,
+		good.Name,
+		good.Description,
+		good.Priority,
+		good.Removed,
+		good.CreatedAt.Format(time.RFC3339),
+		string(tagsJSON),
+		categoryID,
+		attributes,
+		formatOptionalTime(good.VisibleFrom),
+		formatOptionalTime(good.VisibleUntil),
+	}
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// csvCell formats a cell for CSV. Text starting like a formula is prefixed
+// with an apostrophe so spreadsheets show it instead of evaluating it.
+func csvCell(cell any) string {
+	switch v := cell.(type) {
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	return fmt.Sprint(cell)
+}
+
+// exportWriter encodes goods in one of the export formats.
+type exportWriter interface {
+	Write(good entity.Good) error
+	Flush() error
+	Close() error
+}
+
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+	"xlsx":   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+func newExportWriter(format string, c *gin.Context) (exportWriter, error) {
+	switch format {
+	case "csv":
+		w := csv.NewWriter(c.Writer)
+
+		return &csvExport{w: w}, w.Write(exportHeader)
+	case "ndjson":
+		return &ndjsonExport{enc: json.NewEncoder(c.Writer), c: c}, nil
+	case "xlsx":
+		w, err := xlsx.NewWriter(c.Writer, "Goods")
+		if err != nil {
+			return nil, err
+		}
+
+		header := make([]any, len(exportHeader))
+		for i, column := range exportHeader {
+			header[i] = column
+		}
+
+		return &xlsxExport{w: w}, w.WriteRow(header...)
+	}
+
+	return nil, entity.NewQueryError(entity.FieldError{Field: "format", Rule: "oneof", Param: "csv ndjson xlsx"})
+}
+
+type csvExport struct {
+	w *csv.Writer
+}
+
+func (e *csvExport) Write(good entity.Good) error {
+	row := exportRow(good)
+
+	record := make([]string, len(row))
+	for i, cell := range row {
+		record[i] = csvCell(cell)
+	}
+
+	return e.w.Write(record)
+}
+
+func (e *csvExport) Flush() error {
+	e.w.Flush()
+
+	return e.w.Error()
+}
+
+func (e *csvExport) Close() error {
+	return e.Flush()
+}
+
+type ndjsonExport struct {
+	enc *json.Encoder
+	c   *gin.Context
+}
+
+func (e *ndjsonExport) Write(good entity.Good) error {
+	return e.enc.Encode(good)
+}
+
+func (e *ndjsonExport) Flush() error {
+	e.c.Writer.Flush()
+
+	return nil
+}
+
+func (e *ndjsonExport) Close() error {
+	return e.Flush()
+}
+
+type xlsxExport struct {
+	w *xlsx.Writer
+}
+
+// Write needs no formula guard: inline strings are never evaluated.
+func (e *xlsxExport) Write(good entity.Good) error {
+	return e.w.WriteRow(exportRow(good)...)
+}
+
+func (e *xlsxExport) Flush() error {
+	return e.w.Flush()
+}
+
+func (e *xlsxExport) Close() error {
+	return e.w.Close()
+}
+
+// exportGoodsHandler streams the project's goods. Failures after the first
+// byte cannot change the status, so the outcome is also reported in the
+// X-Export-Rows and X-Export-Error trailers.
+func (g ginController) exportGoodsHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	list, err := parseListFilter(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	filter := entity.ExportFilter{ListFilter: list}
+	filter.ProjectID = projectID
+
+	if filter.Offset, err = parseQueryParamAtoi(c, "offset", 1); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if filter.Limit, err = parseQueryParamAtoi(c, "limit", 0); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if filter.IncludeRemoved, err = parseQueryParamBool(c, "include_removed"); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		handleError(c, entity.NewQueryError(entity.FieldError{Field: "format", Rule: "oneof", Param: "csv ndjson xlsx"}), "")
+
+		return
+	}
+
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		requestLogger(c).Warn("Write deadline not cleared", zap.Error(err))
+	}
+
+	var (
+		w    exportWriter
+		rows int
+	)
+
+	// The writer is created on the first good so that errors found before
+	// it, such as an unknown project, still get a proper status
+	err = g.service.Good.Export(c.Request.Context(), projectID, filter, func(good entity.Good) error {
+		if w == nil {
+			if w, err = g.startExport(c, format, contentType, projectID); err != nil {
+				return err
+			}
+		}
+
+		if err := w.Write(good); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushEvery == 0 {
+			return w.Flush()
+		}
+
+		return nil
+	})
+
+	if w == nil {
+		if err != nil {
+			handleError(c, err, "")
+
+			return
+		}
+
+		// An empty export still has a header
+		if w, err = g.startExport(c, format, contentType, projectID); err != nil {
+			requestLogger(c).Error("Export failed", zap.Error(err))
+
+			return
+		}
+	}
+
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+
+	c.Writer.Header().Set(exportRowsTrailer, strconv.Itoa(rows))
+
+	if err != nil {
+		catalogued, _ := classify(err)
+		c.Writer.Header().Set(exportErrorTrailer, catalogued.Error())
+
+		requestLogger(c).Error("Export aborted", zap.Int("rows", rows), zap.Error(err))
+	}
+}
+
+func (g ginController) startExport(c *gin.Context, format, contentType string, projectID int) (exportWriter, error) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="goods-%d.%s"`, projectID, format))
+	c.Header("Trailer", exportRowsTrailer+", "+exportErrorTrailer)
+	c.Status(http.StatusOK)
+
+	return newExportWriter(format, c)
+}
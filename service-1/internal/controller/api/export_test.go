@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+func TestCSVCell(t *testing.T) {
+	for cell, want := range map[any]string{
+		"Tea":               "Tea",
+		"":                  "",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1":                "'+1",
+		"-1":                "'-1",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"\tcmd":             "'\tcmd",
+		"\rcmd":             "'\rcmd",
+		"a=b":               "a=b",
+		-5:                  "-5",
+		true:                "true",
+	} {
+		if got := csvCell(cell); got != want {
+			t.Errorf("csvCell(%q) = %q, want %q", cell, got, want)
+		}
+	}
+}
+
+func TestCSVExportWritesEveryField(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	from := created.Add(time.Hour)
+	category := 4
+
+	good := entity.Good{
+		ID:             1,
+		ProjectID:      2,
+		OrganizationID: 3,
+		Name:           "Tea",
+		Description:    "=cmd()",
+		Priority:       5,
+		CreatedAt:      created,
+		CategoryID:     &category,
+		Tags:           []string{"hot", "a,b"},
+		Attributes:     json.RawMessage(`{"size":"L"}`),
+		Visibility:     entity.Visibility{VisibleFrom: &from},
+	}
+
+	var buf strings.Builder
+
+	w := csv.NewWriter(&buf)
+	e := &csvExport{w: w}
+
+	if err := w.Write(exportHeader); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Write(good); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if err := e.Write(entity.Good{ID: 2, CreatedAt: created}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not CSV: %v", err)
+	}
+
+	want := [][]string{
+		exportHeader,
+		{"1", "2", "3", "Tea", "'=cmd()", "5", "false", "2024-05-01T12:00:00Z",
+			`["hot","a,b"]`, "4", `{"size":"L"}`, "2024-05-01T13:00:00Z", ""},
+		{"2", "0", "0", "", "", "0", "false", "2024-05-01T12:00:00Z",
+			"[]", "", "{}", "", ""},
+	}
+
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+}
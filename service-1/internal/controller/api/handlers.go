@@ -9,52 +9,55 @@ import (
 	"github.com/skantay/hezzl/internal/schemas"
 )
 
-// Internal Server Error
-var ErrISE = errors.New(http.StatusText(http.StatusInternalServerError))
-
-// Bad Request
-var ErrBR = errors.New(http.StatusText(http.StatusBadRequest))
-
 func (g ginController) createGoodHandler(c *gin.Context) {
 	projectID, err := parseQueryParamAtoi(c, "projectID", -1)
 	if err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+		handleError(c, err, "")
 
 		return
 	}
 
 	if projectID < 0 {
-		handleError(c, "project id is invalid", http.StatusBadRequest, ErrBR)
+		handleError(c, entity.NewQueryError(entity.FieldError{Field: "projectID", Rule: "required"}), "project id is invalid")
 
 		return
 	}
 
-	var request schemas.CreateRequest
-
-	if err := c.BindJSON(&request); err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+	changesetID, err := parseChangesetQuery(c)
+	if err != nil {
+		handleError(c, err, "")
 
 		return
 	}
 
-	if err := g.validator.Struct(request); err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+	var request schemas.CreateRequest
+
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
 
 		return
 	}
 
-	good, err := g.service.Good.Create(c.Request.Context(), projectID, request.Name)
-	if err != nil && !errors.Is(err, entity.ErrProjectNotFound) {
-		g.log.Sugar().Errorf("%v", err)
-		handleError(c, "", http.StatusInternalServerError, ErrISE)
+	input := entity.GoodInput{
+		Name:       request.Name,
+		CategoryID: request.CategoryID,
+		Attributes: request.Attributes,
+		Visibility: request.Visibility,
+	}
+	if request.Tags != nil {
+		input.Tags = &request.Tags
+	}
+
+	if changesetID != 0 {
+		change, err := g.service.Changeset.StageCreate(c.Request.Context(), changesetID, projectID, input)
+		respondStaged(c, change, err)
 
 		return
-	} else if errors.Is(err, entity.ErrProjectNotFound) {
-		g.log.Sugar().Errorf("%v", err)
-		handleError(c, "", http.StatusNotFound, entity.ErrProjectNotFound)
+	}
+
+	good, err := g.service.Good.Create(c.Request.Context(), projectID, input)
+	if err != nil {
+		handleError(c, err, "")
 
 		return
 	}
@@ -65,38 +68,43 @@ func (g ginController) createGoodHandler(c *gin.Context) {
 func (g ginController) goodsListHandler(c *gin.Context) {
 	limit, err := parseQueryParamAtoi(c, "limit", 10)
 	if err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+		handleError(c, err, "")
 
 		return
 	}
 
 	offset, err := parseQueryParamAtoi(c, "offset", 1)
 	if err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+		handleError(c, err, "")
 
 		return
 	}
 
 	if offset < 1 || limit < 1 {
-		g.log.Sugar().Errorf("invalid numbers %d and %d", offset, limit)
-		handleError(c, "offset and limit are invalid", http.StatusBadRequest, ErrBR)
+		handleError(c, entity.NewQueryError(
+			entity.FieldError{Field: "offset", Rule: "min", Param: "1"},
+			entity.FieldError{Field: "limit", Rule: "min", Param: "1"},
+		), "offset and limit are invalid")
 
 		return
 	}
 
-	var response schemas.ListResponse
-
-	goods, err := g.service.Good.List(c, limit, offset)
+	filter, err := parseListFilter(c)
 	if err != nil {
-		g.log.Error(err.Error())
+		handleError(c, err, "")
 
-		if !errors.Is(err, entity.ErrGoodNotFound) {
-			handleError(c, "", http.StatusInternalServerError, ErrISE)
+		return
+	}
 
-			return
-		}
+	filter.Limit, filter.Offset = limit, offset
+
+	var response schemas.ListResponse
+
+	goods, err := g.service.Good.List(c.Request.Context(), filter)
+	if err != nil && !errors.Is(err, entity.ErrGoodNotFound) {
+		handleError(c, err, "")
+
+		return
 	}
 
 	var removed int
@@ -109,7 +117,7 @@ func (g ginController) goodsListHandler(c *gin.Context) {
 		}
 	}
 
-	if len(goods) == 0 {
+	if len(response.Goods) == 0 {
 		response.Goods = []struct{ entity.Good }{}
 	}
 
@@ -119,63 +127,44 @@ func (g ginController) goodsListHandler(c *gin.Context) {
 
 	response.Meta.Removed = removed
 
-	response.Meta.Total = len(goods)
+	response.Meta.Total = len(response.Goods)
 
 	c.JSON(http.StatusOK, response)
 }
 
 func (g ginController) reprioritizeGoodHandler(c *gin.Context) {
-	id, err := parseQueryParamAtoi(c, "id", -1)
+	id, projectID, err := parseGoodQuery(c)
 	if err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+		handleError(c, err, "ID or project ID invalid")
 
 		return
 	}
 
-	projectID, err := parseQueryParamAtoi(c, "projectID", -1)
+	changesetID, err := parseChangesetQuery(c)
 	if err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
-
-		return
-	}
-
-	if id < 0 || projectID < 0 {
-		g.log.Sugar().Errorf("invalid numbers %d and %d", id, projectID)
-		handleError(c, "ID or project ID invalid", http.StatusBadRequest, ErrBR)
+		handleError(c, err, "")
 
 		return
 	}
 
 	var request schemas.UpdatePriorityRequest
 
-	if err := c.BindJSON(&request); err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
 
 		return
 	}
 
-	if err := g.validator.Struct(request); err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+	if changesetID != 0 {
+		change, err := g.service.Changeset.StageReprioritize(c.Request.Context(), changesetID, request.NewPriority, id, projectID)
+		respondStaged(c, change, err)
 
 		return
 	}
 
 	goods, err := g.service.Good.Reprioritiize(c.Request.Context(), request.NewPriority, id, projectID)
 	if err != nil {
-		if errors.Is(err, entity.ErrGoodNotFound) {
-
-			g.log.Error(err.Error())
-			handleError(c, "", http.StatusNotFound, entity.ErrGoodNotFound)
-
-			return
-		}
-
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusInternalServerError, ErrISE)
+		handleError(c, err, "")
 
 		return
 	}
@@ -196,40 +185,30 @@ func (g ginController) reprioritizeGoodHandler(c *gin.Context) {
 }
 
 func (g ginController) removeGoodHandler(c *gin.Context) {
-	id, err := parseQueryParamAtoi(c, "id", -1)
+	id, projectID, err := parseGoodQuery(c)
 	if err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+		handleError(c, err, "ID or project ID invalid")
 
 		return
 	}
 
-	projectID, err := parseQueryParamAtoi(c, "projectID", -1)
+	changesetID, err := parseChangesetQuery(c)
 	if err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+		handleError(c, err, "")
 
 		return
 	}
 
-	if id < 0 || projectID < 0 {
-		g.log.Sugar().Errorf("invalid numbers %d and %d", id, projectID)
-		handleError(c, "ID or project ID invalid", http.StatusBadRequest, ErrBR)
+	if changesetID != 0 {
+		change, err := g.service.Changeset.StageRemove(c.Request.Context(), changesetID, id, projectID)
+		respondStaged(c, change, err)
 
 		return
 	}
 
 	good, err := g.service.Good.Delete(c.Request.Context(), id, projectID)
 	if err != nil {
-		if errors.Is(err, entity.ErrGoodNotFound) {
-
-			g.log.Error(err.Error())
-			handleError(c, "", http.StatusNotFound, entity.ErrGoodNotFound)
-
-			return
-		}
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusInternalServerError, ErrISE)
+		handleError(c, err, "")
 
 		return
 	}
@@ -243,73 +222,66 @@ func (g ginController) removeGoodHandler(c *gin.Context) {
 	c.JSON(http.StatusAccepted, response)
 }
 
-func (g ginController) updateGoodHandler(c *gin.Context) {
-	id, err := parseQueryParamAtoi(c, "id", -1)
+func (g ginController) restoreGoodHandler(c *gin.Context) {
+	id, projectID, err := parseGoodQuery(c)
 	if err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+		handleError(c, err, "ID or project ID invalid")
 
 		return
 	}
 
-	projectID, err := parseQueryParamAtoi(c, "projectID", -1)
+	good, err := g.service.Good.Restore(c.Request.Context(), id, projectID)
 	if err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+		handleError(c, err, "")
 
 		return
 	}
 
-	if id < 0 || projectID < 0 {
-		g.log.Sugar().Errorf("invalid numbers %d and %d", id, projectID)
-		handleError(c, "ID or project ID invalid", http.StatusBadRequest, ErrBR)
+	c.JSON(http.StatusOK, good)
+}
+
+func (g ginController) updateGoodHandler(c *gin.Context) {
+	id, projectID, err := parseGoodQuery(c)
+	if err != nil {
+		handleError(c, err, "ID or project ID invalid")
 
 		return
 	}
 
-	var request schemas.UpdateGoodRequest
-
-	if err := c.BindJSON(&request); err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+	changesetID, err := parseChangesetQuery(c)
+	if err != nil {
+		handleError(c, err, "")
 
 		return
 	}
 
-	if err := g.validator.Struct(request); err != nil {
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusBadRequest, ErrBR)
+	var request schemas.UpdateGoodRequest
+
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
 
 		return
 	}
 
-	if request.Name == "" {
-		g.log.Error("empty payload name")
-		handleError(c, "", http.StatusBadRequest, ErrBR)
-
-		return
+	input := entity.GoodInput{
+		Name:        request.Name,
+		Description: request.Description,
+		Tags:        request.Tags,
+		CategoryID:  request.CategoryID,
+		Attributes:  request.Attributes,
+		Visibility:  request.Visibility,
 	}
 
-	emptyDesc := false
+	if changesetID != 0 {
+		change, err := g.service.Changeset.StageUpdate(c.Request.Context(), changesetID, id, projectID, input)
+		respondStaged(c, change, err)
 
-	if request.Description == nil {
-		emptyDesc = true
-		word := ""
-		request.Description = &word
+		return
 	}
 
-	good, err := g.service.Good.Update(c.Request.Context(), id, projectID, request.Name, *request.Description, emptyDesc)
+	good, err := g.service.Good.Update(c.Request.Context(), id, projectID, input)
 	if err != nil {
-		if errors.Is(err, entity.ErrGoodNotFound) {
-
-			g.log.Error(err.Error())
-			handleError(c, "", http.StatusNotFound, entity.ErrGoodNotFound)
-
-			return
-		}
-
-		g.log.Error(err.Error())
-		handleError(c, "", http.StatusInternalServerError, ErrISE)
+		handleError(c, err, "")
 
 		return
 	}
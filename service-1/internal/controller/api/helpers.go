@@ -1,17 +1,14 @@
 package api
 
 import (
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/internal/entity"
 )
 
-type responseError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Details string `json:"details"`
-}
-
 func parseQueryParamAtoi(c *gin.Context, paramName string, defaultValue int) (int, error) {
 	value := c.Query(paramName)
 	if value == "" {
@@ -19,23 +16,82 @@ func parseQueryParamAtoi(c *gin.Context, paramName string, defaultValue int) (in
 	}
 	intValue, err := strconv.Atoi(value)
 	if err != nil {
-		return 0, err
+		return 0, entity.NewQueryError(entity.FieldError{Field: paramName, Rule: "integer"})
 	}
 	return intValue, nil
 }
 
-func handleError(c *gin.Context, details string, code int, err error) {
-	var msg string
+// parseGoodQuery reads the id and projectID query parameters that address a
+// single good.
+func parseGoodQuery(c *gin.Context) (int, int, error) {
+	id, err := parseQueryParamAtoi(c, "id", -1)
+	if err != nil {
+		return 0, 0, err
+	}
 
+	projectID, err := parseQueryParamAtoi(c, "projectID", -1)
 	if err != nil {
-		msg = err.Error()
+		return 0, 0, err
+	}
+
+	var fields []entity.FieldError
+
+	if id < 0 {
+		fields = append(fields, entity.FieldError{Field: "id", Rule: "required"})
+	}
+
+	if projectID < 0 {
+		fields = append(fields, entity.FieldError{Field: "projectID", Rule: "required"})
+	}
+
+	if len(fields) != 0 {
+		return 0, 0, entity.NewQueryError(fields...)
+	}
+
+	return id, projectID, nil
+}
+
+// bindJSON decodes the request body into dst and runs the validator on it.
+func (g ginController) bindJSON(c *gin.Context, dst any) error {
+	if err := c.ShouldBindJSON(dst); err != nil {
+		return fmt.Errorf("%w: %v", entity.ErrMalformedBody, err)
+	}
+
+	if err := g.validator.Struct(dst); err != nil {
+		return validationError(err)
 	}
 
-	resp := responseError{
-		Code:    code,
-		Message: msg,
-		Details: details,
+	return nil
+}
+
+// parseQueryParamTime parses an RFC 3339 timestamp, returning the zero time
+// when the parameter is absent.
+func parseQueryParamTime(c *gin.Context, paramName string) (time.Time, error) {
+	value := c.Query(paramName)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, entity.NewQueryError(entity.FieldError{Field: paramName, Rule: "rfc3339"})
+	}
+
+	return t, nil
+}
+
+// parseQueryParamBool parses a boolean flag, returning false when the
+// parameter is absent.
+func parseQueryParamBool(c *gin.Context, paramName string) (bool, error) {
+	value := c.Query(paramName)
+	if value == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, entity.NewQueryError(entity.FieldError{Field: paramName, Rule: "boolean"})
 	}
 
-	c.JSON(code, resp)
+	return b, nil
 }
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// importFormat picks the decoder from the format parameter, falling back to
+// the Content-Type.
+func importFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+
+	switch mediaType {
+	case "text/csv":
+		return "csv"
+	case "application/x-ndjson", "application/jsonl":
+		return "ndjson"
+	}
+
+	return ""
+}
+
+// parseColumnMap reads map=name:Title,description:Details.
+func parseColumnMap(c *gin.Context) (map[string]string, error) {
+	mapping := make(map[string]string)
+
+	value := c.Query("map")
+	if value == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		field, column, ok := strings.Cut(pair, ":")
+		if !ok || field == "" || column == "" {
+			return nil, entity.NewQueryError(entity.FieldError{Field: "map", Rule: "format", Param: "field:column"})
+		}
+
+		mapping[strings.ToLower(strings.TrimSpace(field))] = strings.TrimSpace(column)
+	}
+
+	return mapping, nil
+}
+
+func (g ginController) importGoodsHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var opts entity.ImportOptions
+
+	if opts.DryRun, err = parseQueryParamBool(c, "dry_run"); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if opts.Upsert, err = parseQueryParamBool(c, "upsert"); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if opts.ChunkSize, err = parseQueryParamAtoi(c, "chunk", g.cfg.Import.ChunkSize); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	// Large files take longer than the server's read and write timeouts
+	rc := http.NewResponseController(c.Writer)
+	if err := errors.Join(rc.SetReadDeadline(time.Time{}), rc.SetWriteDeadline(time.Time{})); err != nil {
+		requestLogger(c).Warn("Deadlines not cleared", zap.Error(err))
+	}
+
+	if g.cfg.Import.MaxBytes > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, g.cfg.Import.MaxBytes)
+	}
+
+	var src usecase.ImportSource
+
+	switch format := importFormat(c); format {
+	case "csv":
+		mapping, err := parseColumnMap(c)
+		if err != nil {
+			handleError(c, err, "")
+
+			return
+		}
+
+		if src, err = usecase.NewCSVSource(c.Request.Body, mapping); err != nil {
+			handleError(c, err, "the first CSV record must be a header with a name column")
+
+			return
+		}
+	case "ndjson":
+		src = usecase.NewNDJSONSource(c.Request.Body)
+	default:
+		handleError(c, entity.NewQueryError(entity.FieldError{Field: "format", Rule: "oneof", Param: "csv ndjson"}), "")
+
+		return
+	}
+
+	report, err := g.service.Good.Import(c.Request.Context(), projectID, src, opts)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err = fmt.Errorf("%w: %v", entity.ErrBodyTooLarge, err)
+		}
+
+		if report.Total == 0 {
+			handleError(c, err, "")
+
+			return
+		}
+
+		// Committed chunks cannot be undone, so the client gets the partial report
+		requestLogger(c).Warn("Import aborted", zap.Int("rows", report.Total), zap.Error(err))
+
+		catalogued, _ := classify(err)
+		report.Aborted = catalogued.Error()
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/pkg/metrics"
+	"github.com/skantay/hezzl/pkg/requestid"
+	"go.uber.org/zap"
+)
+
+const loggerKey = "logger"
+
+func metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		// Unmatched routes are grouped together to keep the label cardinality bounded
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := strconv.Itoa(c.Writer.Status())
+
+		metrics.HTTPRequests.WithLabelValues(route, c.Request.Method, status).Inc()
+		metrics.HTTPDuration.WithLabelValues(route, c.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// requestIDMiddleware accepts or generates X-Request-ID, echoes it back and
+// attaches it to the request context and to every log line of the request.
+func requestIDMiddleware(log *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if id == "" || len(id) > 128 {
+			id = requestid.New()
+		}
+
+		c.Header(requestid.Header, id)
+		ctx := requestid.WithID(c.Request.Context(), id)
+		c.Request = c.Request.WithContext(requestid.WithClientIP(ctx, c.ClientIP()))
+		c.Set(loggerKey, log.With(zap.String("request_id", id)))
+
+		c.Next()
+	}
+}
+
+// accessLogMiddleware replaces gin's text logger with a structured one.
+func accessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		requestLogger(c).Info("request",
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// requestLogger returns the logger bound to the current request.
+func requestLogger(c *gin.Context) *zap.Logger {
+	if log, ok := c.Get(loggerKey); ok {
+		return log.(*zap.Logger)
+	}
+
+	return zap.L()
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/config"
+	"github.com/skantay/hezzl/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// undocumented routes serve the documentation itself.
+var undocumented = map[string]bool{
+	"GET /openapi.json": true,
+	"GET /docs":         true,
+}
+
+// TestOpenAPIMatchesRouter fails when a route is added to the router without
+// documenting it, or documented without being served.
+func TestOpenAPIMatchesRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var cfg config.Config
+	cfg.Server.SwaggerUI = true
+	cfg.Auth.APIKeys = true
+	cfg.Auth.AdminToken = "token"
+	cfg.RateLimit.Enabled = true
+
+	service := usecase.Service{Auth: usecase.NewAuthUsecase(nil, cfg.Auth.APIKeys, nil, "", "", nil)}
+
+	g := ginController{service: service, cfg: cfg, log: zap.NewNop()}
+
+	router, err := g.router()
+	if err != nil {
+		t.Fatalf("router() error = %v", err)
+	}
+
+	served := make(map[string]bool)
+	for _, route := range router.Routes() {
+		key := route.Method + " " + route.Path
+		if !undocumented[key] {
+			served[key] = true
+		}
+	}
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+
+	documented := make(map[string]bool)
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	if missing := difference(served, documented); len(missing) != 0 {
+		t.Errorf("routes missing from openapi.json: %v", missing)
+	}
+
+	if stale := difference(documented, served); len(stale) != 0 {
+		t.Errorf("openapi.json documents routes the router does not serve: %v", stale)
+	}
+}
+
+func difference(a, b map[string]bool) []string {
+	var keys []string
+	for key := range a {
+		if !b[key] {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/internal/schemas"
+)
+
+func (g ginController) cloneProjectHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var request schemas.CloneProjectRequest
+
+	// The body is optional
+	if c.Request.ContentLength != 0 {
+		if err := g.bindJSON(c, &request); err != nil {
+			handleError(c, err, "")
+
+			return
+		}
+	}
+
+	clone, err := g.service.Project.Clone(c.Request.Context(), projectID, request.Name)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, clone)
+}
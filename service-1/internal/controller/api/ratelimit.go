@@ -0,0 +1,81 @@
+package api
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/config"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/pkg/ratelimit"
+)
+
+// ipRateLimitMiddleware runs before authentication and limits every request
+// by client IP, so guessing credentials is limited as well.
+func (g ginController) ipRateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if g.limit(c, "ratelimit:ip:"+c.ClientIP(), g.cfg.RateLimit.PerIP) {
+			c.Next()
+		}
+	}
+}
+
+// rateLimitMiddleware applies the token bucket configured for the route to
+// the authenticated principal, or to the client IP when auth is off.
+func (g ginController) rateLimitMiddleware() gin.HandlerFunc {
+	routes := make(map[string]config.RouteLimit, len(g.cfg.RateLimit.Routes))
+	for _, route := range g.cfg.RateLimit.Routes {
+		routes[route.Route] = route
+	}
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+
+		limit, ok := routes[route]
+		if !ok {
+			limit = g.cfg.RateLimit.Default
+		}
+
+		client := "ip:" + c.ClientIP()
+		if principal, ok := principalFrom(c); ok {
+			client = principal.Subject
+		}
+
+		key := "ratelimit:" + route + ":" + client
+		if limit.PerProject {
+			key += ":project:" + c.Query("projectID")
+		}
+
+		if g.limit(c, key, limit) {
+			c.Next()
+		}
+	}
+}
+
+// limit counts the request against the bucket under key and reports whether
+// it may proceed, answering 429 otherwise.
+func (g ginController) limit(c *gin.Context, key string, limit config.RouteLimit) bool {
+	if limit.Rate <= 0 || limit.Burst <= 0 {
+		return true
+	}
+
+	result := g.limiter.Allow(c.Request.Context(), key, ratelimit.Limit{Rate: limit.Rate, Burst: limit.Burst})
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", ceilSeconds(result.Reset))
+
+	if !result.Allowed {
+		c.Header("Retry-After", ceilSeconds(result.RetryAfter))
+		handleError(c, entity.ErrRateLimited, "")
+
+		return false
+	}
+
+	return true
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/config"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/usecase"
+	"github.com/skantay/hezzl/pkg/ratelimit"
+	"go.uber.org/zap"
+)
+
+// rejectingKeys turns every API key down, as for a caller guessing keys.
+type rejectingKeys struct {
+	usecase.APIKeyUsecase
+}
+
+func (rejectingKeys) Authenticate(ctx context.Context, raw string) (entity.Principal, error) {
+	return entity.Principal{}, entity.ErrUnauthorized
+}
+
+// TestRateLimitCountsRejectedCredentials guards the order of the middleware:
+// failed authentications must use up the client IP's bucket.
+func TestRateLimitCountsRejectedCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var cfg config.Config
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.PerIP = config.RouteLimit{Rate: 0.001, Burst: 2}
+
+	service := usecase.Service{Auth: usecase.NewAuthUsecase(rejectingKeys{}, true, nil, "", "", nil)}
+
+	g := ginController{service: service, cfg: cfg, log: zap.NewNop(), limiter: ratelimit.NewMemory()}
+	router, err := g.router()
+	if err != nil {
+		t.Fatalf("router() error = %v", err)
+	}
+
+	want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}
+
+	for i, status := range want {
+		request := httptest.NewRequest(http.MethodGet, "/goods/list?projectID=1", nil)
+		request.Header.Set(apiKeyHeader, "guess")
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != status {
+			t.Fatalf("attempt %d: got status %d, want %d", i+1, recorder.Code, status)
+		}
+	}
+}
+
+// TestIPRateLimitIgnoresSpoofedForwardedFor makes sure a client cannot get a
+// fresh bucket by sending another X-Forwarded-For, unless it comes through
+// a trusted proxy.
+func TestIPRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		want           []int
+	}{
+		{
+			name: "no trusted proxies",
+			want: []int{http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusTooManyRequests},
+		},
+		{
+			// httptest requests come from 192.0.2.1
+			name:           "trusted proxy",
+			trustedProxies: []string{"192.0.2.0/24"},
+			want:           []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config.Config
+			cfg.Server.TrustedProxies = tt.trustedProxies
+			cfg.RateLimit.Enabled = true
+			cfg.RateLimit.PerIP = config.RouteLimit{Rate: 0.001, Burst: 1}
+
+			service := usecase.Service{Auth: usecase.NewAuthUsecase(rejectingKeys{}, true, nil, "", "", nil)}
+
+			g := ginController{service: service, cfg: cfg, log: zap.NewNop(), limiter: ratelimit.NewMemory()}
+
+			router, err := g.router()
+			if err != nil {
+				t.Fatalf("router() error = %v", err)
+			}
+
+			for i, status := range tt.want {
+				request := httptest.NewRequest(http.MethodGet, "/goods/list?projectID=1", nil)
+				request.Header.Set(apiKeyHeader, "guess")
+				request.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i+1))
+
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, request)
+
+				if recorder.Code != status {
+					t.Fatalf("attempt %d: got status %d, want %d", i+1, recorder.Code, status)
+				}
+			}
+		})
+	}
+}
+
+func TestRouterRejectsInvalidTrustedProxies(t *testing.T) {
+	var cfg config.Config
+	cfg.Server.TrustedProxies = []string{"not an address"}
+
+	g := ginController{service: usecase.Service{Auth: usecase.NewAuthUsecase(nil, false, nil, "", "", nil)}, cfg: cfg, log: zap.NewNop()}
+
+	if _, err := g.router(); err == nil {
+		t.Error("router() error = nil, want the invalid proxy reported")
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/internal/schemas"
+)
+
+func (g ginController) revisionsListHandler(c *gin.Context) {
+	id, projectID, err := parseGoodQuery(c)
+	if err != nil {
+		handleError(c, err, "ID or project ID invalid")
+
+		return
+	}
+
+	limit, err := parseQueryParamAtoi(c, "limit", 20)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	offset, err := parseQueryParamAtoi(c, "offset", 0)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	revisions, total, err := g.service.Revision.List(c.Request.Context(), id, projectID, limit, offset)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var response schemas.RevisionListResponse
+
+	response.Meta.Total = total
+	response.Meta.Limit = limit
+	response.Meta.Offset = offset
+	response.Revisions = revisions
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (g ginController) revisionsDiffHandler(c *gin.Context) {
+	id, projectID, err := parseGoodQuery(c)
+	if err != nil {
+		handleError(c, err, "ID or project ID invalid")
+
+		return
+	}
+
+	from, err := parseQueryParamAtoi(c, "from", -1)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	to, err := parseQueryParamAtoi(c, "to", -1)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	diff, err := g.service.Revision.Diff(c.Request.Context(), id, projectID, from, to)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+func (g ginController) rollbackGoodHandler(c *gin.Context) {
+	id, projectID, err := parseGoodQuery(c)
+	if err != nil {
+		handleError(c, err, "ID or project ID invalid")
+
+		return
+	}
+
+	var request schemas.RollbackGoodRequest
+
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	good, err := g.service.Good.Rollback(c.Request.Context(), id, projectID, request.Revision)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, good)
+}
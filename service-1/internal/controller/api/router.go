@@ -7,10 +7,15 @@ import (
 	"time"
 
 	"github.com/skantay/hezzl/config"
+	"github.com/skantay/hezzl/internal/controller/mq/nats/v"
+	"github.com/skantay/hezzl/internal/entity"
 	"github.com/skantay/hezzl/internal/usecase"
+	"github.com/skantay/hezzl/pkg/metrics"
+	"github.com/skantay/hezzl/pkg/ratelimit"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 )
 
@@ -23,6 +28,8 @@ type ginController struct {
 	log       *zap.Logger
 	cfg       config.Config
 	validator *validator.Validate
+	limiter   ratelimit.Limiter
+	feed      *v.Feed
 }
 
 func New(
@@ -30,23 +37,24 @@ func New(
 	log *zap.Logger,
 	cfg config.Config,
 	validator *validator.Validate,
+	limiter ratelimit.Limiter,
+	feed *v.Feed,
 ) Controller {
 	return ginController{
 		service:   service,
 		log:       log,
 		cfg:       cfg,
 		validator: validator,
+		limiter:   limiter,
+		feed:      feed,
 	}
 }
 
 func (g ginController) Serve(ctx context.Context) error {
-	r := gin.Default()
-
-	r.GET("/goods/list", g.goodsListHandler)
-	r.PATCH("/good/reprioritize", g.reprioritizeGoodHandler)
-	r.PATCH("/good/update", g.updateGoodHandler)
-	r.DELETE("/good/remove", g.removeGoodHandler)
-	r.POST("/good/create", g.createGoodHandler)
+	r, err := g.router()
+	if err != nil {
+		return err
+	}
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", g.cfg.Server.Port),
@@ -67,3 +75,101 @@ func (g ginController) Serve(ctx context.Context) error {
 
 	return nil
 }
+
+// router registers every route. Routes must stay in sync with openapi.json.
+func (g ginController) router() (*gin.Engine, error) {
+	r := gin.New()
+
+	// gin trusts every proxy by default, which would let any client pick
+	// its own IP with X-Forwarded-For
+	if err := r.SetTrustedProxies(g.cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("trusted proxies error: %w", err)
+	}
+
+	r.Use(
+		gin.Recovery(),
+		requestIDMiddleware(g.log),
+		accessLogMiddleware(),
+		otelgin.Middleware(g.cfg.Tracing.ServiceName),
+		metricsMiddleware(),
+	)
+
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	r.GET("/openapi.json", openAPIHandler)
+
+	if g.cfg.Server.SwaggerUI {
+		r.GET("/docs", swaggerUIHandler)
+	}
+
+	protected := r.Group("/")
+
+	// The IP limit goes first so that rejected credentials count too
+	if g.cfg.RateLimit.Enabled {
+		protected.Use(g.ipRateLimitMiddleware())
+	}
+
+	if g.service.Auth.Enabled() {
+		protected.Use(g.authMiddleware())
+	}
+
+	if g.cfg.RateLimit.Enabled {
+		protected.Use(g.rateLimitMiddleware())
+	}
+
+	protected.GET("/goods/list", requireRole(entity.RoleViewer), g.goodsListHandler)
+	protected.GET("/goods/stream", requireRole(entity.RoleViewer), g.goodsStreamHandler)
+	protected.GET("/goods/ws", requireRole(entity.RoleViewer), g.goodsWebSocketHandler)
+	protected.PATCH("/good/reprioritize", requireRole(entity.RoleEditor), g.reprioritizeGoodHandler)
+	protected.PATCH("/good/update", requireRole(entity.RoleEditor), g.updateGoodHandler)
+	protected.DELETE("/good/remove", requireRole(entity.RoleAdmin), g.removeGoodHandler)
+	protected.PATCH("/good/restore", requireRole(entity.RoleAdmin), g.restoreGoodHandler)
+	protected.GET("/good/revisions", requireRole(entity.RoleViewer), g.revisionsListHandler)
+	protected.GET("/good/revisions/diff", requireRole(entity.RoleViewer), g.revisionsDiffHandler)
+	protected.POST("/good/rollback", requireRole(entity.RoleEditor), g.rollbackGoodHandler)
+	protected.POST("/good/create", requireRole(entity.RoleEditor), g.createGoodHandler)
+	protected.POST("/goods/import", requireRole(entity.RoleEditor), g.importGoodsHandler)
+	protected.GET("/goods/export", requireRole(entity.RoleViewer), g.exportGoodsHandler)
+	protected.GET("/goods/search", requireRole(entity.RoleViewer), g.searchGoodsHandler)
+	protected.PATCH("/goods/tags", requireRole(entity.RoleEditor), g.retagGoodsHandler)
+	protected.PATCH("/goods/category", requireRole(entity.RoleEditor), g.recategorizeGoodsHandler)
+	protected.POST("/goods/move", requireRole(entity.RoleEditor), g.moveGoodsHandler)
+	protected.POST("/changesets", requireRole(entity.RoleEditor), g.createChangesetHandler)
+	protected.GET("/changesets", requireRole(entity.RoleEditor), g.changesetsListHandler)
+	protected.DELETE("/changesets", requireRole(entity.RoleEditor), g.discardChangesetHandler)
+	protected.GET("/changesets/preview", requireRole(entity.RoleEditor), g.previewChangesetHandler)
+	protected.POST("/changesets/publish", requireRole(entity.RoleEditor), g.publishChangesetHandler)
+	protected.POST("/categories", requireRole(entity.RoleAdmin), g.createCategoryHandler)
+	protected.GET("/categories", requireRole(entity.RoleViewer), g.categoriesListHandler)
+	protected.DELETE("/categories", requireRole(entity.RoleAdmin), g.deleteCategoryHandler)
+	protected.GET("/tags", requireRole(entity.RoleViewer), g.tagsListHandler)
+	protected.GET("/attributes/schema", requireRole(entity.RoleViewer), g.attributesSchemaHandler)
+	protected.PUT("/attributes/schema", requireRole(entity.RoleAdmin), g.setAttributesSchemaHandler)
+	protected.DELETE("/attributes/schema", requireRole(entity.RoleAdmin), g.deleteAttributesSchemaHandler)
+	protected.GET("/search/language", requireRole(entity.RoleViewer), g.searchLanguageHandler)
+	protected.PUT("/search/language", requireRole(entity.RoleAdmin), g.setSearchLanguageHandler)
+	protected.POST("/project/clone", requireRole(entity.RoleAdmin), g.cloneProjectHandler)
+	protected.GET("/audit", requireRole(entity.RoleAdmin), g.auditListHandler)
+	protected.POST("/webhooks", requireRole(entity.RoleAdmin), g.createWebhookHandler)
+	protected.GET("/webhooks", requireRole(entity.RoleAdmin), g.webhooksListHandler)
+	protected.DELETE("/webhooks", requireRole(entity.RoleAdmin), g.deleteWebhookHandler)
+	protected.GET("/webhooks/deliveries", requireRole(entity.RoleAdmin), g.webhookDeliveriesHandler)
+	protected.POST("/webhooks/deliveries/redeliver", requireRole(entity.RoleAdmin), g.redeliverWebhookHandler)
+
+	if g.cfg.Auth.AdminToken != "" {
+		admin := r.Group("/admin")
+		if g.cfg.RateLimit.Enabled {
+			admin.Use(g.ipRateLimitMiddleware())
+		}
+
+		admin.Use(g.adminMiddleware())
+
+		admin.POST("/apikeys", g.createAPIKeyHandler)
+		admin.GET("/apikeys", g.apiKeysListHandler)
+		admin.DELETE("/apikeys", g.revokeAPIKeyHandler)
+		admin.POST("/organizations", g.createOrganizationHandler)
+		admin.GET("/organizations", g.organizationsListHandler)
+		admin.PUT("/project/organization", g.assignProjectHandler)
+	}
+
+	return r, nil
+}
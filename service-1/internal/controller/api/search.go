@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/schemas"
+)
+
+func (g ginController) searchGoodsHandler(c *gin.Context) {
+	var (
+		query entity.SearchQuery
+		err   error
+	)
+
+	if query.ProjectID, err = parseProjectQuery(c); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if query.Limit, err = parseQueryParamAtoi(c, "limit", 20); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if query.Offset, err = parseQueryParamAtoi(c, "offset", 0); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	query.Query = c.Query("q")
+
+	hits, total, language, err := g.service.Search.Search(c.Request.Context(), query)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var response schemas.SearchResponse
+
+	response.Meta.Query = query.Query
+	response.Meta.Language = language
+	response.Meta.Total = total
+	response.Meta.Limit = query.Limit
+	response.Meta.Offset = query.Offset
+	response.Hits = hits
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (g ginController) searchLanguageHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	language, err := g.service.Project.SearchLanguage(c.Request.Context(), projectID)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, schemas.SearchLanguageResponse{Language: language})
+}
+
+func (g ginController) setSearchLanguageHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var request schemas.SearchLanguageRequest
+
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if err := g.service.Project.SetSearchLanguage(c.Request.Context(), projectID, request.Language); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	g.searchLanguageHandler(c)
+}
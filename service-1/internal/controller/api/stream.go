@@ -0,0 +1,213 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/skantay/hezzl/internal/controller/mq/nats/v"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/schemas"
+	"go.uber.org/zap"
+)
+
+const (
+	lastEventIDHeader = "Last-Event-ID"
+	defaultHeartbeat  = 15 * time.Second
+	wsWriteTimeout    = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{}
+
+// parseFeedQuery reads the project and the event id to resume after. Browsers
+// send Last-Event-ID on EventSource reconnects; WebSocket clients, which
+// cannot set headers, pass lastEventID instead.
+func parseFeedQuery(c *gin.Context) (int, uint64, error) {
+	projectID, err := parseQueryParamAtoi(c, "projectID", -1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if projectID < 0 {
+		return 0, 0, entity.NewQueryError(entity.FieldError{Field: "projectID", Rule: "required"})
+	}
+
+	value := c.GetHeader(lastEventIDHeader)
+	if value == "" {
+		value = c.Query("lastEventID")
+	}
+
+	if value == "" {
+		return projectID, 0, nil
+	}
+
+	lastID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, 0, entity.NewQueryError(entity.FieldError{Field: "lastEventID", Rule: "integer"})
+	}
+
+	return projectID, lastID, nil
+}
+
+func (g ginController) heartbeat() time.Duration {
+	if g.cfg.Feed.Heartbeat > 0 {
+		return g.cfg.Feed.Heartbeat
+	}
+
+	return defaultHeartbeat
+}
+
+func toGoodsEvent(event v.Event) schemas.GoodsEvent {
+	return schemas.GoodsEvent{
+		ID:     event.ID,
+		Action: event.Action,
+		Actor:  event.Actor,
+		Goods:  event.Goods,
+	}
+}
+
+// goodsStreamHandler pushes the project's change events as Server-Sent Events.
+func (g ginController) goodsStreamHandler(c *gin.Context) {
+	projectID, lastID, err := parseFeedQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		requestLogger(c).Warn("Write deadline not cleared", zap.Error(err))
+	}
+
+	backlog, events, cancel := g.feed.Subscribe(projectID, lastID)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, event := range backlog {
+		if err := writeSSE(c, event); err != nil {
+			return
+		}
+	}
+
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(g.heartbeat())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			// A closed channel means the client lagged; it reconnects and resumes
+			if !ok {
+				return
+			}
+
+			if err := writeSSE(c, event); err != nil {
+				return
+			}
+		}
+
+		c.Writer.Flush()
+	}
+}
+
+func writeSSE(c *gin.Context, event v.Event) error {
+	data, err := json.Marshal(toGoodsEvent(event))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Action, data)
+
+	return err
+}
+
+// goodsWebSocketHandler pushes the same events as goodsStreamHandler over a
+// WebSocket, one JSON message per event.
+func (g ginController) goodsWebSocketHandler(c *gin.Context) {
+	projectID, lastID, err := parseFeedQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already replied
+		requestLogger(c).Info("WebSocket upgrade failed", zap.Error(err))
+
+		return
+	}
+	defer conn.Close()
+
+	backlog, events, cancel := g.feed.Subscribe(projectID, lastID)
+	defer cancel()
+
+	// Reading is needed to process control frames and notice the client leaving
+	closed := make(chan struct{})
+
+	go func() {
+		defer close(closed)
+
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, event := range backlog {
+		if err := writeWebSocket(conn, event); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(g.heartbeat())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "lagged, resume with lastEventID"),
+					time.Now().Add(wsWriteTimeout))
+
+				return
+			}
+
+			if err := writeWebSocket(conn, event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeWebSocket(conn *websocket.Conn, event v.Event) error {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+
+	return conn.WriteJSON(toGoodsEvent(event))
+}
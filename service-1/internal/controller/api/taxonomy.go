@@ -0,0 +1,243 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/schemas"
+)
+
+// parseListFilter reads the optional projectID, tags, tag_mode, category,
+// attr.<path> and visible_at query parameters of /goods/list. Tags are comma
+// separated; visible_at is "now" or an RFC 3339 timestamp.
+func parseListFilter(c *gin.Context) (entity.ListFilter, error) {
+	var filter entity.ListFilter
+
+	projectID, err := parseQueryParamAtoi(c, "projectID", 0)
+	if err != nil {
+		return filter, err
+	}
+
+	categoryID, err := parseQueryParamAtoi(c, "category", 0)
+	if err != nil {
+		return filter, err
+	}
+
+	var fields []entity.FieldError
+
+	if projectID < 0 {
+		fields = append(fields, entity.FieldError{Field: "projectID", Rule: "min", Param: "0"})
+	}
+
+	if categoryID < 0 {
+		fields = append(fields, entity.FieldError{Field: "category", Rule: "min", Param: "0"})
+	}
+
+	switch mode := c.DefaultQuery("tag_mode", "any"); mode {
+	case "any":
+	case "all":
+		filter.MatchAll = true
+	default:
+		fields = append(fields, entity.FieldError{Field: "tag_mode", Rule: "oneof", Param: "any all"})
+	}
+
+	if len(fields) != 0 {
+		return filter, entity.NewQueryError(fields...)
+	}
+
+	for _, tag := range strings.Split(c.Query("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+
+	attributes, err := parseAttributeFilter(c)
+	if err != nil {
+		return filter, err
+	}
+
+	if at := c.Query("visible_at"); at == "now" {
+		now := time.Now()
+		filter.VisibleAt = &now
+	} else if at != "" {
+		visibleAt, err := parseQueryParamTime(c, "visible_at")
+		if err != nil {
+			return filter, err
+		}
+
+		filter.VisibleAt = &visibleAt
+	}
+
+	filter.ProjectID = projectID
+	filter.CategoryID = categoryID
+	filter.Attributes = attributes
+
+	return filter, nil
+}
+
+func (g ginController) retagGoodsHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var request schemas.RetagGoodsRequest
+
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	goods, err := g.service.Good.Retag(c.Request.Context(), projectID, request.IDs, request.Add, request.Remove)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, goods)
+}
+
+func (g ginController) recategorizeGoodsHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var request schemas.RecategorizeGoodsRequest
+
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	goods, err := g.service.Good.Recategorize(c.Request.Context(), projectID, request.IDs, *request.CategoryID)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, goods)
+}
+
+func (g ginController) moveGoodsHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var request schemas.MoveGoodsRequest
+
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	// requireRole only sees the source project
+	if principal, ok := principalFrom(c); ok && !principal.Can(request.ProjectID, entity.RoleEditor) {
+		handleError(c, entity.ErrForbidden, entity.RoleEditor.String()+" role required in the target project")
+
+		return
+	}
+
+	move, err := g.service.Good.Move(c.Request.Context(), projectID, request.IDs, request.ProjectID, request.Priority)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, move)
+}
+
+func (g ginController) createCategoryHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var request schemas.CreateCategoryRequest
+
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	category, err := g.service.Taxonomy.CreateCategory(c.Request.Context(), projectID, request.Name, request.ParentID)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+func (g ginController) categoriesListHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	categories, err := g.service.Taxonomy.Categories(c.Request.Context(), projectID)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+func (g ginController) deleteCategoryHandler(c *gin.Context) {
+	id, projectID, err := parseGoodQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if err := g.service.Taxonomy.DeleteCategory(c.Request.Context(), id, projectID); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (g ginController) tagsListHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	tags, err := g.service.Taxonomy.Tags(c.Request.Context(), projectID)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
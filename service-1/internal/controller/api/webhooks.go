@@ -0,0 +1,149 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/schemas"
+)
+
+// parseProjectQuery reads the required projectID query parameter.
+func parseProjectQuery(c *gin.Context) (int, error) {
+	projectID, err := parseQueryParamAtoi(c, "projectID", -1)
+	if err != nil {
+		return 0, err
+	}
+
+	if projectID < 0 {
+		return 0, entity.NewQueryError(entity.FieldError{Field: "projectID", Rule: "required"})
+	}
+
+	return projectID, nil
+}
+
+func (g ginController) createWebhookHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	var request schemas.CreateWebhookRequest
+
+	if err := g.bindJSON(c, &request); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	hook, err := g.service.Webhook.Create(c.Request.Context(), projectID, request.URL, request.Secret, request.Events)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, hook)
+}
+
+func (g ginController) webhooksListHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	hooks, err := g.service.Webhook.List(c.Request.Context(), projectID)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, hooks)
+}
+
+func (g ginController) deleteWebhookHandler(c *gin.Context) {
+	id, projectID, err := parseGoodQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	if err := g.service.Webhook.Delete(c.Request.Context(), id, projectID); err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (g ginController) webhookDeliveriesHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	webhookID, err := parseQueryParamAtoi(c, "webhookID", 0)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	limit, err := parseQueryParamAtoi(c, "limit", 50)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	offset, err := parseQueryParamAtoi(c, "offset", 0)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	deliveries, err := g.service.Webhook.Deliveries(c.Request.Context(), projectID, webhookID, limit, offset)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (g ginController) redeliverWebhookHandler(c *gin.Context) {
+	projectID, err := parseProjectQuery(c)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		handleError(c, entity.NewQueryError(entity.FieldError{Field: "id", Rule: "integer"}), "")
+
+		return
+	}
+
+	delivery, err := g.service.Webhook.Redeliver(c.Request.Context(), id, projectID)
+	if err != nil {
+		handleError(c, err, "")
+
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
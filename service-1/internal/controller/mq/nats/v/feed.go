@@ -0,0 +1,188 @@
+package v
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/skantay/hezzl/internal/entity"
+	"go.uber.org/zap"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it
+// is dropped. Dropped clients resume from their last event id.
+const subscriberBuffer = 64
+
+// ActionReset tells a client that the events after its last event id are no
+// longer known, because the feed restarted, the client moved to another
+// replica or the events left the replay buffer. It should reload the goods
+// and resume from the reset event's id.
+const ActionReset = "reset"
+
+// Event is a change event numbered in the order this replica received it.
+// The upper 32 bits of an id are the second the feed started in, so ids
+// issued before a restart or by another replica are told apart from its own.
+type Event struct {
+	ID uint64
+	entity.Collection
+}
+
+// Feed fans the change events out to per-project subscribers and keeps the
+// most recent ones so that reconnecting clients can resume.
+type Feed struct {
+	mu     sync.Mutex
+	epoch  uint64
+	seq    uint64
+	recent []Event
+	next   int
+	subs   map[*feedSubscriber]struct{}
+
+	sub *nats.Subscription
+	log *zap.Logger
+}
+
+type feedSubscriber struct {
+	projectID int
+	events    chan Event
+}
+
+// NewFeed subscribes to Subject and replays up to size events.
+func NewFeed(nc *nats.Conn, size int, log *zap.Logger) (*Feed, error) {
+	if size < 1 {
+		size = 1
+	}
+
+	f := &Feed{
+		epoch:  uint64(uint32(time.Now().Unix())) << 32,
+		recent: make([]Event, 0, size),
+		subs:   make(map[*feedSubscriber]struct{}),
+		log:    log,
+	}
+
+	sub, err := nc.Subscribe(Subject, f.receive)
+	if err != nil {
+		return nil, fmt.Errorf("trouble subscribing to nats: %w", err)
+	}
+
+	f.sub = sub
+
+	return f, nil
+}
+
+func (f *Feed) receive(msg *nats.Msg) {
+	var collection entity.Collection
+
+	if err := json.Unmarshal(msg.Data, &collection); err != nil {
+		f.log.Warn("Undecodable change event", zap.Error(err))
+
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.seq++
+	event := Event{ID: f.epoch | f.seq, Collection: collection}
+
+	if len(f.recent) < cap(f.recent) {
+		f.recent = append(f.recent, event)
+	} else {
+		f.recent[f.next] = event
+		f.next = (f.next + 1) % len(f.recent)
+	}
+
+	for s := range f.subs {
+		scoped, ok := forProject(event, s.projectID)
+		if !ok {
+			continue
+		}
+
+		select {
+		case s.events <- scoped:
+		default:
+			// Too slow; the client reconnects with its last event id
+			delete(f.subs, s)
+			close(s.events)
+		}
+	}
+}
+
+// Subscribe returns the buffered events of the project newer than lastID and
+// a channel of the events that follow. The channel is closed when the
+// subscriber falls too far behind or cancel is called. When the events after
+// lastID are unknown, the backlog is a single ActionReset event instead.
+func (f *Feed) Subscribe(projectID int, lastID uint64) ([]Event, <-chan Event, func()) {
+	s := &feedSubscriber{projectID: projectID, events: make(chan Event, subscriberBuffer)}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var backlog []Event
+
+	if lastID != 0 && !f.known(lastID) {
+		backlog = append(backlog, Event{ID: f.epoch | f.seq, Collection: entity.Collection{Action: ActionReset, Goods: []entity.Good{}}})
+		lastID = ^uint64(0)
+	}
+
+	for i := range f.recent {
+		event := f.recent[(f.next+i)%len(f.recent)]
+		if event.ID <= lastID {
+			continue
+		}
+
+		if scoped, ok := forProject(event, projectID); ok {
+			backlog = append(backlog, scoped)
+		}
+	}
+
+	f.subs[s] = struct{}{}
+
+	cancel := func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if _, ok := f.subs[s]; ok {
+			delete(f.subs, s)
+			close(s.events)
+		}
+	}
+
+	return backlog, s.events, cancel
+}
+
+// known reports whether every event after lastID is still in the buffer.
+func (f *Feed) known(lastID uint64) bool {
+	if lastID&^0xffffffff != f.epoch || lastID > f.epoch|f.seq {
+		return false
+	}
+
+	// Before the buffer first fills up it holds every event since the start
+	oldest := f.epoch | 1
+	if len(f.recent) == cap(f.recent) {
+		oldest = f.recent[f.next].ID
+	}
+
+	return lastID+1 >= oldest
+}
+
+// Close stops receiving events.
+func (f *Feed) Close() error {
+	return f.sub.Unsubscribe()
+}
+
+// forProject keeps only the goods of projectID, reporting false when none
+// are left.
+func forProject(event Event, projectID int) (Event, bool) {
+	scoped := event
+	scoped.Goods = nil
+
+	for _, good := range event.Goods {
+		if good.ProjectID == projectID {
+			scoped.Goods = append(scoped.Goods, good)
+		}
+	}
+
+	return scoped, len(scoped.Goods) != 0
+}
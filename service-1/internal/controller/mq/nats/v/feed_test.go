@@ -0,0 +1,100 @@
+package v
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/skantay/hezzl/internal/entity"
+	"go.uber.org/zap"
+)
+
+func newTestFeed(epoch uint64, size int) *Feed {
+	return &Feed{
+		epoch:  epoch << 32,
+		recent: make([]Event, 0, size),
+		subs:   make(map[*feedSubscriber]struct{}),
+		log:    zap.NewNop(),
+	}
+}
+
+func (f *Feed) receiveGood(t *testing.T, projectID int) {
+	t.Helper()
+
+	data, err := json.Marshal(entity.Collection{Action: entity.ActionUpdate, Goods: []entity.Good{{ID: 1, ProjectID: projectID}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.receive(&nats.Msg{Data: data})
+}
+
+func TestFeedSubscribe(t *testing.T) {
+	f := newTestFeed(7, 3)
+	for i := 0; i < 5; i++ {
+		f.receiveGood(t, 1)
+	}
+
+	id := func(seq uint64) uint64 { return 7<<32 | seq }
+
+	tests := []struct {
+		name   string
+		lastID uint64
+		want   []uint64
+		reset  bool
+	}{
+		{"fresh client replays the buffer", 0, []uint64{id(3), id(4), id(5)}, false},
+		{"resumes after a buffered event", id(3), []uint64{id(4), id(5)}, false},
+		{"resumes right before the buffer", id(2), []uint64{id(3), id(4), id(5)}, false},
+		{"up to date", id(5), nil, false},
+		{"evicted", id(1), []uint64{id(5)}, true},
+		{"ahead of this replica", id(9), []uint64{id(5)}, true},
+		{"issued before a restart", 6<<32 | 100, []uint64{id(5)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backlog, _, cancel := f.Subscribe(1, tt.lastID)
+			defer cancel()
+
+			if len(backlog) != len(tt.want) {
+				t.Fatalf("got %d events, want %d", len(backlog), len(tt.want))
+			}
+
+			for i, event := range backlog {
+				if event.ID != tt.want[i] {
+					t.Errorf("event %d: got id %x, want %x", i, event.ID, tt.want[i])
+				}
+			}
+
+			if reset := len(backlog) == 1 && backlog[0].Action == ActionReset; reset != tt.reset {
+				t.Errorf("got reset %v, want %v", reset, tt.reset)
+			}
+		})
+	}
+}
+
+func TestFeedScopesEventsToProject(t *testing.T) {
+	f := newTestFeed(1, 10)
+
+	backlog, events, cancel := f.Subscribe(2, 0)
+	defer cancel()
+
+	if len(backlog) != 0 {
+		t.Fatalf("got %d buffered events, want none", len(backlog))
+	}
+
+	f.receiveGood(t, 1)
+	f.receiveGood(t, 2)
+
+	event := <-events
+	if len(event.Goods) != 1 || event.Goods[0].ProjectID != 2 {
+		t.Fatalf("got %+v, want only the goods of project 2", event.Goods)
+	}
+
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %+v", event)
+	default:
+	}
+}
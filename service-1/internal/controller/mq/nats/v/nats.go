@@ -2,13 +2,33 @@ package v
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/nats-io/nats.go"
+	"github.com/skantay/hezzl/pkg/metrics"
+	"github.com/skantay/hezzl/pkg/requestid"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Subject matches the subjects carrying entity.Collection change events,
+// one per organization.
+const Subject = "org.*.Goods.Collection"
+
+// SubjectFor names the subject of an organization's change events.
+func SubjectFor(organizationID int) string {
+	return fmt.Sprintf("org.%d.Goods.Collection", organizationID)
+}
+
+var tracer = otel.Tracer("github.com/skantay/hezzl/internal/controller/mq/nats/v")
+
 type NC interface {
-	SendJSON(ctx context.Context, data any) error
+	// SendJSON publishes data on the subject of the organization
+	SendJSON(ctx context.Context, organizationID int, data any) error
 }
 
 type natsSend struct {
@@ -19,15 +39,61 @@ func New(nc *nats.Conn) NC {
 	return natsSend{nc}
 }
 
-func (n natsSend) SendJSON(ctx context.Context, data any) error {
-	ec, err := nats.NewEncodedConn(n.nc, "json")
+func (n natsSend) SendJSON(ctx context.Context, organizationID int, data any) error {
+	subject := SubjectFor(organizationID)
+
+	ctx, span := tracer.Start(ctx, Subject+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("nats"),
+			semconv.MessagingDestinationName(subject),
+		))
+	defer span.End()
+
+	payload, err := json.Marshal(data)
 	if err != nil {
+		metrics.NatsPublished.WithLabelValues(Subject, "failure").Inc()
+		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("trouble with encoding nats: %w", err)
 	}
 
-	if err := ec.Publish("Goods.Collection", data); err != nil {
+	msg := nats.NewMsg(subject)
+	msg.Data = payload
+
+	// Trace context travels in the message headers so consumers continue the trace
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(msg.Header))
+
+	if id := requestid.FromContext(ctx); id != "" {
+		msg.Header.Set(requestid.Header, id)
+	}
+
+	if err := n.nc.PublishMsg(msg); err != nil {
+		metrics.NatsPublished.WithLabelValues(Subject, "failure").Inc()
+		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("trouble publish to nats: %w", err)
 	}
 
+	metrics.NatsPublished.WithLabelValues(Subject, "success").Inc()
+
 	return nil
 }
+
+// headerCarrier adapts nats.Header to propagation.TextMapCarrier.
+type headerCarrier nats.Header
+
+func (h headerCarrier) Get(key string) string {
+	return nats.Header(h).Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	nats.Header(h).Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
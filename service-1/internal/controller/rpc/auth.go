@@ -0,0 +1,152 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/skantay/hezzl/internal/controller/rpc/pb"
+	"github.com/skantay/hezzl/internal/entity"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	authorizationKey = "authorization"
+	apiKeyKey        = "x-api-key"
+	bearerPrefix     = "Bearer "
+)
+
+// methodRoles is the role each RPC requires on the requested project,
+// mirroring the HTTP routes.
+var methodRoles = map[string]entity.Role{
+	pb.GoodsService_GetGood_FullMethodName:          entity.RoleViewer,
+	pb.GoodsService_ListGoods_FullMethodName:        entity.RoleViewer,
+	pb.GoodsService_WatchGoods_FullMethodName:       entity.RoleViewer,
+	pb.GoodsService_CreateGood_FullMethodName:       entity.RoleEditor,
+	pb.GoodsService_UpdateGood_FullMethodName:       entity.RoleEditor,
+	pb.GoodsService_ReprioritizeGood_FullMethodName: entity.RoleEditor,
+	pb.GoodsService_RemoveGood_FullMethodName:       entity.RoleAdmin,
+	pb.GoodsService_RestoreGood_FullMethodName:      entity.RoleAdmin,
+}
+
+// projectScoped is implemented by every request naming a project.
+type projectScoped interface {
+	GetProjectId() int64
+}
+
+func (g *grpcController) unaryAuthInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	ctx, err := g.authenticate(ctx)
+	if err != nil {
+		return nil, g.toStatus(ctx, err)
+	}
+
+	if err := authorize(ctx, info.FullMethod, req); err != nil {
+		return nil, g.toStatus(ctx, err)
+	}
+
+	return handler(ctx, req)
+}
+
+func (g *grpcController) streamAuthInterceptor(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := g.authenticate(ss.Context())
+	if err != nil {
+		return g.toStatus(ctx, err)
+	}
+
+	return handler(srv, &authStream{ServerStream: ss, ctx: ctx, method: info.FullMethod, g: g})
+}
+
+// authenticate puts the caller's principal into ctx when authentication is on.
+func (g *grpcController) authenticate(ctx context.Context) (context.Context, error) {
+	if !g.service.Auth.Enabled() {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var bearer, apiKey string
+	if values := md.Get(authorizationKey); len(values) > 0 && strings.HasPrefix(values[0], bearerPrefix) {
+		bearer = strings.TrimPrefix(values[0], bearerPrefix)
+	}
+	if values := md.Get(apiKeyKey); len(values) > 0 {
+		apiKey = values[0]
+	}
+
+	principal, err := g.service.Auth.Authenticate(ctx, bearer, apiKey)
+	if err != nil {
+		return ctx, err
+	}
+
+	return entity.WithPrincipal(ctx, principal), nil
+}
+
+// authorize rejects requests for a project where the caller lacks the
+// method's role. Requests without a project are filtered by the handler.
+func authorize(ctx context.Context, method string, req any) error {
+	principal, ok := entity.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	role, ok := methodRoles[method]
+	if !ok {
+		return entity.ErrForbidden
+	}
+
+	scoped, ok := req.(projectScoped)
+	if !ok {
+		return nil
+	}
+
+	// The project of a listing is an optional filter, as on /goods/list
+	if list, ok := req.(*pb.ListGoodsRequest); ok && list.GetProjectId() == 0 {
+		return nil
+	}
+
+	if !principal.Can(int(scoped.GetProjectId()), role) {
+		return entity.ErrForbidden
+	}
+
+	return nil
+}
+
+// authStream carries the principal and checks the role and rate limit once
+// the request message of a server-streaming call has been received.
+type authStream struct {
+	grpc.ServerStream
+
+	ctx    context.Context
+	method string
+	g      *grpcController
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *authStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	if err := authorize(s.ctx, s.method, m); err != nil {
+		return s.g.toStatus(s.ctx, err)
+	}
+
+	if err := s.g.limitRoute(s.ctx, s.method, m); err != nil {
+		return s.g.toStatus(s.ctx, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/pkg/requestid"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusCodes maps the entity error catalogue to gRPC codes. Anything not
+// listed is reported as codes.Internal.
+var statusCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{entity.ErrGoodNotFound, codes.NotFound},
+	{entity.ErrProjectNotFound, codes.NotFound},
+	{entity.ErrOrganizationNotFound, codes.NotFound},
+	{entity.ErrGoodNameTaken, codes.AlreadyExists},
+	{entity.ErrRevisionNotFound, codes.NotFound},
+	{entity.ErrChangesetNotFound, codes.NotFound},
+	{entity.ErrChangesetConflict, codes.Aborted},
+	{entity.ErrCategoryNotFound, codes.NotFound},
+	{entity.ErrCategoryNameTaken, codes.AlreadyExists},
+	{entity.ErrAPIKeyNotFound, codes.NotFound},
+	{entity.ErrWebhookNotFound, codes.NotFound},
+	{entity.ErrDeliveryNotFound, codes.NotFound},
+	{entity.ErrUnauthorized, codes.Unauthenticated},
+	{entity.ErrForbidden, codes.PermissionDenied},
+	{entity.ErrRateLimited, codes.ResourceExhausted},
+	{entity.ErrValidation, codes.InvalidArgument},
+	{entity.ErrMalformedBody, codes.InvalidArgument},
+	{entity.ErrInvalidQuery, codes.InvalidArgument},
+	{entity.ErrBodyTooLarge, codes.ResourceExhausted},
+}
+
+// toStatus converts err to a gRPC status. Internal errors are logged and
+// their text is kept from the client.
+func (g *grpcController) toStatus(ctx context.Context, err error) error {
+	for _, sc := range statusCodes {
+		if errors.Is(err, sc.err) {
+			return status.Error(sc.code, err.Error())
+		}
+	}
+
+	g.log.Error("Request failed",
+		zap.String("request_id", requestid.FromContext(ctx)),
+		zap.Error(err))
+
+	return status.Error(codes.Internal, entity.ErrInternal.Error())
+}
@@ -0,0 +1,186 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/skantay/hezzl/config"
+	"github.com/skantay/hezzl/internal/controller/rpc/pb"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/pkg/ratelimit"
+	"github.com/skantay/hezzl/pkg/requestid"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const requestIDKey = "x-request-id"
+
+// methodRoutes names the HTTP route of each RPC, so that both APIs share the
+// route's configured limit and bucket. Other methods are limited under
+// their own name.
+var methodRoutes = map[string]string{
+	pb.GoodsService_ListGoods_FullMethodName:        "/goods/list",
+	pb.GoodsService_WatchGoods_FullMethodName:       "/goods/stream",
+	pb.GoodsService_CreateGood_FullMethodName:       "/good/create",
+	pb.GoodsService_UpdateGood_FullMethodName:       "/good/update",
+	pb.GoodsService_RemoveGood_FullMethodName:       "/good/remove",
+	pb.GoodsService_RestoreGood_FullMethodName:      "/good/restore",
+	pb.GoodsService_ReprioritizeGood_FullMethodName: "/good/reprioritize",
+}
+
+// requestContext accepts or generates the request ID, as the HTTP API does,
+// and stores it with the caller's address.
+func requestContext(ctx context.Context) (context.Context, string) {
+	var id string
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(requestIDKey); len(values) > 0 && len(values[0]) <= 128 {
+		id = values[0]
+	}
+
+	if id == "" {
+		id = requestid.New()
+	}
+
+	ctx = requestid.WithID(ctx, id)
+
+	if p, ok := peer.FromContext(ctx); ok {
+		ctx = requestid.WithClientIP(ctx, clientIP(p.Addr))
+	}
+
+	return ctx, id
+}
+
+func clientIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+
+	return host
+}
+
+func (g *grpcController) unaryRequestIDInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	ctx, id := requestContext(ctx)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+
+	return handler(ctx, req)
+}
+
+func (g *grpcController) streamRequestIDInterceptor(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, id := requestContext(ss.Context())
+	_ = ss.SetHeader(metadata.Pairs(requestIDKey, id))
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// contextStream replaces the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// unaryIPLimitInterceptor and streamIPLimitInterceptor run before
+// authentication, so that guessing credentials is limited as well.
+func (g *grpcController) unaryIPLimitInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if err := g.limitIP(ctx); err != nil {
+		return nil, g.toStatus(ctx, err)
+	}
+
+	return handler(ctx, req)
+}
+
+func (g *grpcController) streamIPLimitInterceptor(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := g.limitIP(ss.Context()); err != nil {
+		return g.toStatus(ss.Context(), err)
+	}
+
+	return handler(srv, ss)
+}
+
+// unaryRateLimitInterceptor applies the route's limit to the authenticated
+// caller. Streams are limited once their request arrives, see authStream.
+func (g *grpcController) unaryRateLimitInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if err := g.limitRoute(ctx, info.FullMethod, req); err != nil {
+		return nil, g.toStatus(ctx, err)
+	}
+
+	return handler(ctx, req)
+}
+
+func (g *grpcController) limitIP(ctx context.Context) error {
+	return g.limit(ctx, "ratelimit:ip:"+requestid.ClientIPFromContext(ctx), g.cfg.RateLimit.PerIP)
+}
+
+// limitRoute keys the bucket as the HTTP API does.
+func (g *grpcController) limitRoute(ctx context.Context, method string, req any) error {
+	route, ok := methodRoutes[method]
+	if !ok {
+		route = method
+	}
+
+	limit, ok := g.routes[route]
+	if !ok {
+		limit = g.cfg.RateLimit.Default
+	}
+
+	client := "ip:" + requestid.ClientIPFromContext(ctx)
+	if principal, ok := entity.PrincipalFromContext(ctx); ok {
+		client = principal.Subject
+	}
+
+	key := "ratelimit:" + route + ":" + client
+	if scoped, ok := req.(projectScoped); ok && limit.PerProject {
+		key += ":project:" + strconv.FormatInt(scoped.GetProjectId(), 10)
+	}
+
+	return g.limit(ctx, key, limit)
+}
+
+func (g *grpcController) limit(ctx context.Context, key string, limit config.RouteLimit) error {
+	if !g.cfg.RateLimit.Enabled || limit.Rate <= 0 || limit.Burst <= 0 {
+		return nil
+	}
+
+	result := g.limiter.Allow(ctx, key, ratelimit.Limit{Rate: limit.Rate, Burst: limit.Burst})
+	if !result.Allowed {
+		return fmt.Errorf("%w: retry after %ds", entity.ErrRateLimited, int(math.Ceil(result.RetryAfter.Seconds())))
+	}
+
+	return nil
+}
@@ -0,0 +1,398 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: goods/v1/goods.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	GoodsService_CreateGood_FullMethodName       = "/goods.v1.GoodsService/CreateGood"
+	GoodsService_GetGood_FullMethodName          = "/goods.v1.GoodsService/GetGood"
+	GoodsService_ListGoods_FullMethodName        = "/goods.v1.GoodsService/ListGoods"
+	GoodsService_UpdateGood_FullMethodName       = "/goods.v1.GoodsService/UpdateGood"
+	GoodsService_RemoveGood_FullMethodName       = "/goods.v1.GoodsService/RemoveGood"
+	GoodsService_RestoreGood_FullMethodName      = "/goods.v1.GoodsService/RestoreGood"
+	GoodsService_ReprioritizeGood_FullMethodName = "/goods.v1.GoodsService/ReprioritizeGood"
+	GoodsService_WatchGoods_FullMethodName       = "/goods.v1.GoodsService/WatchGoods"
+)
+
+// GoodsServiceClient is the client API for GoodsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GoodsServiceClient interface {
+	CreateGood(ctx context.Context, in *CreateGoodRequest, opts ...grpc.CallOption) (*Good, error)
+	GetGood(ctx context.Context, in *GetGoodRequest, opts ...grpc.CallOption) (*Good, error)
+	ListGoods(ctx context.Context, in *ListGoodsRequest, opts ...grpc.CallOption) (*ListGoodsResponse, error)
+	UpdateGood(ctx context.Context, in *UpdateGoodRequest, opts ...grpc.CallOption) (*Good, error)
+	RemoveGood(ctx context.Context, in *RemoveGoodRequest, opts ...grpc.CallOption) (*Good, error)
+	RestoreGood(ctx context.Context, in *RestoreGoodRequest, opts ...grpc.CallOption) (*Good, error)
+	ReprioritizeGood(ctx context.Context, in *ReprioritizeGoodRequest, opts ...grpc.CallOption) (*ReprioritizeGoodResponse, error)
+	// WatchGoods streams changes to goods of a project as they are published.
+	WatchGoods(ctx context.Context, in *WatchGoodsRequest, opts ...grpc.CallOption) (GoodsService_WatchGoodsClient, error)
+}
+
+type goodsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGoodsServiceClient(cc grpc.ClientConnInterface) GoodsServiceClient {
+	return &goodsServiceClient{cc}
+}
+
+func (c *goodsServiceClient) CreateGood(ctx context.Context, in *CreateGoodRequest, opts ...grpc.CallOption) (*Good, error) {
+	out := new(Good)
+	err := c.cc.Invoke(ctx, GoodsService_CreateGood_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goodsServiceClient) GetGood(ctx context.Context, in *GetGoodRequest, opts ...grpc.CallOption) (*Good, error) {
+	out := new(Good)
+	err := c.cc.Invoke(ctx, GoodsService_GetGood_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goodsServiceClient) ListGoods(ctx context.Context, in *ListGoodsRequest, opts ...grpc.CallOption) (*ListGoodsResponse, error) {
+	out := new(ListGoodsResponse)
+	err := c.cc.Invoke(ctx, GoodsService_ListGoods_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goodsServiceClient) UpdateGood(ctx context.Context, in *UpdateGoodRequest, opts ...grpc.CallOption) (*Good, error) {
+	out := new(Good)
+	err := c.cc.Invoke(ctx, GoodsService_UpdateGood_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goodsServiceClient) RemoveGood(ctx context.Context, in *RemoveGoodRequest, opts ...grpc.CallOption) (*Good, error) {
+	out := new(Good)
+	err := c.cc.Invoke(ctx, GoodsService_RemoveGood_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goodsServiceClient) RestoreGood(ctx context.Context, in *RestoreGoodRequest, opts ...grpc.CallOption) (*Good, error) {
+	out := new(Good)
+	err := c.cc.Invoke(ctx, GoodsService_RestoreGood_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goodsServiceClient) ReprioritizeGood(ctx context.Context, in *ReprioritizeGoodRequest, opts ...grpc.CallOption) (*ReprioritizeGoodResponse, error) {
+	out := new(ReprioritizeGoodResponse)
+	err := c.cc.Invoke(ctx, GoodsService_ReprioritizeGood_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goodsServiceClient) WatchGoods(ctx context.Context, in *WatchGoodsRequest, opts ...grpc.CallOption) (GoodsService_WatchGoodsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &GoodsService_ServiceDesc.Streams[0], GoodsService_WatchGoods_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &goodsServiceWatchGoodsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GoodsService_WatchGoodsClient interface {
+	Recv() (*GoodsEvent, error)
+	grpc.ClientStream
+}
+
+type goodsServiceWatchGoodsClient struct {
+	grpc.ClientStream
+}
+
+func (x *goodsServiceWatchGoodsClient) Recv() (*GoodsEvent, error) {
+	m := new(GoodsEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// GoodsServiceServer is the server API for GoodsService service.
+// All implementations must embed UnimplementedGoodsServiceServer
+// for forward compatibility
+type GoodsServiceServer interface {
+	CreateGood(context.Context, *CreateGoodRequest) (*Good, error)
+	GetGood(context.Context, *GetGoodRequest) (*Good, error)
+	ListGoods(context.Context, *ListGoodsRequest) (*ListGoodsResponse, error)
+	UpdateGood(context.Context, *UpdateGoodRequest) (*Good, error)
+	RemoveGood(context.Context, *RemoveGoodRequest) (*Good, error)
+	RestoreGood(context.Context, *RestoreGoodRequest) (*Good, error)
+	ReprioritizeGood(context.Context, *ReprioritizeGoodRequest) (*ReprioritizeGoodResponse, error)
+	// WatchGoods streams changes to goods of a project as they are published.
+	WatchGoods(*WatchGoodsRequest, GoodsService_WatchGoodsServer) error
+	mustEmbedUnimplementedGoodsServiceServer()
+}
+
+// UnimplementedGoodsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedGoodsServiceServer struct {
+}
+
+func (UnimplementedGoodsServiceServer) CreateGood(context.Context, *CreateGoodRequest) (*Good, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateGood not implemented")
+}
+func (UnimplementedGoodsServiceServer) GetGood(context.Context, *GetGoodRequest) (*Good, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGood not implemented")
+}
+func (UnimplementedGoodsServiceServer) ListGoods(context.Context, *ListGoodsRequest) (*ListGoodsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGoods not implemented")
+}
+func (UnimplementedGoodsServiceServer) UpdateGood(context.Context, *UpdateGoodRequest) (*Good, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateGood not implemented")
+}
+func (UnimplementedGoodsServiceServer) RemoveGood(context.Context, *RemoveGoodRequest) (*Good, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveGood not implemented")
+}
+func (UnimplementedGoodsServiceServer) RestoreGood(context.Context, *RestoreGoodRequest) (*Good, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreGood not implemented")
+}
+func (UnimplementedGoodsServiceServer) ReprioritizeGood(context.Context, *ReprioritizeGoodRequest) (*ReprioritizeGoodResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReprioritizeGood not implemented")
+}
+func (UnimplementedGoodsServiceServer) WatchGoods(*WatchGoodsRequest, GoodsService_WatchGoodsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchGoods not implemented")
+}
+func (UnimplementedGoodsServiceServer) mustEmbedUnimplementedGoodsServiceServer() {}
+
+// UnsafeGoodsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GoodsServiceServer will
+// result in compilation errors.
+type UnsafeGoodsServiceServer interface {
+	mustEmbedUnimplementedGoodsServiceServer()
+}
+
+func RegisterGoodsServiceServer(s grpc.ServiceRegistrar, srv GoodsServiceServer) {
+	s.RegisterService(&GoodsService_ServiceDesc, srv)
+}
+
+func _GoodsService_CreateGood_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateGoodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).CreateGood(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_CreateGood_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).CreateGood(ctx, req.(*CreateGoodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoodsService_GetGood_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGoodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).GetGood(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_GetGood_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).GetGood(ctx, req.(*GetGoodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoodsService_ListGoods_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGoodsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).ListGoods(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_ListGoods_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).ListGoods(ctx, req.(*ListGoodsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoodsService_UpdateGood_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateGoodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).UpdateGood(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_UpdateGood_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).UpdateGood(ctx, req.(*UpdateGoodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoodsService_RemoveGood_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveGoodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).RemoveGood(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_RemoveGood_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).RemoveGood(ctx, req.(*RemoveGoodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoodsService_RestoreGood_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreGoodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).RestoreGood(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_RestoreGood_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).RestoreGood(ctx, req.(*RestoreGoodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoodsService_ReprioritizeGood_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReprioritizeGoodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).ReprioritizeGood(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_ReprioritizeGood_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).ReprioritizeGood(ctx, req.(*ReprioritizeGoodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoodsService_WatchGoods_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchGoodsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GoodsServiceServer).WatchGoods(m, &goodsServiceWatchGoodsServer{stream})
+}
+
+type GoodsService_WatchGoodsServer interface {
+	Send(*GoodsEvent) error
+	grpc.ServerStream
+}
+
+type goodsServiceWatchGoodsServer struct {
+	grpc.ServerStream
+}
+
+func (x *goodsServiceWatchGoodsServer) Send(m *GoodsEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// GoodsService_ServiceDesc is the grpc.ServiceDesc for GoodsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GoodsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "goods.v1.GoodsService",
+	HandlerType: (*GoodsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateGood",
+			Handler:    _GoodsService_CreateGood_Handler,
+		},
+		{
+			MethodName: "GetGood",
+			Handler:    _GoodsService_GetGood_Handler,
+		},
+		{
+			MethodName: "ListGoods",
+			Handler:    _GoodsService_ListGoods_Handler,
+		},
+		{
+			MethodName: "UpdateGood",
+			Handler:    _GoodsService_UpdateGood_Handler,
+		},
+		{
+			MethodName: "RemoveGood",
+			Handler:    _GoodsService_RemoveGood_Handler,
+		},
+		{
+			MethodName: "RestoreGood",
+			Handler:    _GoodsService_RestoreGood_Handler,
+		},
+		{
+			MethodName: "ReprioritizeGood",
+			Handler:    _GoodsService_ReprioritizeGood_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchGoods",
+			Handler:       _GoodsService_WatchGoods_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "goods/v1/goods.proto",
+}
@@ -0,0 +1,332 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/skantay/hezzl/config"
+	"github.com/skantay/hezzl/internal/controller/mq/nats/v"
+	"github.com/skantay/hezzl/internal/controller/rpc/pb"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/usecase"
+	"github.com/skantay/hezzl/pkg/ratelimit"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Controller interface {
+	Serve(ctx context.Context) error
+}
+
+type grpcController struct {
+	pb.UnimplementedGoodsServiceServer
+
+	service usecase.Service
+	log     *zap.Logger
+	cfg     config.Config
+	feed    *v.Feed
+	limiter ratelimit.Limiter
+	routes  map[string]config.RouteLimit
+}
+
+// New returns the gRPC controller. feed serves WatchGoods; limiter is shared
+// with the HTTP API so that both count against the same buckets.
+func New(service usecase.Service, log *zap.Logger, cfg config.Config, feed *v.Feed, limiter ratelimit.Limiter) Controller {
+	routes := make(map[string]config.RouteLimit, len(cfg.RateLimit.Routes))
+	for _, route := range cfg.RateLimit.Routes {
+		routes[route.Route] = route
+	}
+
+	return &grpcController{
+		service: service,
+		log:     log,
+		cfg:     cfg,
+		feed:    feed,
+		limiter: limiter,
+		routes:  routes,
+	}
+}
+
+func (g *grpcController) Serve(ctx context.Context) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", g.cfg.GRPC.Port))
+	if err != nil {
+		return fmt.Errorf("trouble listening grpc port: %w", err)
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			g.unaryRequestIDInterceptor,
+			g.unaryIPLimitInterceptor,
+			g.unaryAuthInterceptor,
+			g.unaryRateLimitInterceptor,
+		),
+		grpc.ChainStreamInterceptor(
+			g.streamRequestIDInterceptor,
+			g.streamIPLimitInterceptor,
+			g.streamAuthInterceptor,
+		),
+	)
+
+	pb.RegisterGoodsServiceServer(server, g)
+
+	stopped := make(chan struct{})
+	defer close(stopped)
+
+	// Let calls in flight finish once the service shuts down
+	go func() {
+		select {
+		case <-ctx.Done():
+			server.GracefulStop()
+		case <-stopped:
+		}
+	}()
+
+	if err := server.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("trouble serving grpc: %w", err)
+	}
+
+	return nil
+}
+
+func (g *grpcController) CreateGood(ctx context.Context, req *pb.CreateGoodRequest) (*pb.Good, error) {
+	input := entity.GoodInput{
+		Name:       req.GetName(),
+		Tags:       &req.Tags,
+		Visibility: fromVisibility(req.GetVisibility()),
+	}
+	if req.Attributes != nil {
+		input.Attributes = json.RawMessage(req.GetAttributes())
+	}
+	if req.CategoryId != nil {
+		categoryID := int(req.GetCategoryId())
+		input.CategoryID = &categoryID
+	}
+
+	good, err := g.service.Good.Create(ctx, int(req.GetProjectId()), input)
+	if err != nil {
+		return nil, g.toStatus(ctx, err)
+	}
+
+	return toProto(good), nil
+}
+
+func (g *grpcController) GetGood(ctx context.Context, req *pb.GetGoodRequest) (*pb.Good, error) {
+	good, err := g.service.Good.Get(ctx, int(req.GetId()), int(req.GetProjectId()))
+	if err != nil {
+		return nil, g.toStatus(ctx, err)
+	}
+
+	return toProto(good), nil
+}
+
+func (g *grpcController) ListGoods(ctx context.Context, req *pb.ListGoodsRequest) (*pb.ListGoodsResponse, error) {
+	limit, offset := int(req.GetLimit()), int(req.GetOffset())
+	if limit == 0 {
+		limit = 10
+	}
+	if offset == 0 {
+		offset = 1
+	}
+
+	if offset < 1 || limit < 1 {
+		return nil, g.toStatus(ctx, entity.NewQueryError(
+			entity.FieldError{Field: "offset", Rule: "min", Param: "1"},
+			entity.FieldError{Field: "limit", Rule: "min", Param: "1"},
+		))
+	}
+
+	goods, err := g.service.Good.List(ctx, entity.ListFilter{
+		Limit:      limit,
+		Offset:     offset,
+		ProjectID:  int(req.GetProjectId()),
+		Tags:       req.GetTags(),
+		MatchAll:   req.GetMatchAllTags(),
+		CategoryID: int(req.GetCategoryId()),
+		Attributes: attributesFilter(req.GetAttributes()),
+		VisibleAt:  fromTimestamp(req.GetVisibleAt()),
+	})
+	if err != nil && !errors.Is(err, entity.ErrGoodNotFound) {
+		return nil, g.toStatus(ctx, err)
+	}
+
+	response := &pb.ListGoodsResponse{Goods: []*pb.Good{}}
+
+	for _, good := range goods {
+		response.Goods = append(response.Goods, toProto(good))
+
+		if good.Removed {
+			response.Removed++
+		}
+	}
+
+	response.Total = int64(len(response.Goods))
+
+	return response, nil
+}
+
+func (g *grpcController) UpdateGood(ctx context.Context, req *pb.UpdateGoodRequest) (*pb.Good, error) {
+	input := entity.GoodInput{Name: req.GetName(), Description: req.Description}
+	if req.Tags != nil {
+		input.Tags = &req.Tags.Names
+	}
+	if req.Attributes != nil {
+		input.Attributes = json.RawMessage(req.GetAttributes())
+	}
+	if req.CategoryId != nil {
+		categoryID := int(req.GetCategoryId())
+		input.CategoryID = &categoryID
+	}
+	if req.Visibility != nil {
+		input.Visibility = fromVisibility(req.Visibility)
+	}
+
+	good, err := g.service.Good.Update(ctx, int(req.GetId()), int(req.GetProjectId()), input)
+	if err != nil {
+		return nil, g.toStatus(ctx, err)
+	}
+
+	return toProto(good), nil
+}
+
+func (g *grpcController) RemoveGood(ctx context.Context, req *pb.RemoveGoodRequest) (*pb.Good, error) {
+	good, err := g.service.Good.Delete(ctx, int(req.GetId()), int(req.GetProjectId()))
+	if err != nil {
+		return nil, g.toStatus(ctx, err)
+	}
+
+	return toProto(good), nil
+}
+
+func (g *grpcController) RestoreGood(ctx context.Context, req *pb.RestoreGoodRequest) (*pb.Good, error) {
+	good, err := g.service.Good.Restore(ctx, int(req.GetId()), int(req.GetProjectId()))
+	if err != nil {
+		return nil, g.toStatus(ctx, err)
+	}
+
+	return toProto(good), nil
+}
+
+func (g *grpcController) ReprioritizeGood(ctx context.Context, req *pb.ReprioritizeGoodRequest) (*pb.ReprioritizeGoodResponse, error) {
+	goods, err := g.service.Good.Reprioritiize(ctx,
+		int(req.GetNewPriority()),
+		int(req.GetId()),
+		int(req.GetProjectId()))
+	if err != nil {
+		return nil, g.toStatus(ctx, err)
+	}
+
+	response := &pb.ReprioritizeGoodResponse{Goods: make([]*pb.Good, len(goods))}
+
+	for i, good := range goods {
+		response.Goods[i] = toProto(good)
+	}
+
+	return response, nil
+}
+
+// WatchGoods streams the project's change events until the client goes away
+// or falls too far behind.
+func (g *grpcController) WatchGoods(req *pb.WatchGoodsRequest, stream pb.GoodsService_WatchGoodsServer) error {
+	backlog, events, cancel := g.feed.Subscribe(int(req.GetProjectId()), req.GetLastEventId())
+	defer cancel()
+
+	for _, event := range backlog {
+		if err := stream.Send(toEvent(event)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "change feed lagged, resume with last_event_id")
+			}
+
+			if err := stream.Send(toEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toEvent(event v.Event) *pb.GoodsEvent {
+	goods := make([]*pb.Good, len(event.Goods))
+	for i, good := range event.Goods {
+		goods[i] = toProto(good)
+	}
+
+	return &pb.GoodsEvent{
+		Id:     event.ID,
+		Action: event.Action,
+		Actor:  event.Actor,
+		Goods:  goods,
+	}
+}
+
+func toProto(good entity.Good) *pb.Good {
+	var categoryID *int64
+	if good.CategoryID != nil {
+		id := int64(*good.CategoryID)
+		categoryID = &id
+	}
+
+	return &pb.Good{
+		Id:           int64(good.ID),
+		ProjectId:    int64(good.ProjectID),
+		Name:         good.Name,
+		Description:  good.Description,
+		Priority:     int64(good.Priority),
+		Removed:      good.Removed,
+		CreatedAt:    timestamppb.New(good.CreatedAt),
+		Tags:         good.Tags,
+		CategoryId:   categoryID,
+		Attributes:   string(good.Attributes),
+		VisibleFrom:  toTimestamp(good.VisibleFrom),
+		VisibleUntil: toTimestamp(good.VisibleUntil),
+	}
+}
+
+// toTimestamp and fromTimestamp map an open end of a window to an unset
+// timestamp and back.
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+
+	return timestamppb.New(*t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+
+	t := ts.AsTime()
+
+	return &t
+}
+
+// fromVisibility treats an unset window as open at both ends.
+func fromVisibility(v *pb.Visibility) *entity.Visibility {
+	return &entity.Visibility{VisibleFrom: fromTimestamp(v.GetVisibleFrom()), VisibleUntil: fromTimestamp(v.GetVisibleUntil())}
+}
+
+// attributesFilter treats an empty filter as none.
+func attributesFilter(filter string) json.RawMessage {
+	if filter == "" {
+		return nil
+	}
+
+	return json.RawMessage(filter)
+}
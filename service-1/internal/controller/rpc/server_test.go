@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/skantay/hezzl/config"
+	"github.com/skantay/hezzl/internal/controller/rpc/pb"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/usecase"
+	"github.com/skantay/hezzl/pkg/ratelimit"
+	"github.com/skantay/hezzl/pkg/requestid"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestServeStopsWithContext(t *testing.T) {
+	var cfg config.Config
+
+	g := New(usecase.Service{}, zap.NewNop(), cfg, nil, ratelimit.NewMemory())
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- g.Serve(ctx) }()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Serve returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not stop after the context was cancelled")
+	}
+}
+
+func TestRequestContext(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDKey, "abc"))
+
+	if _, id := requestContext(ctx); id != "abc" {
+		t.Errorf("got request ID %q, want the caller's", id)
+	}
+
+	ctx, id := requestContext(context.Background())
+	if id == "" || requestid.FromContext(ctx) != id {
+		t.Errorf("got request ID %q in context %q, want a generated one", id, requestid.FromContext(ctx))
+	}
+}
+
+func TestRateLimitInterceptor(t *testing.T) {
+	var cfg config.Config
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.Default = config.RouteLimit{Rate: 0.001, Burst: 1}
+
+	g := New(usecase.Service{}, zap.NewNop(), cfg, nil, ratelimit.NewMemory()).(*grpcController)
+
+	info := &grpc.UnaryServerInfo{FullMethod: pb.GoodsService_GetGood_FullMethodName}
+	handler := func(ctx context.Context, req any) (any, error) { return req, nil }
+
+	ctx := requestid.WithClientIP(context.Background(), "192.0.2.1")
+
+	if _, err := g.unaryRateLimitInterceptor(ctx, &pb.GetGoodRequest{}, info, handler); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+
+	_, err := g.unaryRateLimitInterceptor(ctx, &pb.GetGoodRequest{}, info, handler)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second call: got %v, want ResourceExhausted", err)
+	}
+
+	// Another caller has a bucket of its own
+	other := entity.WithPrincipal(ctx, entity.Principal{Subject: "key:2"})
+	if _, err := g.unaryRateLimitInterceptor(other, &pb.GetGoodRequest{}, info, handler); err != nil {
+		t.Fatalf("other caller: %v", err)
+	}
+
+	if err := g.limit(ctx, "ratelimit:ip:192.0.2.1", config.RouteLimit{}); err != nil {
+		t.Errorf("a zero limit must not limit: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// APIKey is a credential scoped to one or more projects. Only a hash of the
+// secret is stored; the plaintext is shown once when the key is issued.
+type APIKey struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Prefix     string `json:"prefix"`
+	ProjectIDs []int  `json:"project_ids"`
+	// Role is what the key may do in each of its projects
+	Role Role `json:"role"`
+	// OrganizationID is the tenant owning every project of the key
+	OrganizationID int        `json:"organization_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+}
+
+// Active reports whether the key is neither revoked nor expired at now.
+func (k APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
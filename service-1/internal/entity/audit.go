@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audited actions on goods.
+const (
+	ActionCreate       = "create"
+	ActionUpdate       = "update"
+	ActionRemove       = "remove"
+	ActionRestore      = "restore"
+	ActionReprioritize = "reprioritize"
+	// ActionRollback restores an earlier revision of a good
+	ActionRollback = "rollback"
+	// ActionPublish and ActionExpire are emitted by the scheduler when a
+	// good's visibility window opens or closes. They are not audited.
+	ActionPublish = "publish"
+	ActionExpire  = "expire"
+	// ActionChangeset announces a published changeset with the final state
+	// of every good it touched; its changes are audited one by one.
+	ActionChangeset = "changeset"
+	// ActionMove reassigns goods to another project
+	ActionMove = "move"
+)
+
+// AuditEntry records who changed a good, from where, and how.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	ProjectID int             `json:"project_id"`
+	GoodID    int             `json:"good_id"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	RequestID string          `json:"request_id"`
+	ClientIP  string          `json:"client_ip"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows an audit query. Zero values mean no restriction.
+type AuditFilter struct {
+	ProjectID int
+	// OrganizationID keeps the entries of one tenant's projects
+	OrganizationID int
+	GoodID         int
+	From           time.Time
+	To             time.Time
+	Limit          int
+	Offset         int
+}
@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// Changeset statuses. Only drafts accept changes.
+const (
+	ChangesetDraft     = "draft"
+	ChangesetPublished = "published"
+	ChangesetDiscarded = "discarded"
+)
+
+// Changeset is a draft of changes to a project's goods that is published
+// in one transaction or discarded.
+type Changeset struct {
+	ID        int        `json:"id"`
+	ProjectID int        `json:"project_id"`
+	Status    string     `json:"status"`
+	Author    string     `json:"author"`
+	CreatedAt time.Time  `json:"created_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+	Changes   []Change   `json:"changes"`
+}
+
+// Change is a staged write, applied in the order it was staged. Action is
+// one of ActionCreate, ActionUpdate, ActionRemove or ActionReprioritize;
+// GoodID is unset for creates, Input is set for creates and updates and
+// Priority for reprioritizes.
+type Change struct {
+	ID        int64      `json:"id"`
+	Action    string     `json:"action"`
+	GoodID    *int       `json:"good_id"`
+	Input     *GoodInput `json:"input,omitempty"`
+	Priority  *int       `json:"priority,omitempty"`
+	Author    string     `json:"author"`
+	CreatedAt time.Time  `json:"created_at"`
+}
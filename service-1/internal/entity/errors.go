@@ -1,7 +1,84 @@
 package entity
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
-var ErrGoodNotFound = errors.New("errors.good.notFound")
+// Error catalogue. The messages are stable identifiers clients can match on.
+var (
+	ErrGoodNotFound = errors.New("errors.good.notFound")
 
-var ErrProjectNotFound = errors.New("errors.project.notFound")
+	ErrProjectNotFound = errors.New("errors.project.notFound")
+
+	ErrOrganizationNotFound = errors.New("errors.organization.notFound")
+
+	ErrGoodNameTaken = errors.New("errors.good.nameTaken")
+
+	ErrAPIKeyNotFound = errors.New("errors.apiKey.notFound")
+
+	ErrRevisionNotFound = errors.New("errors.revision.notFound")
+
+	ErrChangesetNotFound = errors.New("errors.changeset.notFound")
+
+	ErrChangesetConflict = errors.New("errors.changeset.conflict")
+
+	ErrCategoryNotFound = errors.New("errors.category.notFound")
+
+	ErrCategoryNameTaken = errors.New("errors.category.nameTaken")
+
+	ErrWebhookNotFound = errors.New("errors.webhook.notFound")
+
+	ErrDeliveryNotFound = errors.New("errors.webhook.deliveryNotFound")
+
+	ErrUnauthorized = errors.New("errors.auth.unauthorized")
+
+	ErrForbidden = errors.New("errors.auth.forbidden")
+
+	ErrRateLimited = errors.New("errors.rateLimit.exceeded")
+
+	ErrValidation = errors.New("errors.validation.failed")
+
+	ErrMalformedBody = errors.New("errors.request.malformedBody")
+
+	ErrInvalidQuery = errors.New("errors.request.invalidQuery")
+
+	ErrBodyTooLarge = errors.New("errors.request.bodyTooLarge")
+
+	ErrInternal = errors.New("errors.internal")
+)
+
+// FieldError describes a single rule a field or query parameter failed.
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
+
+// ValidationError carries per-field details and unwraps to ErrValidation
+// or ErrInvalidQuery.
+type ValidationError struct {
+	Err    error
+	Fields []FieldError
+}
+
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Err: ErrValidation, Fields: fields}
+}
+
+func NewQueryError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Err: ErrInvalidQuery, Fields: fields}
+}
+
+func (v *ValidationError) Error() string {
+	parts := make([]string, 0, len(v.Fields))
+	for _, f := range v.Fields {
+		parts = append(parts, f.Field+": "+f.Rule)
+	}
+
+	return v.Err.Error() + ": " + strings.Join(parts, ", ")
+}
+
+func (v *ValidationError) Unwrap() error {
+	return v.Err
+}
@@ -0,0 +1,8 @@
+package entity
+
+// ExportFilter narrows an export with the listing filters. As in listing,
+// Offset is the first good id; a zero Limit exports every matching good.
+type ExportFilter struct {
+	ListFilter
+	IncludeRemoved bool
+}
@@ -2,19 +2,133 @@ package entity
 
 import (
 	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Good struct {
-	ID          int       `json:"id"`
-	ProjectID   int       `json:"project_id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Priority    int       `json:"priority"`
-	Removed     bool      `json:"removed"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID        int `json:"id"`
+	ProjectID int `json:"project_id"`
+	// OrganizationID is the tenant owning the good's project
+	OrganizationID int       `json:"organization_id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Priority       int       `json:"priority"`
+	Removed        bool      `json:"removed"`
+	CreatedAt      time.Time `json:"created_at"`
+	CategoryID     *int      `json:"category_id"`
+	Tags           []string  `json:"tags"`
+	// Attributes is a JSON object checked against the project's schema
+	Attributes json.RawMessage `json:"attributes"`
+	Visibility
+}
+
+// GoodInput carries the optional parts of a create or update. Nil fields are
+// left unchanged; an empty Tags slice removes every tag, a zero CategoryID
+// removes the category and Visibility replaces both ends of the window.
+type GoodInput struct {
+	Name        string          `json:"name"`
+	Description *string         `json:"description,omitempty"`
+	Tags        *[]string       `json:"tags,omitempty"`
+	CategoryID  *int            `json:"category_id,omitempty"`
+	Attributes  json.RawMessage `json:"attributes,omitempty"`
+	Visibility  *Visibility     `json:"visibility,omitempty"`
+}
+
+// Collection is the change event published to NATS for every write.
+type Collection struct {
+	// Action is one of the audited actions, e.g. ActionUpdate
+	Action string `json:"action"`
+	Goods  []Good `json:"goods"`
+	// Actor is the authenticated subject that made the change
+	Actor string `json:"actor"`
+	// FromProjectID, FromOrganizationID, ToProjectID and Moved are set on
+	// ActionMove only. Moved lists the ids of the moved goods; the others
+	// were shifted in either project to make room or close the gap
+	FromProjectID      int   `json:"from_project_id,omitempty"`
+	FromOrganizationID int   `json:"from_organization_id,omitempty"`
+	ToProjectID        int   `json:"to_project_id,omitempty"`
+	Moved              []int `json:"moved,omitempty"`
 }
 
 func (g Good) MarshalBinary() ([]byte, error) {
 	return json.Marshal(g)
 }
+
+// Domain constraints for goods, mirrored by CHECK constraints in the database.
+const (
+	NameMaxLength        = 255
+	DescriptionMaxLength = 2000
+	PriorityMin          = 1
+	PriorityMax          = 1_000_000
+	AttributesMaxSize    = 16 << 10
+)
+
+// nameSymbols are the punctuation characters allowed in names besides
+// letters, digits and spaces.
+const nameSymbols = " -_.,:;!?'\"()&/#+%№«»"
+
+// NormalizeName trims surrounding whitespace from a good name.
+func NormalizeName(name string) string {
+	return strings.TrimSpace(name)
+}
+
+// ValidateName checks an already normalized name.
+func ValidateName(name string) []FieldError {
+	var fields []FieldError
+
+	if name == "" {
+		return append(fields, FieldError{Field: "name", Rule: "required"})
+	}
+
+	if utf8.RuneCountInString(name) > NameMaxLength {
+		fields = append(fields, FieldError{Field: "name", Rule: "max", Param: strconv.Itoa(NameMaxLength)})
+	}
+
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) && !strings.ContainsRune(nameSymbols, r) {
+			fields = append(fields, FieldError{Field: "name", Rule: "charset"})
+			break
+		}
+	}
+
+	return fields
+}
+
+func ValidateDescription(desc string) []FieldError {
+	if utf8.RuneCountInString(desc) > DescriptionMaxLength {
+		return []FieldError{{Field: "description", Rule: "max", Param: strconv.Itoa(DescriptionMaxLength)}}
+	}
+
+	return nil
+}
+
+func ValidatePriority(priority int) []FieldError {
+	if priority < PriorityMin || priority > PriorityMax {
+		return []FieldError{{
+			Field: "newPriority",
+			Rule:  "range",
+			Param: strconv.Itoa(PriorityMin) + "-" + strconv.Itoa(PriorityMax),
+		}}
+	}
+
+	return nil
+}
+
+// ValidateAttributes checks that attributes are a JSON object of a sane
+// size. The project schema is checked separately.
+func ValidateAttributes(attrs json.RawMessage) []FieldError {
+	if len(attrs) > AttributesMaxSize {
+		return []FieldError{{Field: "attributes", Rule: "max", Param: strconv.Itoa(AttributesMaxSize)}}
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(attrs, &object); err != nil || object == nil {
+		return []FieldError{{Field: "attributes", Rule: "object"}}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ImportRow is one parsed row of an import file. Row is its 1-based
+// position among the data rows.
+type ImportRow struct {
+	Row         int
+	Name        string
+	Description *string
+	Priority    *int
+	// Attributes is a JSON object; nil leaves an upserted good's unchanged
+	Attributes json.RawMessage
+}
+
+// ImportOptions controls how rows are written.
+type ImportOptions struct {
+	// Upsert updates the non-removed good with the same name instead of
+	// creating a new one
+	Upsert bool
+	// DryRun validates and writes every chunk but rolls it back
+	DryRun    bool
+	ChunkSize int
+}
+
+// ImportOutcome is what happened to a row that passed validation.
+type ImportOutcome struct {
+	Row     int
+	Good    Good
+	Updated bool
+	Err     error
+}
+
+// ImportRowError reports a rejected row.
+type ImportRowError struct {
+	Row     int          `json:"row"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// RowError is returned by import sources for a row that cannot be parsed.
+// The source can continue with the next row.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// ImportReport summarizes an import.
+type ImportReport struct {
+	Total   int  `json:"total"`
+	Created int  `json:"created"`
+	Updated int  `json:"updated"`
+	Failed  int  `json:"failed"`
+	DryRun  bool `json:"dry_run"`
+	// Aborted is the error that stopped the import after some rows were
+	// processed; earlier chunks stay committed
+	Aborted string           `json:"aborted,omitempty"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+// Reject records a failed row, keeping field details when err has them.
+func (r *ImportReport) Reject(row int, err error) {
+	r.Failed++
+
+	rowErr := ImportRowError{Row: row, Message: err.Error()}
+
+	var validation *ValidationError
+	if errors.As(err, &validation) {
+		rowErr.Message = validation.Err.Error()
+		rowErr.Fields = validation.Fields
+	}
+
+	r.Errors = append(r.Errors, rowErr)
+}
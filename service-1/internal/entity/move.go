@@ -0,0 +1,12 @@
+package entity
+
+// GoodsMove is the outcome of moving goods to another project.
+type GoodsMove struct {
+	FromProjectID int `json:"from_project_id"`
+	ToProjectID   int `json:"to_project_id"`
+	// Goods are the moved goods in their new order
+	Goods []Good `json:"goods"`
+	// Shifted lists the goods of either project whose priority changed to
+	// close the gap or make room
+	Shifted []int `json:"shifted"`
+}
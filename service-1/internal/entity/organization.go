@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// Organization is a tenant owning projects. Callers confined to one see and
+// change nothing of the others.
+type Organization struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const OrganizationNameMaxLength = 255
@@ -0,0 +1,149 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Role grants permissions on a project. Higher roles include lower ones.
+type Role int
+
+const (
+	RoleNone Role = iota
+	// RoleViewer may list and get goods
+	RoleViewer
+	// RoleEditor may also create, update and reprioritize goods
+	RoleEditor
+	// RoleAdmin may also remove goods and manage projects
+	RoleAdmin
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleEditor:
+		return "editor"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+func ParseRole(s string) (Role, error) {
+	switch s {
+	case "viewer":
+		return RoleViewer, nil
+	case "editor":
+		return RoleEditor, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return RoleNone, fmt.Errorf("unknown role %q", s)
+	}
+}
+
+// MarshalText encodes the role by name.
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Role) UnmarshalText(text []byte) error {
+	role, err := ParseRole(string(text))
+	if err != nil {
+		return err
+	}
+
+	*r = role
+
+	return nil
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string `json:"subject"`
+	// Roles holds the role per project id
+	Roles map[int]Role `json:"roles"`
+	// GlobalRole applies to every project
+	GlobalRole Role `json:"global_role"`
+	// OrganizationID confines the principal to the projects of one tenant,
+	// listed in Projects. Zero leaves the principal unconfined.
+	OrganizationID int          `json:"organization_id,omitempty"`
+	Projects       map[int]bool `json:"-"`
+}
+
+// RoleFor returns the effective role of the principal in the project. No
+// role applies outside the principal's organization.
+func (p Principal) RoleFor(projectID int) Role {
+	if p.OrganizationID != 0 && !p.Projects[projectID] {
+		return RoleNone
+	}
+
+	if role := p.Roles[projectID]; role > p.GlobalRole {
+		return role
+	}
+
+	return p.GlobalRole
+}
+
+// Can reports whether the principal holds at least role in the project.
+func (p Principal) Can(projectID int, role Role) bool {
+	return p.RoleFor(projectID) >= role
+}
+
+// CanAccess reports whether the principal may see the project at all.
+func (p Principal) CanAccess(projectID int) bool {
+	return p.Can(projectID, RoleViewer)
+}
+
+// VisibleProjects lists the projects the principal may see when its roles
+// are per project. all is set instead when a global role covers every
+// project it can reach.
+func (p Principal) VisibleProjects() (ids []int, all bool) {
+	if p.GlobalRole >= RoleViewer {
+		return nil, true
+	}
+
+	ids = make([]int, 0, len(p.Roles))
+	for projectID := range p.Roles {
+		if p.CanAccess(projectID) {
+			ids = append(ids, projectID)
+		}
+	}
+
+	sort.Ints(ids)
+
+	return ids, false
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// OrganizationFromContext returns the tenant the caller is confined to, or
+// zero when it is not.
+func OrganizationFromContext(ctx context.Context) int {
+	if p, ok := PrincipalFromContext(ctx); ok {
+		return p.OrganizationID
+	}
+
+	return 0
+}
+
+// ActorFromContext returns the subject recorded as the author of a change.
+func ActorFromContext(ctx context.Context) string {
+	if p, ok := PrincipalFromContext(ctx); ok {
+		return p.Subject
+	}
+
+	return ""
+}
@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVisibleProjects(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal Principal
+		wantIDs   []int
+		wantAll   bool
+	}{
+		{name: "global viewer", principal: Principal{GlobalRole: RoleViewer}, wantAll: true},
+		{name: "global role in an organization", principal: Principal{GlobalRole: RoleAdmin, OrganizationID: 1}, wantAll: true},
+		{
+			name:      "roles per project",
+			principal: Principal{Roles: map[int]Role{3: RoleAdmin, 1: RoleViewer, 2: RoleNone}},
+			wantIDs:   []int{1, 3},
+		},
+		{
+			name: "roles outside the organization",
+			principal: Principal{
+				Roles:          map[int]Role{1: RoleEditor, 2: RoleEditor},
+				OrganizationID: 1,
+				Projects:       map[int]bool{2: true},
+			},
+			wantIDs: []int{2},
+		},
+		{name: "no roles", principal: Principal{}, wantIDs: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, all := tt.principal.VisibleProjects()
+			if all != tt.wantAll || !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("VisibleProjects() = %v, %v, want %v, %v", ids, all, tt.wantIDs, tt.wantAll)
+			}
+		})
+	}
+}
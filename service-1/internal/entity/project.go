@@ -7,3 +7,18 @@ type Project struct {
 	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+const ProjectNameMaxLength = 255
+
+// ProjectClone is the outcome of copying a project with its goods.
+type ProjectClone struct {
+	Project  Project `json:"project"`
+	SourceID int     `json:"source_id"`
+	// Goods pairs each copied good with its copy, by priority
+	Goods []ClonedGood `json:"goods"`
+}
+
+type ClonedGood struct {
+	SourceID int `json:"source_id"`
+	ID       int `json:"id"`
+}
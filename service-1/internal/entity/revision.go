@@ -0,0 +1,127 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Revision is the state of a good after one change. Revisions of a good are
+// numbered from 1.
+type Revision struct {
+	GoodID    int       `json:"good_id"`
+	Revision  int       `json:"revision"`
+	ProjectID int       `json:"project_id"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Good      Good      `json:"good"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RevisionDiff lists the fields that differ between two revisions.
+type RevisionDiff struct {
+	GoodID  int           `json:"good_id"`
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is a changed field with its old and new JSON values. Fields
+// of attributes are reported one by one, e.g. "attributes.price".
+type FieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// DiffGoods compares two states of a good field by field, in field name
+// order.
+func DiffGoods(from, to Good) ([]FieldChange, error) {
+	a, err := goodFields(from)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := goodFields(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diffObjects("", a, b)
+
+	for i, change := range changes {
+		// Attributes are compared field by field too
+		if change.Field != "attributes" {
+			continue
+		}
+
+		var oldAttrs, newAttrs map[string]json.RawMessage
+
+		if json.Unmarshal(change.From, &oldAttrs) != nil || json.Unmarshal(change.To, &newAttrs) != nil {
+			continue
+		}
+
+		nested := diffObjects("attributes.", oldAttrs, newAttrs)
+
+		return append(append(changes[:i:i], nested...), changes[i+1:]...), nil
+	}
+
+	return changes, nil
+}
+
+func goodFields(good Good) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(good)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+
+	return fields, json.Unmarshal(data, &fields)
+}
+
+func diffObjects(prefix string, a, b map[string]json.RawMessage) []FieldChange {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	changes := make([]FieldChange, 0)
+
+	for _, key := range keys {
+		from, to := orNull(a[key]), orNull(b[key])
+
+		if !jsonEqual(from, to) {
+			changes = append(changes, FieldChange{Field: prefix + key, From: from, To: to})
+		}
+	}
+
+	return changes
+}
+
+func orNull(value json.RawMessage) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+
+	return value
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var x, y any
+
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return bytes.Equal(a, b)
+	}
+
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,130 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDiffGoods(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	category := 3
+
+	base := Good{
+		ID:             1,
+		ProjectID:      2,
+		OrganizationID: 4,
+		Name:           "apple",
+		Description:    "red",
+		Priority:       1,
+		CreatedAt:      created,
+		Tags:           []string{"fruit"},
+		Attributes:     json.RawMessage(`{"color":"red","size":{"w":1,"h":2}}`),
+	}
+
+	change := func(field, from, to string) FieldChange {
+		return FieldChange{Field: field, From: json.RawMessage(from), To: json.RawMessage(to)}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Good)
+		want   []FieldChange
+	}{
+		{name: "unchanged", modify: func(*Good) {}, want: []FieldChange{}},
+		{name: "name", modify: func(g *Good) { g.Name = "pear" }, want: []FieldChange{change("name", `"apple"`, `"pear"`)}},
+		{name: "description", modify: func(g *Good) { g.Description = "" }, want: []FieldChange{change("description", `"red"`, `""`)}},
+		{name: "priority", modify: func(g *Good) { g.Priority = 5 }, want: []FieldChange{change("priority", `1`, `5`)}},
+		{name: "removed", modify: func(g *Good) { g.Removed = true }, want: []FieldChange{change("removed", `false`, `true`)}},
+		{name: "project", modify: func(g *Good) { g.ProjectID = 7 }, want: []FieldChange{change("project_id", `2`, `7`)}},
+		{name: "organization", modify: func(g *Good) { g.OrganizationID = 8 }, want: []FieldChange{change("organization_id", `4`, `8`)}},
+		{name: "category set", modify: func(g *Good) { g.CategoryID = &category }, want: []FieldChange{change("category_id", `null`, `3`)}},
+		{name: "tags", modify: func(g *Good) { g.Tags = []string{"fruit", "sale"} }, want: []FieldChange{change("tags", `["fruit"]`, `["fruit","sale"]`)}},
+		{name: "tags cleared", modify: func(g *Good) { g.Tags = nil }, want: []FieldChange{change("tags", `["fruit"]`, `null`)}},
+		{
+			name:   "visibility",
+			modify: func(g *Good) { g.VisibleFrom = &from },
+			want:   []FieldChange{change("visible_from", `null`, `"2024-06-01T00:00:00Z"`)},
+		},
+		{
+			name:   "attribute changed",
+			modify: func(g *Good) { g.Attributes = json.RawMessage(`{"color":"green","size":{"w":1,"h":2}}`) },
+			want:   []FieldChange{change("attributes.color", `"red"`, `"green"`)},
+		},
+		{
+			name:   "attribute added and removed",
+			modify: func(g *Good) { g.Attributes = json.RawMessage(`{"size":{"w":1,"h":2},"weight":3}`) },
+			want:   []FieldChange{change("attributes.color", `"red"`, `null`), change("attributes.weight", `null`, `3`)},
+		},
+		{
+			name:   "nested attribute compared as a whole",
+			modify: func(g *Good) { g.Attributes = json.RawMessage(`{"color":"red","size":{"w":1,"h":3}}`) },
+			want:   []FieldChange{change("attributes.size", `{"w":1,"h":2}`, `{"w":1,"h":3}`)},
+		},
+		{
+			name:   "attribute formatting ignored",
+			modify: func(g *Good) { g.Attributes = json.RawMessage(`{ "size": {"h":2, "w":1.0}, "color": "red" }`) },
+			want:   []FieldChange{},
+		},
+		{
+			name: "attributes spliced among other fields",
+			modify: func(g *Good) {
+				g.Attributes = json.RawMessage(`{"color":"green","size":{"w":1,"h":2}}`)
+				g.CategoryID = &category
+				g.Name = "pear"
+			},
+			want: []FieldChange{
+				change("attributes.color", `"red"`, `"green"`),
+				change("category_id", `null`, `3`),
+				change("name", `"apple"`, `"pear"`),
+			},
+		},
+		{
+			name:   "attributes cleared",
+			modify: func(g *Good) { g.Attributes = nil },
+			want:   []FieldChange{change("attributes.color", `"red"`, `null`), change("attributes.size", `{"w":1,"h":2}`, `null`)},
+		},
+		{
+			name:   "attributes that are not an object",
+			modify: func(g *Good) { g.Attributes = json.RawMessage(`["red"]`) },
+			want:   []FieldChange{change("attributes", `{"color":"red","size":{"w":1,"h":2}}`, `["red"]`)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to := base
+			tt.modify(&to)
+
+			got, err := DiffGoods(base, to)
+			if err != nil {
+				t.Fatalf("DiffGoods() error = %v", err)
+			}
+
+			if !equalChanges(got, tt.want) {
+				t.Errorf("DiffGoods() = %s, want %s", formatChanges(got), formatChanges(tt.want))
+			}
+		})
+	}
+}
+
+func equalChanges(a, b []FieldChange) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Field != b[i].Field || !jsonEqual(a[i].From, b[i].From) || !jsonEqual(a[i].To, b[i].To) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func formatChanges(changes []FieldChange) string {
+	data, _ := json.Marshal(changes)
+
+	return string(data)
+}
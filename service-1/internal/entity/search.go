@@ -0,0 +1,26 @@
+package entity
+
+// SearchQueryMaxLength caps the length of a search query in characters.
+const SearchQueryMaxLength = 256
+
+// SearchQuery is a full-text search over the non-removed goods of a project.
+type SearchQuery struct {
+	ProjectID int
+	Query     string
+	Limit     int
+	Offset    int
+}
+
+// SearchHit is a good matching a search, best matches first.
+type SearchHit struct {
+	Good Good    `json:"good"`
+	Rank float64 `json:"rank"`
+	// Highlight holds the HTML-escaped name and description with the matched
+	// words wrapped in <mark> tags
+	Highlight SearchHighlight `json:"highlight"`
+}
+
+type SearchHighlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
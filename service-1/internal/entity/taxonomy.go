@@ -0,0 +1,110 @@
+package entity
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	TagMaxLength      = 64
+	TagsPerGoodMax    = 32
+	CategoryMaxLength = 255
+)
+
+// Tag is a project-scoped label. Names are unique per project regardless
+// of case.
+type Tag struct {
+	ID        int    `json:"id"`
+	ProjectID int    `json:"project_id"`
+	Name      string `json:"name"`
+	// Goods is how many goods carry the tag
+	Goods int `json:"goods"`
+}
+
+// Category is a node of a project's category tree.
+type Category struct {
+	ID        int       `json:"id"`
+	ProjectID int       `json:"project_id"`
+	ParentID  *int      `json:"parent_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListFilter selects goods for listing. Offset is the first good id, as the
+// list endpoint has always paged.
+type ListFilter struct {
+	Limit     int
+	Offset    int
+	ProjectID int
+	// Tags are normalized tag names; MatchAll requires every one of them
+	Tags       []string
+	MatchAll   bool
+	CategoryID int
+	// Attributes is a JSON object the goods' attributes must contain
+	Attributes json.RawMessage
+	// VisibleAt keeps goods whose visibility window contains the time
+	VisibleAt *time.Time
+	// OrganizationID keeps the goods of one tenant
+	OrganizationID int
+	// ProjectIDs, unless nil, keeps the goods of those projects only
+	ProjectIDs []int
+}
+
+// NormalizeTags trims and deduplicates tag names case-insensitively,
+// keeping the first spelling.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+
+		if key := strings.ToLower(tag); !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, tag)
+		}
+	}
+
+	return normalized
+}
+
+// ValidateTags checks normalized tag names.
+func ValidateTags(tags []string) []FieldError {
+	var fields []FieldError
+
+	if len(tags) > TagsPerGoodMax {
+		fields = append(fields, FieldError{Field: "tags", Rule: "max", Param: strconv.Itoa(TagsPerGoodMax)})
+	}
+
+	for _, tag := range tags {
+		if tag == "" {
+			fields = append(fields, FieldError{Field: "tags", Rule: "required"})
+
+			break
+		}
+
+		if utf8.RuneCountInString(tag) > TagMaxLength {
+			fields = append(fields, FieldError{Field: "tags", Rule: "max", Param: strconv.Itoa(TagMaxLength)})
+
+			break
+		}
+	}
+
+	return fields
+}
+
+// ValidateCategoryName checks an already trimmed category name.
+func ValidateCategoryName(name string) []FieldError {
+	if name == "" {
+		return []FieldError{{Field: "name", Rule: "required"}}
+	}
+
+	if utf8.RuneCountInString(name) > CategoryMaxLength {
+		return []FieldError{{Field: "name", Rule: "max", Param: strconv.Itoa(CategoryMaxLength)}}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package entity
+
+import "time"
+
+// Visibility is the window in which a good is shown, from VisibleFrom
+// inclusive to VisibleUntil exclusive. A nil end leaves that side open.
+type Visibility struct {
+	VisibleFrom  *time.Time `json:"visible_from"`
+	VisibleUntil *time.Time `json:"visible_until"`
+}
+
+// ValidateVisibility checks that a closed window does not end before it
+// starts.
+func ValidateVisibility(v Visibility) []FieldError {
+	if v.VisibleFrom != nil && v.VisibleUntil != nil && !v.VisibleUntil.After(*v.VisibleFrom) {
+		return []FieldError{{Field: "visible_until", Rule: "gtfield", Param: "visible_from"}}
+	}
+
+	return nil
+}
+
+// VisibleAt reports whether the good is shown at t.
+func (g Good) VisibleAt(t time.Time) bool {
+	if g.VisibleFrom != nil && t.Before(*g.VisibleFrom) {
+		return false
+	}
+
+	return g.VisibleUntil == nil || t.Before(*g.VisibleUntil)
+}
+
+// NextBoundary returns the first end of the window after t, if any.
+func (g Good) NextBoundary(t time.Time) (time.Time, bool) {
+	for _, boundary := range []*time.Time{g.VisibleFrom, g.VisibleUntil} {
+		if boundary != nil && boundary.After(t) {
+			return *boundary, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Crossed reports which ends of the window fall in (since, until], the
+// interval between two checks. A short window may both open and close.
+func (v Visibility) Crossed(since, until time.Time) (opened, closed bool) {
+	within := func(boundary *time.Time) bool {
+		return boundary != nil && boundary.After(since) && !boundary.After(until)
+	}
+
+	return within(v.VisibleFrom), within(v.VisibleUntil)
+}
@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVisibleAt(t *testing.T) {
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	until := from.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		window Visibility
+		at     time.Time
+		want   bool
+	}{
+		{name: "open window", at: from, want: true},
+		{name: "before start", window: Visibility{VisibleFrom: &from}, at: from.Add(-time.Nanosecond), want: false},
+		{name: "start is inclusive", window: Visibility{VisibleFrom: &from}, at: from, want: true},
+		{name: "after start", window: Visibility{VisibleFrom: &from}, at: until, want: true},
+		{name: "before end", window: Visibility{VisibleUntil: &until}, at: until.Add(-time.Nanosecond), want: true},
+		{name: "end is exclusive", window: Visibility{VisibleUntil: &until}, at: until, want: false},
+		{name: "inside closed window", window: Visibility{VisibleFrom: &from, VisibleUntil: &until}, at: from.Add(time.Minute), want: true},
+		{name: "after closed window", window: Visibility{VisibleFrom: &from, VisibleUntil: &until}, at: until.Add(time.Minute), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Good{Visibility: tt.window}).VisibleAt(tt.at); got != tt.want {
+				t.Errorf("VisibleAt(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextBoundary(t *testing.T) {
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	until := from.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		window Visibility
+		at     time.Time
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "open window", at: from},
+		{name: "before start", window: Visibility{VisibleFrom: &from, VisibleUntil: &until}, at: from.Add(-time.Minute), want: from, wantOK: true},
+		{name: "at start", window: Visibility{VisibleFrom: &from, VisibleUntil: &until}, at: from, want: until, wantOK: true},
+		{name: "inside window", window: Visibility{VisibleFrom: &from, VisibleUntil: &until}, at: from.Add(time.Minute), want: until, wantOK: true},
+		{name: "at end", window: Visibility{VisibleFrom: &from, VisibleUntil: &until}, at: until},
+		{name: "start passed, no end", window: Visibility{VisibleFrom: &from}, at: until},
+		{name: "end only", window: Visibility{VisibleUntil: &until}, at: from, want: until, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := (Good{Visibility: tt.window}).NextBoundary(tt.at)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("NextBoundary(%v) = %v, %v, want %v, %v", tt.at, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCrossed(t *testing.T) {
+	tick := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	next := tick.Add(10 * time.Second)
+	at := func(d time.Duration) *time.Time {
+		t := tick.Add(d)
+
+		return &t
+	}
+
+	tests := []struct {
+		name       string
+		window     Visibility
+		wantOpened bool
+		wantClosed bool
+	}{
+		{name: "open window"},
+		{name: "opens between ticks", window: Visibility{VisibleFrom: at(5 * time.Second)}, wantOpened: true},
+		{name: "opens at the next tick", window: Visibility{VisibleFrom: &next}, wantOpened: true},
+		{name: "opened at the previous tick", window: Visibility{VisibleFrom: &tick}},
+		{name: "opens later", window: Visibility{VisibleFrom: at(11 * time.Second)}},
+		{name: "closes between ticks", window: Visibility{VisibleUntil: at(5 * time.Second)}, wantClosed: true},
+		{name: "closes at the next tick", window: Visibility{VisibleUntil: &next}, wantClosed: true},
+		{name: "closed at the previous tick", window: Visibility{VisibleUntil: &tick}},
+		{
+			name:       "opens and closes between ticks",
+			window:     Visibility{VisibleFrom: at(2 * time.Second), VisibleUntil: at(7 * time.Second)},
+			wantOpened: true,
+			wantClosed: true,
+		},
+		{
+			name:       "opened earlier, closes between ticks",
+			window:     Visibility{VisibleFrom: at(-time.Hour), VisibleUntil: at(7 * time.Second)},
+			wantClosed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened, closed := tt.window.Crossed(tick, next)
+			if opened != tt.wantOpened || closed != tt.wantClosed {
+				t.Errorf("Crossed() = %v, %v, want %v, %v", opened, closed, tt.wantOpened, tt.wantClosed)
+			}
+		})
+	}
+}
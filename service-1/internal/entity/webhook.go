@@ -0,0 +1,115 @@
+package entity
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// Webhook delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook posts a project's change events to a partner URL. The secret is
+// only returned when the webhook is created.
+type Webhook struct {
+	ID        int    `json:"id"`
+	ProjectID int    `json:"project_id"`
+	URL       string `json:"url"`
+	Secret    string `json:"secret,omitempty"`
+	// Events lists the actions to deliver; empty means all of them
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Wants reports whether the webhook subscribes to action.
+func (w Webhook) Wants(action string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, event := range w.Events {
+		if event == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookDelivery is one change event queued for one webhook.
+type WebhookDelivery struct {
+	ID            int64           `json:"id"`
+	WebhookID     int             `json:"webhook_id"`
+	Action        string          `json:"action"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	ResponseCode  *int            `json:"response_code"`
+	LastError     *string         `json:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at"`
+}
+
+// DueDelivery is a claimed delivery with the webhook it goes to.
+type DueDelivery struct {
+	Delivery WebhookDelivery
+	Webhook  Webhook
+}
+
+// WebhookPayload is the body posted to webhook receivers.
+type WebhookPayload struct {
+	Event      string    `json:"event"`
+	ProjectID  int       `json:"project_id"`
+	Actor      string    `json:"actor"`
+	Goods      []Good    `json:"goods"`
+	OccurredAt time.Time `json:"occurred_at"`
+	// FromProjectID, ToProjectID and Moved are set on move events only
+	FromProjectID int   `json:"from_project_id,omitempty"`
+	ToProjectID   int   `json:"to_project_id,omitempty"`
+	Moved         []int `json:"moved,omitempty"`
+}
+
+// NewWebhookPayloads splits a change event into one payload per project it
+// touches, ordered by project id. The project goods were moved out of gets
+// the moved goods too.
+func NewWebhookPayloads(event Collection, occurredAt time.Time) []WebhookPayload {
+	moved := make(map[int]bool, len(event.Moved))
+	for _, id := range event.Moved {
+		moved[id] = true
+	}
+
+	byProject := make(map[int][]Good)
+	for _, good := range event.Goods {
+		byProject[good.ProjectID] = append(byProject[good.ProjectID], good)
+
+		if moved[good.ID] && good.ProjectID != event.FromProjectID {
+			byProject[event.FromProjectID] = append(byProject[event.FromProjectID], good)
+		}
+	}
+
+	payloads := make([]WebhookPayload, 0, len(byProject))
+	for projectID, goods := range byProject {
+		payloads = append(payloads, WebhookPayload{
+			Event:      event.Action,
+			ProjectID:  projectID,
+			Actor:      event.Actor,
+			Goods:      goods,
+			OccurredAt: occurredAt,
+
+			FromProjectID: event.FromProjectID,
+			ToProjectID:   event.ToProjectID,
+			Moved:         event.Moved,
+		})
+	}
+
+	sort.Slice(payloads, func(i, j int) bool {
+		return payloads[i].ProjectID < payloads[j].ProjectID
+	})
+
+	return payloads
+}
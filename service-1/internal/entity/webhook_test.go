@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewWebhookPayloads(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	a := Good{ID: 1, ProjectID: 2}
+	b := Good{ID: 2, ProjectID: 1}
+	c := Good{ID: 3, ProjectID: 2}
+	shiftedTarget := Good{ID: 4, ProjectID: 2}
+	shiftedSource := Good{ID: 5, ProjectID: 5}
+
+	tests := []struct {
+		name  string
+		event Collection
+		want  []WebhookPayload
+	}{
+		{
+			name:  "no goods",
+			event: Collection{Action: ActionPublish},
+			want:  []WebhookPayload{},
+		},
+		{
+			name:  "one project per payload",
+			event: Collection{Action: ActionUpdate, Actor: "alice", Goods: []Good{a, b, c}},
+			want: []WebhookPayload{
+				{Event: ActionUpdate, ProjectID: 1, Actor: "alice", Goods: []Good{b}, OccurredAt: now},
+				{Event: ActionUpdate, ProjectID: 2, Actor: "alice", Goods: []Good{a, c}, OccurredAt: now},
+			},
+		},
+		{
+			name: "move reaches the source project",
+			event: Collection{
+				Action:        ActionMove,
+				Goods:         []Good{a, c},
+				FromProjectID: 5,
+				ToProjectID:   2,
+				Moved:         []int{1, 3},
+			},
+			want: []WebhookPayload{
+				{Event: ActionMove, ProjectID: 2, Goods: []Good{a, c}, OccurredAt: now, FromProjectID: 5, ToProjectID: 2, Moved: []int{1, 3}},
+				{Event: ActionMove, ProjectID: 5, Goods: []Good{a, c}, OccurredAt: now, FromProjectID: 5, ToProjectID: 2, Moved: []int{1, 3}},
+			},
+		},
+		{
+			name: "move with goods shifted in either project",
+			event: Collection{
+				Action:        ActionMove,
+				Goods:         []Good{a, shiftedTarget, shiftedSource},
+				FromProjectID: 5,
+				ToProjectID:   2,
+				Moved:         []int{1},
+			},
+			want: []WebhookPayload{
+				{Event: ActionMove, ProjectID: 2, Goods: []Good{a, shiftedTarget}, OccurredAt: now, FromProjectID: 5, ToProjectID: 2, Moved: []int{1}},
+				{Event: ActionMove, ProjectID: 5, Goods: []Good{a, shiftedSource}, OccurredAt: now, FromProjectID: 5, ToProjectID: 2, Moved: []int{1}},
+			},
+		},
+		{
+			name:  "empty move",
+			event: Collection{Action: ActionMove, FromProjectID: 5, ToProjectID: 2},
+			want:  []WebhookPayload{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewWebhookPayloads(tt.event, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewWebhookPayloads() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
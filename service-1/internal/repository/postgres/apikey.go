@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key entity.APIKey, hash string) (entity.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (entity.APIKey, error)
+	List(ctx context.Context) ([]entity.APIKey, error)
+	Revoke(ctx context.Context, id int, at time.Time) (entity.APIKey, error)
+}
+
+type apiKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return apiKeyRepository{db}
+}
+
+const selectAPIKey = `SELECT k.id, k.name, k.prefix, k.role, k.organization_id, k.created_at, k.expires_at, k.revoked_at,
+                             COALESCE(array_agg(p.project_id) FILTER (WHERE p.project_id IS NOT NULL), '{}')
+                      FROM api_keys k
+                      LEFT JOIN api_key_projects p ON p.api_key_id = k.id`
+
+// Create stores the key in the organization of its projects, which must all
+// belong to the same one.
+func (a apiKeyRepository) Create(ctx context.Context, key entity.APIKey, hash string) (entity.APIKey, error) {
+	tx, err := beginTx(ctx, a.db, nil)
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, projectID := range key.ProjectIDs {
+		var organizationID int
+
+		err := tx.QueryRowContext(ctx, "SELECT organization_id FROM projects WHERE id = $1", projectID).Scan(&organizationID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.APIKey{}, fmt.Errorf("project #%d %w", projectID, entity.ErrProjectNotFound)
+		}
+		if err != nil {
+			return entity.APIKey{}, fmt.Errorf("trouble checking project existence: %w", err)
+		}
+
+		if i > 0 && organizationID != key.OrganizationID {
+			return entity.APIKey{}, entity.NewValidationError(entity.FieldError{Field: "project_ids", Rule: "organization"})
+		}
+
+		key.OrganizationID = organizationID
+	}
+
+	stmt := `INSERT INTO api_keys(name, prefix, key_hash, role, created_at, expires_at, organization_id)
+             VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id;`
+
+	if err := tx.QueryRowContext(ctx, stmt,
+		key.Name,
+		key.Prefix,
+		hash,
+		key.Role.String(),
+		key.CreatedAt,
+		key.ExpiresAt,
+		key.OrganizationID,
+	).Scan(&key.ID); err != nil {
+		return entity.APIKey{}, fmt.Errorf("trouble executing db: %w", err)
+	}
+
+	for _, projectID := range key.ProjectIDs {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO api_key_projects(api_key_id, project_id) VALUES($1, $2) ON CONFLICT DO NOTHING;",
+			key.ID, projectID); err != nil {
+			return entity.APIKey{}, fmt.Errorf("trouble scoping api key: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.APIKey{}, fmt.Errorf("trouble with committing a transaction: %w", err)
+	}
+
+	return key, nil
+}
+
+func (a apiKeyRepository) GetByHash(ctx context.Context, hash string) (entity.APIKey, error) {
+	stmt := selectAPIKey + ` WHERE k.key_hash = $1 GROUP BY k.id;`
+
+	key, err := scanAPIKey(a.db.QueryRowContext(ctx, stmt, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.APIKey{}, entity.ErrAPIKeyNotFound
+		}
+
+		return entity.APIKey{}, fmt.Errorf("query error: %w", err)
+	}
+
+	return key, nil
+}
+
+func (a apiKeyRepository) List(ctx context.Context) ([]entity.APIKey, error) {
+	stmt := selectAPIKey + ` GROUP BY k.id ORDER BY k.id;`
+
+	rows, err := a.db.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]entity.APIKey, 0)
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("trouble with scanning row: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (a apiKeyRepository) Revoke(ctx context.Context, id int, at time.Time) (entity.APIKey, error) {
+	res, err := a.db.ExecContext(ctx,
+		"UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2;", at, id)
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("trouble revoking api key: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return entity.APIKey{}, entity.ErrAPIKeyNotFound
+	}
+
+	stmt := selectAPIKey + ` WHERE k.id = $1 GROUP BY k.id;`
+
+	key, err := scanAPIKey(a.db.QueryRowContext(ctx, stmt, id))
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("query error: %w", err)
+	}
+
+	return key, nil
+}
+
+func scanAPIKey(row scanner) (entity.APIKey, error) {
+	var (
+		key      entity.APIKey
+		role     string
+		projects []int64
+	)
+
+	if err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&role,
+		&key.OrganizationID,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.RevokedAt,
+		pq.Array(&projects),
+	); err != nil {
+		return entity.APIKey{}, err
+	}
+
+	if err := key.Role.UnmarshalText([]byte(role)); err != nil {
+		return entity.APIKey{}, err
+	}
+
+	key.ProjectIDs = make([]int, len(projects))
+	for i, id := range projects {
+		key.ProjectIDs[i] = int(id)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,183 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/pkg/requestid"
+)
+
+type AuditRepository interface {
+	List(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, int, error)
+}
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return auditRepository{db}
+}
+
+type noAuditKey struct{}
+
+// withoutAudit marks writes that are rolled back, such as previews, so that
+// writeAudit skips them.
+func withoutAudit(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noAuditKey{}, true)
+}
+
+// writeAudit records a change inside the transaction that makes it, so the
+// entry exists if and only if the change is committed. before and after are
+// nil for the sides that do not exist. A good after the change is also
+// stored as its next revision.
+func writeAudit(ctx context.Context, tx *sql.Tx, action string, projectID, goodID int, before, after any) error {
+	if ctx.Value(noAuditKey{}) != nil {
+		return nil
+	}
+
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	stmt := `INSERT INTO audit_log(project_id, good_id, action, actor, request_id, client_ip, before, after)
+             VALUES($1, $2, $3, $4, $5, $6, $7, $8);`
+
+	if _, err := tx.ExecContext(ctx, stmt,
+		projectID,
+		goodID,
+		action,
+		entity.ActorFromContext(ctx),
+		requestid.FromContext(ctx),
+		requestid.ClientIPFromContext(ctx),
+		beforeJSON,
+		afterJSON,
+	); err != nil {
+		return fmt.Errorf("trouble writing audit entry: %w", err)
+	}
+
+	if good, ok := after.(entity.Good); ok {
+		return writeRevision(ctx, tx, action, good)
+	}
+
+	return nil
+}
+
+func auditJSON(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("trouble encoding audit state: %w", err)
+	}
+
+	return string(data), nil
+}
+
+func (a auditRepository) List(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, int, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ProjectID != 0 {
+		add("project_id = $%d", filter.ProjectID)
+	}
+
+	if filter.OrganizationID != 0 {
+		add("project_id IN (SELECT id FROM projects WHERE organization_id = $%d)", filter.OrganizationID)
+	}
+
+	if filter.GoodID != 0 {
+		add("good_id = $%d", filter.GoodID)
+	}
+
+	if !filter.From.IsZero() {
+		add("created_at >= $%d", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		add("created_at < $%d", filter.To)
+	}
+
+	where := ""
+	if len(conditions) != 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	stmt := fmt.Sprintf(`SELECT id, project_id, good_id, action, actor, request_id, client_ip,
+                                COALESCE(before, 'null'), COALESCE(after, 'null'), created_at
+                         FROM audit_log%s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d;`,
+		where, len(args)+1, len(args)+2)
+
+	var total int
+
+	entries := make([]entity.AuditEntry, 0, filter.Limit)
+
+	err := scoped(ctx, a.db, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&total); err != nil {
+			return fmt.Errorf("query error: %w", err)
+		}
+
+		rows, err := tx.QueryContext(ctx, stmt, append(args, filter.Limit, filter.Offset)...)
+		if err != nil {
+			return fmt.Errorf("query error: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				entry         entity.AuditEntry
+				before, after []byte
+			)
+
+			if err := rows.Scan(
+				&entry.ID,
+				&entry.ProjectID,
+				&entry.GoodID,
+				&entry.Action,
+				&entry.Actor,
+				&entry.RequestID,
+				&entry.ClientIP,
+				&before,
+				&after,
+				&entry.CreatedAt,
+			); err != nil {
+				return fmt.Errorf("trouble with scanning row: %w", err)
+			}
+
+			entry.Before = before
+			entry.After = after
+
+			entries = append(entries, entry)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error during iteration: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/pkg/requestid"
+)
+
+func TestWriteAudit(t *testing.T) {
+	ctx := entity.WithPrincipal(context.Background(), entity.Principal{OrganizationID: 1, Subject: "admin"})
+	ctx = requestid.WithClientIP(requestid.WithID(ctx, "req-1"), "192.0.2.1")
+
+	before := entity.Good{ID: 7, ProjectID: 1, Name: "old"}
+	after := entity.Good{ID: 7, ProjectID: 1, Name: "new"}
+
+	const change = "UPDATE goods"
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		after  any
+		commit bool
+		// want are the statements committed after the change, by table
+		want []string
+	}{
+		{
+			name:   "committed with the change",
+			ctx:    ctx,
+			after:  after,
+			commit: true,
+			want:   []string{change, "INSERT INTO audit_log", "INSERT INTO good_revisions"},
+		},
+		{
+			name:   "removal has no revision",
+			ctx:    ctx,
+			commit: true,
+			want:   []string{change, "INSERT INTO audit_log"},
+		},
+		{
+			name:  "rolled back with the change",
+			ctx:   ctx,
+			after: after,
+		},
+		{
+			name:   "skipped for previews",
+			ctx:    withoutAudit(ctx),
+			after:  after,
+			commit: true,
+			want:   []string{change},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, committed := openRecordingDB(
+				fakeTable{query: change, organizationID: 1},
+				fakeTable{query: "INSERT INTO audit_log", organizationID: 1},
+				fakeTable{query: "INSERT INTO good_revisions", organizationID: 1},
+			)
+			defer db.Close()
+
+			tx, err := beginTx(tt.ctx, db, nil)
+			if err != nil {
+				t.Fatalf("beginTx() error = %v", err)
+			}
+
+			if _, err := tx.ExecContext(tt.ctx, change+" SET name = $1 WHERE id = $2;", "new", 7); err != nil {
+				t.Fatalf("change error = %v", err)
+			}
+
+			if err := writeAudit(tt.ctx, tx, entity.ActionUpdate, 1, 7, before, tt.after); err != nil {
+				t.Fatalf("writeAudit() error = %v", err)
+			}
+
+			if tt.commit {
+				err = tx.Commit()
+			} else {
+				err = tx.Rollback()
+			}
+			if err != nil {
+				t.Fatalf("ending the transaction: %v", err)
+			}
+
+			var got []string
+			for _, statement := range *committed {
+				for _, table := range []string{change, "INSERT INTO audit_log", "INSERT INTO good_revisions"} {
+					if strings.Contains(statement.query, table) {
+						got = append(got, table)
+					}
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("committed %q, want %q", got, tt.want)
+			}
+
+			for _, statement := range *committed {
+				if !strings.Contains(statement.query, "INSERT INTO audit_log") {
+					continue
+				}
+
+				// project, good, action, actor, request id and client IP
+				want := []driver.Value{int64(1), int64(7), entity.ActionUpdate, "admin", "req-1", "192.0.2.1"}
+				if !reflect.DeepEqual(statement.args[:len(want)], want) {
+					t.Errorf("audit arguments = %v, want %v", statement.args[:len(want)], want)
+				}
+
+				if before, _ := statement.args[6].(string); !strings.Contains(before, `"old"`) {
+					t.Errorf("before = %q, want the good before the change", before)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditListFilter(t *testing.T) {
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter entity.AuditFilter
+		// where and args are those of the count; the page adds limit and offset
+		where string
+		args  []driver.Value
+	}{
+		{
+			name:   "everything",
+			filter: entity.AuditFilter{Limit: 50},
+		},
+		{
+			name:   "organization",
+			filter: entity.AuditFilter{OrganizationID: 1, Limit: 10},
+			where:  " WHERE project_id IN (SELECT id FROM projects WHERE organization_id = $1)",
+			args:   []driver.Value{int64(1)},
+		},
+		{
+			name:   "project of an organization",
+			filter: entity.AuditFilter{OrganizationID: 1, ProjectID: 2, Limit: 10, Offset: 20},
+			where:  " WHERE project_id = $1 AND project_id IN (SELECT id FROM projects WHERE organization_id = $2)",
+			args:   []driver.Value{int64(2), int64(1)},
+		},
+		{
+			name:   "good since",
+			filter: entity.AuditFilter{GoodID: 3, From: from, Limit: 1},
+			where:  " WHERE good_id = $1 AND created_at >= $2",
+			args:   []driver.Value{int64(3), from},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, committed := openRecordingDB(
+				fakeTable{query: "COUNT(*) FROM audit_log", organizationID: 1, columns: []string{"count"}, values: [][]driver.Value{{int64(0)}}},
+				fakeTable{query: "FROM audit_log", organizationID: 1, columns: []string{"id"}},
+			)
+			defer db.Close()
+
+			ctx := entity.WithPrincipal(context.Background(), entity.Principal{OrganizationID: 1})
+			if _, _, err := NewAuditRepository(db).List(ctx, tt.filter); err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			if len(*committed) != 2 {
+				t.Fatalf("ran %d statements, want the count and the page", len(*committed))
+			}
+
+			count, page := (*committed)[0], (*committed)[1]
+
+			if want := "SELECT COUNT(*) FROM audit_log" + tt.where; count.query != want {
+				t.Errorf("count = %q, want %q", count.query, want)
+			}
+
+			if !reflect.DeepEqual(count.args, tt.args) {
+				t.Errorf("count arguments = %v, want %v", count.args, tt.args)
+			}
+
+			wantPage := append(append([]driver.Value{}, tt.args...), int64(tt.filter.Limit), int64(tt.filter.Offset))
+			if !reflect.DeepEqual(page.args, wantPage) {
+				t.Errorf("page arguments = %v, want %v", page.args, wantPage)
+			}
+
+			if !strings.Contains(page.query, "FROM audit_log"+tt.where+" ORDER BY") {
+				t.Errorf("page = %q, want the conditions %q", page.query, tt.where)
+			}
+		})
+	}
+}
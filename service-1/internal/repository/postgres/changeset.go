@@ -0,0 +1,498 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skantay/hezzl/internal/controller/mq/nats/v"
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+type ChangesetRepository interface {
+	Create(ctx context.Context, projectID int) (entity.Changeset, error)
+	// List returns the project's drafts with their changes, oldest first.
+	List(ctx context.Context, projectID int) ([]entity.Changeset, error)
+	Get(ctx context.Context, id, projectID int) (entity.Changeset, error)
+	Stage(ctx context.Context, id, projectID int, change entity.Change) (entity.Change, error)
+	// Preview returns a page of the project's goods, by priority, as they
+	// would be after publishing, and the ids of the goods the draft touches.
+	// Nothing is locked or audited.
+	Preview(ctx context.Context, id, projectID, limit, offset int, check ChangeCheck) ([]entity.Good, []int, error)
+	// Publish applies the draft in one transaction and announces the goods
+	// it touched in one event.
+	Publish(ctx context.Context, id, projectID int, check ChangeCheck) ([]entity.Good, error)
+	Discard(ctx context.Context, id, projectID int) error
+}
+
+// ChangeCheck validates a staged change again just before it is applied,
+// since the rules and the catalogue may have moved on since it was staged.
+// It may normalize the change.
+type ChangeCheck func(ctx context.Context, change *entity.Change) error
+
+// changesetRepository shares the connection and the event publisher of the
+// goods it writes.
+type changesetRepository struct {
+	goodRepository
+}
+
+func NewChangesetRepository(db *sql.DB, nc v.NC) ChangesetRepository {
+	return changesetRepository{goodRepository{db, nc}}
+}
+
+const changesetColumns = `id, project_id, status, author, created_at, closed_at`
+
+func scanChangeset(row scanner, changeset *entity.Changeset) error {
+	return row.Scan(
+		&changeset.ID,
+		&changeset.ProjectID,
+		&changeset.Status,
+		&changeset.Author,
+		&changeset.CreatedAt,
+		&changeset.ClosedAt,
+	)
+}
+
+func (c changesetRepository) Create(ctx context.Context, projectID int) (entity.Changeset, error) {
+	changeset := entity.Changeset{Changes: []entity.Change{}}
+
+	// The project is looked up within the caller's organization
+	err := scoped(ctx, c.db, func(tx *sql.Tx) error {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", projectID).Scan(&exists); err != nil {
+			return fmt.Errorf("trouble checking project existence: %w", err)
+		}
+		if !exists {
+			return entity.ErrProjectNotFound
+		}
+
+		stmt := `INSERT INTO changesets(project_id, author) VALUES($1, $2) RETURNING ` + changesetColumns + `;`
+
+		if err := scanChangeset(tx.QueryRowContext(ctx, stmt, projectID, entity.ActorFromContext(ctx)), &changeset); err != nil {
+			return fmt.Errorf("trouble executing db: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return entity.Changeset{}, err
+	}
+
+	return changeset, nil
+}
+
+func (c changesetRepository) List(ctx context.Context, projectID int) ([]entity.Changeset, error) {
+	stmt := `SELECT ` + changesetColumns + ` FROM changesets
+             WHERE project_id = $1 AND status = 'draft'
+             ORDER BY id;`
+
+	changesets := make([]entity.Changeset, 0)
+
+	err := scoped(ctx, c.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, stmt, projectID)
+		if err != nil {
+			return fmt.Errorf("query error: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var changeset entity.Changeset
+
+			if err := scanChangeset(rows, &changeset); err != nil {
+				return fmt.Errorf("trouble with scanning row: %w", err)
+			}
+
+			changesets = append(changesets, changeset)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error during iteration: %w", err)
+		}
+
+		// The connection serves one query at a time
+		rows.Close()
+
+		for i := range changesets {
+			if changesets[i].Changes, err = listChanges(ctx, tx, changesets[i].ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changesets, nil
+}
+
+func (c changesetRepository) Get(ctx context.Context, id, projectID int) (entity.Changeset, error) {
+	stmt := `SELECT ` + changesetColumns + ` FROM changesets WHERE id = $1 AND project_id = $2;`
+
+	var changeset entity.Changeset
+
+	err := scoped(ctx, c.db, func(tx *sql.Tx) error {
+		err := scanChangeset(tx.QueryRowContext(ctx, stmt, id, projectID), &changeset)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("changeset #%d %w", id, entity.ErrChangesetNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("query error: %w", err)
+		}
+
+		changeset.Changes, err = listChanges(ctx, tx, id)
+
+		return err
+	})
+	if err != nil {
+		return entity.Changeset{}, err
+	}
+
+	return changeset, nil
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func listChanges(ctx context.Context, db querier, changesetID int) ([]entity.Change, error) {
+	stmt := `SELECT id, action, good_id, input, priority, author, created_at
+             FROM changeset_changes WHERE changeset_id = $1
+             ORDER BY id;`
+
+	rows, err := db.QueryContext(ctx, stmt, changesetID)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]entity.Change, 0)
+
+	for rows.Next() {
+		var (
+			change entity.Change
+			input  []byte
+		)
+
+		if err := rows.Scan(&change.ID, &change.Action, &change.GoodID, &input, &change.Priority, &change.Author, &change.CreatedAt); err != nil {
+			return nil, fmt.Errorf("trouble with scanning row: %w", err)
+		}
+
+		if input != nil {
+			change.Input = &entity.GoodInput{}
+
+			if err := json.Unmarshal(input, change.Input); err != nil {
+				return nil, fmt.Errorf("trouble decoding change #%d: %w", change.ID, err)
+			}
+		}
+
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	return changes, nil
+}
+
+// lockDraft locks the changeset for the rest of the transaction, provided
+// it is a draft of the project.
+func lockDraft(ctx context.Context, tx *sql.Tx, id, projectID int) (entity.Changeset, error) {
+	return getDraft(ctx, tx, id, projectID, " FOR UPDATE")
+}
+
+// getDraft reads a draft of the project with its changes; lock is appended
+// to the query.
+func getDraft(ctx context.Context, tx *sql.Tx, id, projectID int, lock string) (entity.Changeset, error) {
+	stmt := `SELECT ` + changesetColumns + ` FROM changesets
+             WHERE id = $1 AND project_id = $2 AND status = 'draft'` + lock + `;`
+
+	var changeset entity.Changeset
+
+	err := scanChangeset(tx.QueryRowContext(ctx, stmt, id, projectID), &changeset)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Changeset{}, fmt.Errorf("draft changeset #%d %w", id, entity.ErrChangesetNotFound)
+	}
+	if err != nil {
+		return entity.Changeset{}, fmt.Errorf("query error: %w", err)
+	}
+
+	if changeset.Changes, err = listChanges(ctx, tx, id); err != nil {
+		return entity.Changeset{}, err
+	}
+
+	return changeset, nil
+}
+
+// Stage appends a change to a draft. The good it targets must exist in the
+// project now; it is looked up again when the draft is applied.
+func (c changesetRepository) Stage(ctx context.Context, id, projectID int, change entity.Change) (entity.Change, error) {
+	tx, err := beginTx(ctx, c.db, nil)
+	if err != nil {
+		return entity.Change{}, fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockDraft(ctx, tx, id, projectID); err != nil {
+		return entity.Change{}, err
+	}
+
+	if change.GoodID != nil {
+		var exists bool
+
+		stmt := "SELECT EXISTS(SELECT 1 FROM goods WHERE id = $1 AND project_id = $2)"
+		if err := tx.QueryRowContext(ctx, stmt, *change.GoodID, projectID).Scan(&exists); err != nil {
+			return entity.Change{}, fmt.Errorf("query error: %w", err)
+		}
+		if !exists {
+			return entity.Change{}, fmt.Errorf("good with id #%d %w", *change.GoodID, entity.ErrGoodNotFound)
+		}
+	}
+
+	var input *string
+	if change.Input != nil {
+		data, err := json.Marshal(change.Input)
+		if err != nil {
+			return entity.Change{}, fmt.Errorf("trouble encoding change: %w", err)
+		}
+
+		input = jsonArg(data)
+	}
+
+	change.Author = entity.ActorFromContext(ctx)
+
+	stmt := `INSERT INTO changeset_changes(changeset_id, action, good_id, input, priority, author)
+             VALUES($1, $2, $3, $4::jsonb, $5, $6) RETURNING id, created_at;`
+
+	err = tx.QueryRowContext(ctx, stmt, id, change.Action, change.GoodID, input, change.Priority, change.Author).
+		Scan(&change.ID, &change.CreatedAt)
+	if err != nil {
+		return entity.Change{}, fmt.Errorf("trouble executing db: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.Change{}, fmt.Errorf("trouble with committing a transaction: %w", err)
+	}
+
+	return change, nil
+}
+
+// apply checks and makes the changes inside the transaction, with the same
+// writes and audit entries as making them one by one, and returns the ids of
+// the goods they touched. A change that no longer fits the catalogue fails
+// the lot.
+func apply(ctx context.Context, tx *sql.Tx, projectID int, changes []entity.Change, check ChangeCheck) ([]int, error) {
+	var ids []int
+
+	touched := make(map[int]bool)
+	touch := func(goods ...entity.Good) {
+		for _, good := range goods {
+			if !touched[good.ID] {
+				touched[good.ID] = true
+				ids = append(ids, good.ID)
+			}
+		}
+	}
+
+	for _, change := range changes {
+		if err := check(ctx, &change); err != nil {
+			return nil, err
+		}
+
+		var (
+			goods []entity.Good
+			err   error
+		)
+
+		switch change.Action {
+		case entity.ActionCreate:
+			goods, err = applyCreate(ctx, tx, projectID, *change.Input)
+		case entity.ActionUpdate:
+			var good entity.Good
+			good, err = updateGood(ctx, tx, *change.GoodID, projectID, *change.Input, entity.ActionUpdate)
+			goods = []entity.Good{good}
+		case entity.ActionRemove:
+			var good entity.Good
+			good, err = setGoodRemoved(ctx, tx, *change.GoodID, projectID, true, entity.ActionRemove)
+			goods = []entity.Good{good}
+		case entity.ActionReprioritize:
+			goods, err = updatePriority(ctx, tx, *change.Priority, *change.GoodID, projectID)
+		default:
+			err = fmt.Errorf("unknown action %q", change.Action)
+		}
+
+		// Names taken by earlier changes of the draft only show up here
+		if errors.Is(err, entity.ErrGoodNotFound) || errors.Is(err, entity.ErrCategoryNotFound) || errors.Is(err, entity.ErrGoodNameTaken) {
+			return nil, fmt.Errorf("%w: change #%d: %v", entity.ErrChangesetConflict, change.ID, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("trouble applying change #%d: %w", change.ID, err)
+		}
+
+		touch(goods...)
+	}
+
+	return ids, nil
+}
+
+// applyCreate adds the good at the end of the catalogue, as creating it
+// directly would.
+func applyCreate(ctx context.Context, tx *sql.Tx, projectID int, input entity.GoodInput) ([]entity.Good, error) {
+	var maxPriority int
+	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(priority), 0) FROM goods WHERE project_id = $1", projectID).Scan(&maxPriority); err != nil {
+		return nil, fmt.Errorf("get max priority error: %w", err)
+	}
+
+	good := entity.Good{
+		ProjectID:  projectID,
+		Name:       input.Name,
+		Priority:   maxPriority + 1,
+		CreatedAt:  time.Now(),
+		Attributes: input.Attributes,
+	}
+
+	if input.Tags != nil {
+		good.Tags = *input.Tags
+	}
+
+	if input.CategoryID != nil && *input.CategoryID != 0 {
+		good.CategoryID = input.CategoryID
+	}
+
+	if input.Visibility != nil {
+		good.Visibility = *input.Visibility
+	}
+
+	created, err := createGood(ctx, tx, good)
+	if err != nil {
+		return nil, err
+	}
+
+	return []entity.Good{created}, nil
+}
+
+func (c changesetRepository) Preview(ctx context.Context, id, projectID, limit, offset int, check ChangeCheck) ([]entity.Good, []int, error) {
+	tx, err := beginTx(ctx, c.db, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	// Nothing applied here is ever committed
+	defer tx.Rollback()
+
+	changeset, err := getDraft(ctx, tx, id, projectID, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids, err := apply(withoutAudit(ctx), tx, projectID, changeset.Changes, check)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stmt := `SELECT ` + goodColumns + ` FROM goods WHERE project_id = $1
+             ORDER BY priority, id
+             LIMIT $2 OFFSET $3;`
+
+	rows, err := tx.QueryContext(ctx, stmt, projectID, limit, offset)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	goods := make([]entity.Good, 0)
+
+	for rows.Next() {
+		var good entity.Good
+
+		if err := scanGood(rows, &good); err != nil {
+			return nil, nil, fmt.Errorf("trouble with scanning row: %w", err)
+		}
+
+		goods = append(goods, good)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	if ids == nil {
+		ids = []int{}
+	}
+
+	return goods, ids, nil
+}
+
+func (c changesetRepository) Publish(ctx context.Context, id, projectID int, check ChangeCheck) ([]entity.Good, error) {
+	tx, err := beginTx(ctx, c.db, nil)
+	if err != nil {
+		return nil, fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	changeset, err := lockDraft(ctx, tx, id, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := apply(ctx, tx, projectID, changeset.Changes, check)
+	if err != nil {
+		return nil, err
+	}
+
+	goods := make([]entity.Good, 0, len(ids))
+
+	for _, goodID := range ids {
+		good, err := getGood(ctx, tx, goodID)
+		if err != nil {
+			return nil, err
+		}
+
+		goods = append(goods, good)
+	}
+
+	if err := closeChangeset(ctx, tx, id, entity.ChangesetPublished); err != nil {
+		return nil, err
+	}
+
+	if err := c.commit(ctx, tx, event(ctx, entity.ActionChangeset, goods...)); err != nil {
+		return nil, err
+	}
+
+	return goods, nil
+}
+
+func (c changesetRepository) Discard(ctx context.Context, id, projectID int) error {
+	tx, err := beginTx(ctx, c.db, nil)
+	if err != nil {
+		return fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockDraft(ctx, tx, id, projectID); err != nil {
+		return err
+	}
+
+	if err := closeChangeset(ctx, tx, id, entity.ChangesetDiscarded); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("trouble with committing a transaction: %w", err)
+	}
+
+	return nil
+}
+
+func closeChangeset(ctx context.Context, tx *sql.Tx, id int, status string) error {
+	if _, err := tx.ExecContext(ctx, "UPDATE changesets SET status = $1, closed_at = NOW() WHERE id = $2;", status, id); err != nil {
+		return fmt.Errorf("trouble executing db: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+// Clone copies the project's settings, categories, tags and non-removed
+// goods into a new project in one transaction, and announces the copies in
+// one create event. An empty name appends " (copy)" to the source's.
+func (p projectRepository) Clone(ctx context.Context, projectID int, name string) (entity.ProjectClone, error) {
+	// The goods are read twice, so they must not move in between
+	tx, err := beginTx(ctx, p.db, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return entity.ProjectClone{}, fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	clone := entity.ProjectClone{SourceID: projectID}
+
+	stmt := `INSERT INTO projects(name, created_at, attributes_schema, search_language, organization_id)
+             SELECT COALESCE(NULLIF($2, ''), name || ' (copy)'), NOW(), attributes_schema, search_language, organization_id
+             FROM projects WHERE id = $1
+             RETURNING id, name, created_at;`
+
+	err = tx.QueryRowContext(ctx, stmt, projectID, name).Scan(&clone.Project.ID, &clone.Project.Name, &clone.Project.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.ProjectClone{}, fmt.Errorf("project #%d %w", projectID, entity.ErrProjectNotFound)
+	}
+	if err != nil {
+		return entity.ProjectClone{}, fmt.Errorf("trouble executing db: %w", err)
+	}
+
+	oldCategories, newCategories, err := cloneCategories(ctx, tx, projectID, clone.Project.ID)
+	if err != nil {
+		return entity.ProjectClone{}, err
+	}
+
+	stmt = `INSERT INTO tags(project_id, name) SELECT $2, name FROM tags WHERE project_id = $1;`
+	if _, err := tx.ExecContext(ctx, stmt, projectID, clone.Project.ID); err != nil {
+		return entity.ProjectClone{}, fmt.Errorf("trouble copying tags: %w", err)
+	}
+
+	// Ids are drawn up front so the copies can be matched to their sources
+	stmt = `SELECT id, nextval(pg_get_serial_sequence('goods', 'id'))
+            FROM goods WHERE project_id = $1 AND NOT removed
+            ORDER BY priority, id;`
+
+	rows, err := tx.QueryContext(ctx, stmt, projectID)
+	if err != nil {
+		return entity.ProjectClone{}, fmt.Errorf("query error: %w", err)
+	}
+
+	var sourceIDs, ids []int
+
+	for rows.Next() {
+		var cloned entity.ClonedGood
+
+		if err := rows.Scan(&cloned.SourceID, &cloned.ID); err != nil {
+			rows.Close()
+			return entity.ProjectClone{}, fmt.Errorf("trouble with scanning row: %w", err)
+		}
+
+		clone.Goods = append(clone.Goods, cloned)
+		sourceIDs = append(sourceIDs, cloned.SourceID)
+		ids = append(ids, cloned.ID)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return entity.ProjectClone{}, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	if clone.Goods == nil {
+		clone.Goods = []entity.ClonedGood{}
+	}
+
+	stmt = `INSERT INTO goods(id, project_id, name, description, priority, removed, created_at, category_id, attributes, visible_from, visible_until)
+            SELECT m.id, $1, g.name, g.description, g.priority, false, NOW(), c.id, g.attributes, g.visible_from, g.visible_until
+            FROM unnest($2::int[], $3::int[]) AS m(source_id, id)
+            JOIN goods g ON g.id = m.source_id
+            LEFT JOIN unnest($4::int[], $5::int[]) AS c(source_id, id) ON c.source_id = g.category_id;`
+
+	_, err = tx.ExecContext(ctx, stmt,
+		clone.Project.ID,
+		pq.Array(sourceIDs),
+		pq.Array(ids),
+		pq.Array(oldCategories),
+		pq.Array(newCategories),
+	)
+	if err != nil {
+		return entity.ProjectClone{}, fmt.Errorf("trouble copying goods: %w", err)
+	}
+
+	stmt = `INSERT INTO good_tags(good_id, tag_id)
+            SELECT m.id, nt.id
+            FROM unnest($2::int[], $3::int[]) AS m(source_id, id)
+            JOIN good_tags gt ON gt.good_id = m.source_id
+            JOIN tags t ON t.id = gt.tag_id
+            JOIN tags nt ON nt.project_id = $1 AND lower(nt.name) = lower(t.name);`
+
+	if _, err := tx.ExecContext(ctx, stmt, clone.Project.ID, pq.Array(sourceIDs), pq.Array(ids)); err != nil {
+		return entity.ProjectClone{}, fmt.Errorf("trouble copying good tags: %w", err)
+	}
+
+	goods := make([]entity.Good, 0, len(ids))
+
+	for _, id := range ids {
+		good, err := getGood(ctx, tx, id)
+		if err != nil {
+			return entity.ProjectClone{}, err
+		}
+
+		if err := writeAudit(ctx, tx, entity.ActionCreate, good.ProjectID, good.ID, nil, good); err != nil {
+			return entity.ProjectClone{}, err
+		}
+
+		goods = append(goods, good)
+	}
+
+	if err := p.commit(ctx, tx, event(ctx, entity.ActionCreate, goods...)); err != nil {
+		return entity.ProjectClone{}, err
+	}
+
+	return clone, nil
+}
+
+// cloneCategories copies the category tree parents first and returns the
+// source ids alongside the ids of their copies.
+func cloneCategories(ctx context.Context, tx *sql.Tx, projectID, cloneID int) ([]int, []int, error) {
+	stmt := `WITH RECURSIVE tree AS (
+                 SELECT id, parent_id, name, created_at, 0 AS depth FROM categories
+                 WHERE project_id = $1 AND parent_id IS NULL
+                 UNION ALL
+                 SELECT c.id, c.parent_id, c.name, c.created_at, t.depth + 1 FROM categories c
+                 JOIN tree t ON c.parent_id = t.id
+             )
+             SELECT id, parent_id, name, created_at FROM tree ORDER BY depth, id;`
+
+	rows, err := tx.QueryContext(ctx, stmt, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query error: %w", err)
+	}
+
+	var categories []entity.Category
+
+	for rows.Next() {
+		var category entity.Category
+
+		if err := rows.Scan(&category.ID, &category.ParentID, &category.Name, &category.CreatedAt); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("trouble with scanning row: %w", err)
+		}
+
+		categories = append(categories, category)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	copies := make(map[int]int, len(categories))
+	sourceIDs := make([]int, 0, len(categories))
+	ids := make([]int, 0, len(categories))
+
+	for _, category := range categories {
+		var parentID *int
+		if category.ParentID != nil {
+			id := copies[*category.ParentID]
+			parentID = &id
+		}
+
+		var id int
+
+		stmt := `INSERT INTO categories(project_id, parent_id, name, created_at) VALUES($1, $2, $3, $4) RETURNING id;`
+		if err := tx.QueryRowContext(ctx, stmt, cloneID, parentID, category.Name, category.CreatedAt).Scan(&id); err != nil {
+			return nil, nil, fmt.Errorf("trouble copying category #%d: %w", category.ID, err)
+		}
+
+		copies[category.ID] = id
+		sourceIDs = append(sourceIDs, category.ID)
+		ids = append(ids, id)
+	}
+
+	return sourceIDs, ids, nil
+}
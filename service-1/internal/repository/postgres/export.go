@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+// exportFetchSize is how many rows are held in memory at a time.
+const exportFetchSize = 500
+
+// Export streams the project's goods in priority order to fn through a
+// server-side cursor, so memory use does not grow with the project. The
+// snapshot is consistent for the whole export.
+func (g goodRepository) Export(ctx context.Context, projectID int, filter entity.ExportFilter, fn func(entity.Good) error) error {
+	tx, err := beginTx(ctx, g.db, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", projectID).Scan(&exists); err != nil {
+		return fmt.Errorf("trouble checking project existence: %w", err)
+	}
+	if !exists {
+		return entity.ErrProjectNotFound
+	}
+
+	var limit any
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+
+	filter.ProjectID = projectID
+
+	stmt := `DECLARE export_goods NO SCROLL CURSOR FOR ` + listSubtree + `
+             SELECT ` + goodColumns + ` FROM goods
+             WHERE ` + listConditions + ` AND ($10 OR NOT removed)
+             ORDER BY priority, id
+             LIMIT $11;`
+
+	if _, err := tx.ExecContext(ctx, stmt, append(listArgs(filter.ListFilter), filter.IncludeRemoved, limit)...); err != nil {
+		return fmt.Errorf("trouble declaring cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM export_goods;", exportFetchSize)
+
+	for {
+		n, err := fetchExport(ctx, tx, fetch, fn)
+		if err != nil {
+			return err
+		}
+
+		if n < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+func fetchExport(ctx context.Context, tx *sql.Tx, fetch string, fn func(entity.Good) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return 0, fmt.Errorf("trouble fetching rows: %w", err)
+	}
+	defer rows.Close()
+
+	var n int
+
+	for rows.Next() {
+		var good entity.Good
+		if err := scanGood(rows, &good); err != nil {
+			return 0, fmt.Errorf("trouble with scanning row: %w", err)
+		}
+
+		if err := fn(good); err != nil {
+			return 0, err
+		}
+
+		n++
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	return n, nil
+}
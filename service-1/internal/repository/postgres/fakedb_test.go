@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// fakeTable answers the statements containing query with the rows of one
+// organization. It applies row level security as the policies do: the rows
+// are seen only by transactions whose app.organization_id is that
+// organization or '*', and by nothing outside a transaction.
+type fakeTable struct {
+	query          string
+	organizationID int
+	columns        []string
+	values         [][]driver.Value
+}
+
+// fakeStatement is a statement the database ran, with its arguments.
+type fakeStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// openFakeDB returns a database serving the tables.
+func openFakeDB(tables ...fakeTable) *sql.DB {
+	db, _ := openRecordingDB(tables...)
+	return db
+}
+
+// openRecordingDB is openFakeDB that also returns the statements of the
+// committed transactions, in order. Those of rolled back transactions are
+// dropped, as their effects are.
+func openRecordingDB(tables ...fakeTable) (*sql.DB, *[]fakeStatement) {
+	committed := new([]fakeStatement)
+
+	return sql.OpenDB(fakeConnector{tables, committed}), committed
+}
+
+type fakeConnector struct {
+	tables    []fakeTable
+	committed *[]fakeStatement
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{tables: c.tables, committed: c.committed}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("open through the connector")
+}
+
+type fakeConn struct {
+	tables []fakeTable
+	// setting is the transaction's app.organization_id
+	setting   string
+	pending   []fakeStatement
+	committed *[]fakeStatement
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("statements are not prepared")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+// Commit and Rollback drop the setting, which set_config made local.
+func (c *fakeConn) Commit() error {
+	*c.committed = append(*c.committed, c.pending...)
+	c.setting, c.pending = "", nil
+
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.setting, c.pending = "", nil
+	return nil
+}
+
+func (c *fakeConn) record(query string, args []driver.NamedValue) {
+	statement := fakeStatement{query: query}
+	for _, arg := range args {
+		statement.args = append(statement.args, arg.Value)
+	}
+
+	c.pending = append(c.pending, statement)
+}
+
+func (c *fakeConn) table(query string) (fakeTable, bool, error) {
+	for _, table := range c.tables {
+		if strings.Contains(query, table.query) {
+			visible := c.setting == allOrganizations || c.setting == strconv.Itoa(table.organizationID)
+
+			return table, visible, nil
+		}
+	}
+
+	return fakeTable{}, false, fmt.Errorf("unexpected statement %q", query)
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if strings.Contains(query, "set_config('app.organization_id'") {
+		c.setting = args[0].Value.(string)
+
+		return &fakeRows{columns: []string{"set_config"}, values: [][]driver.Value{{c.setting}}}, nil
+	}
+
+	table, visible, err := c.table(query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.record(query, args)
+
+	rows := &fakeRows{columns: table.columns}
+	if visible {
+		rows.values = table.values
+	}
+
+	return rows, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "set_config('app.organization_id'") {
+		if _, err := c.QueryContext(ctx, query, args); err != nil {
+			return nil, err
+		}
+
+		return driver.RowsAffected(0), nil
+	}
+
+	table, visible, err := c.table(query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.record(query, args)
+
+	if !visible {
+		return driver.RowsAffected(0), nil
+	}
+
+	return driver.RowsAffected(len(table.values)), nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+// Import writes one chunk of validated rows in a single transaction. Each row
+// runs under a savepoint, so a failing row is reported in its outcome without
+// aborting the others. With uniqueNames, creating a name that is already
+// taken in the project fails the row.
+func (g goodRepository) Import(ctx context.Context, projectID int, rows []entity.ImportRow, opts entity.ImportOptions, uniqueNames bool) ([]entity.ImportOutcome, error) {
+	tx, err := beginTx(ctx, g.db, nil)
+	if err != nil {
+		return nil, fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var maxPriority int
+
+	// Locking the project serializes imports and creates that append priorities
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE((SELECT MAX(priority) FROM goods WHERE project_id = p.id), 0)
+                                   FROM projects p WHERE p.id = $1 FOR UPDATE;`, projectID).Scan(&maxPriority)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, entity.ErrProjectNotFound
+		}
+
+		return nil, fmt.Errorf("trouble locking the project: %w", err)
+	}
+
+	outcomes := make([]entity.ImportOutcome, 0, len(rows))
+
+	for _, row := range rows {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT import_row;"); err != nil {
+			return nil, fmt.Errorf("trouble with savepoint: %w", err)
+		}
+
+		outcome := importRow(ctx, tx, projectID, row, opts.Upsert, uniqueNames, &maxPriority)
+
+		release := "RELEASE SAVEPOINT import_row;"
+		if outcome.Err != nil {
+			release = "ROLLBACK TO SAVEPOINT import_row;"
+		}
+
+		if _, err := tx.ExecContext(ctx, release); err != nil {
+			return nil, fmt.Errorf("trouble with savepoint: %w", err)
+		}
+
+		outcomes = append(outcomes, outcome)
+	}
+
+	if opts.DryRun {
+		return outcomes, nil
+	}
+
+	var created, updated []entity.Good
+
+	for _, outcome := range outcomes {
+		switch {
+		case outcome.Err != nil:
+		case outcome.Updated:
+			updated = append(updated, outcome.Good)
+		default:
+			created = append(created, outcome.Good)
+		}
+	}
+
+	if err := g.commit(ctx, tx,
+		event(ctx, entity.ActionCreate, created...),
+		event(ctx, entity.ActionUpdate, updated...),
+	); err != nil {
+		return nil, err
+	}
+
+	return outcomes, nil
+}
+
+func importRow(ctx context.Context, tx *sql.Tx, projectID int, row entity.ImportRow, upsert, uniqueNames bool, maxPriority *int) entity.ImportOutcome {
+	outcome := entity.ImportOutcome{Row: row.Row}
+
+	var existingID int
+
+	if upsert || uniqueNames {
+		err := tx.QueryRowContext(ctx, `SELECT id FROM goods
+                                        WHERE project_id = $1 AND lower(name) = lower($2) AND NOT removed
+                                        ORDER BY id LIMIT 1 FOR UPDATE;`, projectID, row.Name).Scan(&existingID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			outcome.Err = fmt.Errorf("query error: %w", err)
+
+			return outcome
+		}
+	}
+
+	if existingID != 0 && upsert {
+		before, err := getForUpdate(ctx, tx, existingID, projectID)
+		if err != nil {
+			outcome.Err = err
+
+			return outcome
+		}
+
+		stmt := `UPDATE goods SET
+                     name = $1,
+                     description = COALESCE($2, description),
+                     priority = COALESCE($3, priority),
+                     attributes = COALESCE($4::jsonb, attributes)
+                 WHERE id = $5 AND project_id = $6 RETURNING ` + goodColumns + `;`
+
+		if err := scanGood(tx.QueryRowContext(ctx, stmt, row.Name, row.Description, row.Priority, jsonArg(row.Attributes), existingID, projectID), &outcome.Good); err != nil {
+			outcome.Err = fmt.Errorf("trouble executing db: %w", err)
+
+			return outcome
+		}
+
+		outcome.Updated = true
+		outcome.Err = writeAudit(ctx, tx, entity.ActionUpdate, projectID, existingID, before, outcome.Good)
+
+		return outcome
+	}
+
+	if existingID != 0 {
+		outcome.Err = entity.ErrGoodNameTaken
+
+		return outcome
+	}
+
+	priority := *maxPriority + 1
+	if row.Priority != nil {
+		priority = *row.Priority
+	}
+
+	var desc string
+	if row.Description != nil {
+		desc = *row.Description
+	}
+
+	stmt := `INSERT INTO goods(project_id, name, description, priority, removed, created_at, attributes)
+             VALUES($1, $2, $3, $4, false, $5, COALESCE($6::jsonb, '{}')) RETURNING ` + goodColumns + `;`
+
+	if err := scanGood(tx.QueryRowContext(ctx, stmt, projectID, row.Name, desc, priority, time.Now(), jsonArg(row.Attributes)), &outcome.Good); err != nil {
+		outcome.Err = fmt.Errorf("trouble executing db: %w", err)
+
+		return outcome
+	}
+
+	if priority > *maxPriority {
+		*maxPriority = priority
+	}
+
+	outcome.Err = writeAudit(ctx, tx, entity.ActionCreate, projectID, outcome.Good.ID, nil, outcome.Good)
+
+	return outcome
+}
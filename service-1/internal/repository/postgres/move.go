@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+// Move reassigns goods of a project to another one, keeping their relative
+// order. They are placed from priority on, or after the target's last good
+// when priority is nil, and the source project closes up behind them. Tags
+// are carried over by name; categories belong to the source and are dropped.
+// The goods shifted in either project are announced with the moved ones.
+func (g goodRepository) Move(ctx context.Context, projectID int, ids []int, targetID int, priority *int, check GoodCheck) (entity.GoodsMove, error) {
+	tx, err := beginTx(ctx, g.db, nil)
+	if err != nil {
+		return entity.GoodsMove{}, fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The target is locked so concurrent moves into it take turns at its end
+	err = tx.QueryRowContext(ctx, "SELECT id FROM projects WHERE id = $1 FOR UPDATE;", targetID).Scan(&targetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.GoodsMove{}, fmt.Errorf("project #%d %w", targetID, entity.ErrProjectNotFound)
+	}
+	if err != nil {
+		return entity.GoodsMove{}, fmt.Errorf("trouble checking project existence: %w", err)
+	}
+
+	before := make([]entity.Good, 0, len(ids))
+
+	for _, id := range ids {
+		good, err := getForUpdate(ctx, tx, id, projectID)
+		if err != nil {
+			return entity.GoodsMove{}, err
+		}
+
+		if err := check(ctx, good); err != nil {
+			return entity.GoodsMove{}, err
+		}
+
+		before = append(before, good)
+	}
+
+	sort.SliceStable(before, func(i, j int) bool {
+		if before[i].Priority != before[j].Priority {
+			return before[i].Priority < before[j].Priority
+		}
+
+		return before[i].ID < before[j].ID
+	})
+
+	priorities := make([]int, 0, len(before))
+	for _, good := range before {
+		priorities = append(priorities, good.Priority)
+	}
+
+	move := entity.GoodsMove{FromProjectID: projectID, ToProjectID: targetID}
+
+	stmt := `UPDATE goods g SET priority = g.priority - (SELECT COUNT(*) FROM unnest($2::int[]) AS p WHERE p < g.priority)
+             WHERE g.project_id = $1 AND NOT g.id = ANY($3) AND g.priority > $4
+             RETURNING g.id;`
+
+	move.Shifted, err = queryIDs(ctx, tx, stmt, projectID, pq.Array(priorities), pq.Array(ids), priorities[0])
+	if err != nil {
+		return entity.GoodsMove{}, fmt.Errorf("trouble closing the gap: %w", err)
+	}
+
+	var first int
+
+	if priority != nil {
+		first = *priority
+	} else {
+		stmt = `SELECT COALESCE(MAX(priority), 0) + 1 FROM goods WHERE project_id = $1;`
+
+		if err := tx.QueryRowContext(ctx, stmt, targetID).Scan(&first); err != nil {
+			return entity.GoodsMove{}, fmt.Errorf("get max priority error: %w", err)
+		}
+	}
+
+	// makeRoom may renumber the whole target, so its goods are compared
+	// before and after
+	targetBefore, err := projectPriorities(ctx, tx, targetID)
+	if err != nil {
+		return entity.GoodsMove{}, err
+	}
+
+	if first, err = makeRoom(ctx, tx, targetID, first, len(before)); err != nil {
+		return entity.GoodsMove{}, err
+	}
+
+	targetAfter, err := projectPriorities(ctx, tx, targetID)
+	if err != nil {
+		return entity.GoodsMove{}, err
+	}
+
+	for id, priority := range targetAfter {
+		if targetBefore[id] != priority {
+			move.Shifted = append(move.Shifted, id)
+		}
+	}
+
+	sort.Ints(move.Shifted)
+
+	move.Goods = make([]entity.Good, 0, len(before))
+
+	for i, old := range before {
+		stmt = `UPDATE goods SET project_id = $1, priority = $2, category_id = NULL WHERE id = $3;`
+
+		if _, err := tx.ExecContext(ctx, stmt, targetID, first+i, old.ID); err != nil {
+			return entity.GoodsMove{}, writeError(err)
+		}
+
+		if err := setTags(ctx, tx, targetID, old.ID, old.Tags); err != nil {
+			return entity.GoodsMove{}, err
+		}
+
+		good, err := getGood(ctx, tx, old.ID)
+		if err != nil {
+			return entity.GoodsMove{}, err
+		}
+
+		if err := writeAudit(ctx, tx, entity.ActionMove, targetID, good.ID, old, good); err != nil {
+			return entity.GoodsMove{}, err
+		}
+
+		move.Goods = append(move.Goods, good)
+	}
+
+	announced := append([]entity.Good(nil), move.Goods...)
+
+	for _, id := range move.Shifted {
+		good, err := getGood(ctx, tx, id)
+		if err != nil {
+			return entity.GoodsMove{}, err
+		}
+
+		announced = append(announced, good)
+	}
+
+	moved := event(ctx, entity.ActionMove, announced...)
+	moved.FromProjectID = projectID
+	moved.FromOrganizationID = before[0].OrganizationID
+	moved.ToProjectID = targetID
+	moved.Moved = ids
+
+	if err := g.commit(ctx, tx, moved); err != nil {
+		return entity.GoodsMove{}, err
+	}
+
+	return move, nil
+}
+
+// projectPriorities maps the ids of the project's goods to their priority.
+func projectPriorities(ctx context.Context, tx *sql.Tx, projectID int) (map[int]int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT id, priority FROM goods WHERE project_id = $1;", projectID)
+	if err != nil {
+		return nil, fmt.Errorf("trouble reading priorities: %w", err)
+	}
+	defer rows.Close()
+
+	priorities := make(map[int]int)
+
+	for rows.Next() {
+		var id, priority int
+		if err := rows.Scan(&id, &priority); err != nil {
+			return nil, fmt.Errorf("trouble with scanning row: %w", err)
+		}
+
+		priorities[id] = priority
+	}
+
+	return priorities, rows.Err()
+}
+
+func queryIDs(ctx context.Context, q querier, stmt string, args ...any) ([]int, error) {
+	rows, err := q.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, name string) (entity.Organization, error)
+	List(ctx context.Context) ([]entity.Organization, error)
+	Projects(ctx context.Context, organizationID int) ([]int, error)
+	AssignProject(ctx context.Context, projectID, organizationID int) error
+}
+
+type organizationRepository struct {
+	db *sql.DB
+}
+
+func NewOrganizationRepository(db *sql.DB) OrganizationRepository {
+	return organizationRepository{db}
+}
+
+func (o organizationRepository) Create(ctx context.Context, name string) (entity.Organization, error) {
+	organization := entity.Organization{Name: name}
+
+	stmt := `INSERT INTO organizations(name) VALUES($1) RETURNING id, created_at;`
+
+	err := scoped(ctx, o.db, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, stmt, name).Scan(&organization.ID, &organization.CreatedAt)
+	})
+	if err != nil {
+		return entity.Organization{}, fmt.Errorf("trouble executing db: %w", err)
+	}
+
+	return organization, nil
+}
+
+func (o organizationRepository) List(ctx context.Context) ([]entity.Organization, error) {
+	organizations := make([]entity.Organization, 0)
+
+	err := scoped(ctx, o.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT id, name, created_at FROM organizations ORDER BY id;")
+		if err != nil {
+			return fmt.Errorf("query error: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var organization entity.Organization
+
+			if err := rows.Scan(&organization.ID, &organization.Name, &organization.CreatedAt); err != nil {
+				return fmt.Errorf("trouble with scanning row: %w", err)
+			}
+
+			organizations = append(organizations, organization)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error during iteration: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return organizations, nil
+}
+
+// Projects returns the ids of the organization's projects.
+func (o organizationRepository) Projects(ctx context.Context, organizationID int) ([]int, error) {
+	var ids []int
+
+	err := scoped(ctx, o.db, func(tx *sql.Tx) error {
+		var err error
+
+		ids, err = queryIDs(ctx, tx, "SELECT id FROM projects WHERE organization_id = $1;", organizationID)
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+
+	return ids, nil
+}
+
+// AssignProject hands a project, with its goods, to another organization.
+func (o organizationRepository) AssignProject(ctx context.Context, projectID, organizationID int) error {
+	return scoped(ctx, o.db, func(tx *sql.Tx) error {
+		var exists bool
+
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM organizations WHERE id = $1)", organizationID).Scan(&exists); err != nil {
+			return fmt.Errorf("trouble checking organization existence: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("organization #%d %w", organizationID, entity.ErrOrganizationNotFound)
+		}
+
+		result, err := tx.ExecContext(ctx, "UPDATE projects SET organization_id = $1 WHERE id = $2;", organizationID, projectID)
+		if err != nil {
+			return fmt.Errorf("trouble executing db: %w", err)
+		}
+
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("project #%d %w", projectID, entity.ErrProjectNotFound)
+		}
+
+		return nil
+	})
+}
@@ -3,9 +3,13 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/skantay/hezzl/internal/controller/mq/nats/v"
 	"github.com/skantay/hezzl/internal/entity"
 )
@@ -13,31 +17,302 @@ import (
 type GoodRepository interface {
 	Create(ctx context.Context, good entity.Good) (entity.Good, error)
 	Delete(ctx context.Context, id, projectID int) (entity.Good, error)
-	UpdateDesc(ctx context.Context, name string, id, projectID int) (entity.Good, error)
-	UpdateName(ctx context.Context, desc string, id, projectID int) (entity.Good, error)
+	Restore(ctx context.Context, id, projectID int) (entity.Good, error)
+	Update(ctx context.Context, id, projectID int, input entity.GoodInput) (entity.Good, error)
+	Rollback(ctx context.Context, id, projectID int, input entity.GoodInput) (entity.Good, error)
 	UpdatePriority(ctx context.Context, priority, id, projectID int) ([]entity.Good, error)
+	Retag(ctx context.Context, projectID int, ids []int, add, remove []string) ([]entity.Good, error)
+	Recategorize(ctx context.Context, projectID int, ids []int, categoryID int) ([]entity.Good, error)
 	Get(ctx context.Context, id int) (entity.Good, error)
+	List(ctx context.Context, filter entity.ListFilter) ([]entity.Good, error)
 	GetMaxPriority(ctx context.Context, projectID int) (int, error)
-	CountRows(ctx context.Context) (int, error)
+	NameExists(ctx context.Context, projectID int, name string, excludeID int) (bool, error)
+	Import(ctx context.Context, projectID int, rows []entity.ImportRow, opts entity.ImportOptions, uniqueNames bool) ([]entity.ImportOutcome, error)
+	Export(ctx context.Context, projectID int, filter entity.ExportFilter, fn func(entity.Good) error) error
+	Crossings(ctx context.Context) ([]entity.Good, error)
+	// Move runs check on each good, locked, before moving it.
+	Move(ctx context.Context, projectID int, ids []int, targetID int, priority *int, check GoodCheck) (entity.GoodsMove, error)
 }
 
-type Collection struct {
-	Goods []entity.Good `json:"goods"`
-}
+// GoodCheck validates a good inside the transaction about to change it.
+type GoodCheck func(ctx context.Context, good entity.Good) error
 
 type goodRepository struct {
 	db *sql.DB
 	nc v.NC
 }
 
+// goodsNameIndex backs the goods.uniquenames setting, so that concurrent
+// writes cannot both pass the usecase's name check.
+const goodsNameIndex = "goods_project_name_unique"
+
+// UniqueNames adds the unique name index when names must be unique and drops
+// it otherwise. Migrations cannot read the setting, so it runs after them.
+func UniqueNames(ctx context.Context, db *sql.DB, enabled bool) error {
+	if !enabled {
+		if _, err := db.ExecContext(ctx, `DROP INDEX IF EXISTS `+goodsNameIndex+`;`); err != nil {
+			return fmt.Errorf("trouble dropping unique name index: %w", err)
+		}
+
+		return nil
+	}
+
+	stmt := `CREATE UNIQUE INDEX IF NOT EXISTS ` + goodsNameIndex + ` ON goods (project_id, lower(name)) WHERE NOT removed;`
+
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return fmt.Errorf("projects hold goods with duplicate names, rename them first: %w", err)
+		}
+
+		return fmt.Errorf("trouble creating unique name index: %w", err)
+	}
+
+	return nil
+}
+
+// BypassesRowSecurity reports whether the connected role skips row level
+// security, as superusers and BYPASSRLS roles do. Tenant isolation then
+// rests on the queries' own filters alone.
+func BypassesRowSecurity(ctx context.Context, db *sql.DB) (bool, error) {
+	var bypasses bool
+
+	stmt := `SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user;`
+
+	if err := db.QueryRowContext(ctx, stmt).Scan(&bypasses); err != nil {
+		return false, fmt.Errorf("trouble reading role attributes: %w", err)
+	}
+
+	return bypasses, nil
+}
+
+// writeError reports a write the unique name index rejected as
+// entity.ErrGoodNameTaken.
+func writeError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == goodsNameIndex {
+		return entity.ErrGoodNameTaken
+	}
+
+	return fmt.Errorf("trouble executing db: %w", err)
+}
+
 func New(db *sql.DB, nc v.NC) GoodRepository {
 	return goodRepository{db, nc}
 }
 
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// goodColumns selects a good with its tags, in the order scanGood expects.
+const goodColumns = `id, project_id, organization_id, name, description, priority, removed, created_at, category_id,
+                     COALESCE((SELECT array_agg(t.name ORDER BY t.name) FROM good_tags gt JOIN tags t ON t.id = gt.tag_id
+                               WHERE gt.good_id = goods.id), '{}'),
+                     attributes, visible_from, visible_until`
+
+// scanGood reads the goodColumns of a row into good, followed by any extra
+// columns the query selects.
+func scanGood(row scanner, good *entity.Good, extra ...any) error {
+	return row.Scan(append([]any{
+		&good.ID,
+		&good.ProjectID,
+		&good.OrganizationID,
+		&good.Name,
+		&good.Description,
+		&good.Priority,
+		&good.Removed,
+		&good.CreatedAt,
+		&good.CategoryID,
+		pq.Array(&good.Tags),
+		&good.Attributes,
+		&good.VisibleFrom,
+		&good.VisibleUntil,
+	}, extra...)...)
+}
+
+// jsonArg passes a JSON document as text, as lib/pq would send raw bytes as
+// bytea. A nil document becomes NULL.
+func jsonArg(doc json.RawMessage) *string {
+	if doc == nil {
+		return nil
+	}
+
+	text := string(doc)
+
+	return &text
+}
+
+// allOrganizations is the app.organization_id of unconfined callers, such
+// as admins and the background workers. Row level security lets nothing
+// through while the setting is missing, so every statement must run in a
+// transaction from beginTx.
+const allOrganizations = "*"
+
+// organizationSetting is the app.organization_id of the caller.
+func organizationSetting(ctx context.Context) string {
+	if id := entity.OrganizationFromContext(ctx); id != 0 {
+		return strconv.Itoa(id)
+	}
+
+	return allOrganizations
+}
+
+// beginTx starts a transaction that row level security confines to the
+// caller's organization, or opens to all of them for unconfined callers.
+func beginTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions) (*sql.Tx, error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	setting := organizationSetting(ctx)
+
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('app.organization_id', $1, true);", setting); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("trouble confining to organization %q: %w", setting, err)
+	}
+
+	return tx, nil
+}
+
+// scoped runs fn in a transaction confined to the caller's organization, as
+// beginTx does, so that row level security covers the statements made
+// outside the write transactions too. It commits when fn succeeds.
+func scoped(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := beginTx(ctx, db, nil)
+	if err != nil {
+		return fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("trouble with committing a transaction: %w", err)
+	}
+
+	return nil
+}
+
+// getGood reads a good inside the transaction, after its tags were changed.
+func getGood(ctx context.Context, tx *sql.Tx, id int) (entity.Good, error) {
+	var good entity.Good
+
+	if err := scanGood(tx.QueryRowContext(ctx, `SELECT `+goodColumns+` FROM goods WHERE id = $1;`, id), &good); err != nil {
+		return entity.Good{}, fmt.Errorf("query error: %w", err)
+	}
+
+	return good, nil
+}
+
+// getForUpdate locks the good for the rest of the transaction.
+func getForUpdate(ctx context.Context, tx *sql.Tx, id, projectID int) (entity.Good, error) {
+	stmt := `SELECT ` + goodColumns + ` FROM goods WHERE id = $1 AND project_id = $2 FOR UPDATE;`
+
+	var good entity.Good
+
+	if err := scanGood(tx.QueryRowContext(ctx, stmt, id, projectID), &good); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Good{}, fmt.Errorf("good with id #%d %w", id, entity.ErrGoodNotFound)
+		}
+
+		return entity.Good{}, fmt.Errorf("query error: %w", err)
+	}
+
+	return good, nil
+}
+
+// event describes goods changed by action on behalf of the caller.
+func event(ctx context.Context, action string, goods ...entity.Good) entity.Collection {
+	return entity.Collection{Action: action, Goods: goods, Actor: entity.ActorFromContext(ctx)}
+}
+
+// commit queues the webhook deliveries of the events in tx, commits it and
+// only then publishes the events. A delivery is thus stored exactly when the
+// change is, while the NATS feed stays best effort.
+func (g goodRepository) commit(ctx context.Context, tx *sql.Tx, events ...entity.Collection) error {
+	now := time.Now().UTC()
+
+	for _, event := range events {
+		if err := enqueueWebhooks(ctx, tx, event, now); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("trouble with committing a transaction: %w", err)
+	}
+
+	for _, event := range events {
+		g.send(ctx, event)
+	}
+
+	return nil
+}
+
+// send publishes the goods of each organization in the event on that
+// organization's subject, so no tenant receives another's goods.
+func (g goodRepository) send(ctx context.Context, event entity.Collection) {
+	for _, split := range splitByOrganization(event) {
+		g.nc.SendJSON(ctx, split.organizationID, split.event)
+	}
+}
+
+type organizationEvent struct {
+	organizationID int
+	event          entity.Collection
+}
+
+// splitByOrganization divides the goods of the event by organization, in
+// the order the organizations first appear. The organization goods were
+// moved out of gets the moved goods too.
+func splitByOrganization(event entity.Collection) []organizationEvent {
+	moved := make(map[int]bool, len(event.Moved))
+	for _, id := range event.Moved {
+		moved[id] = true
+	}
+
+	var organizations []int
+
+	byOrganization := make(map[int][]entity.Good)
+	add := func(organizationID int, good entity.Good) {
+		if _, ok := byOrganization[organizationID]; !ok {
+			organizations = append(organizations, organizationID)
+		}
+
+		byOrganization[organizationID] = append(byOrganization[organizationID], good)
+	}
+
+	for _, good := range event.Goods {
+		add(good.OrganizationID, good)
+
+		if moved[good.ID] && event.FromOrganizationID != 0 && event.FromOrganizationID != good.OrganizationID {
+			add(event.FromOrganizationID, good)
+		}
+	}
+
+	split := make([]organizationEvent, 0, len(organizations))
+
+	for _, organizationID := range organizations {
+		payload := event
+		payload.Goods = byOrganization[organizationID]
+
+		split = append(split, organizationEvent{organizationID, payload})
+	}
+
+	return split
+}
+
 func (g goodRepository) GetMaxPriority(ctx context.Context, projectID int) (int, error) {
 	var maxPriority *int
 
-	if err := g.db.QueryRowContext(ctx, "SELECT MAX(priority) FROM goods WHERE project_id = $1", projectID).Scan(&maxPriority); err != nil && err != sql.ErrNoRows {
+	err := scoped(ctx, g.db, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, "SELECT MAX(priority) FROM goods WHERE project_id = $1", projectID).Scan(&maxPriority)
+	})
+	if err != nil && err != sql.ErrNoRows {
 		return 0, fmt.Errorf("get max priority error: %w", err)
 	}
 	if maxPriority == nil {
@@ -47,8 +322,28 @@ func (g goodRepository) GetMaxPriority(ctx context.Context, projectID int) (int,
 }
 
 func (g goodRepository) Create(ctx context.Context, good entity.Good) (entity.Good, error) {
+	tx, err := beginTx(ctx, g.db, nil)
+	if err != nil {
+		return entity.Good{}, fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	newGood, err := createGood(ctx, tx, good)
+	if err != nil {
+		return entity.Good{}, err
+	}
+
+	if err := g.commit(ctx, tx, event(ctx, entity.ActionCreate, newGood)); err != nil {
+		return entity.Good{}, err
+	}
+
+	return newGood, nil
+}
+
+// createGood inserts and audits a good inside the transaction.
+func createGood(ctx context.Context, tx *sql.Tx, good entity.Good) (entity.Good, error) {
 	var exists bool
-	err := g.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", good.ProjectID).Scan(&exists)
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", good.ProjectID).Scan(&exists)
 	if err != nil {
 		return entity.Good{}, fmt.Errorf("trouble checking project existence: %w", err)
 	}
@@ -56,193 +351,234 @@ func (g goodRepository) Create(ctx context.Context, good entity.Good) (entity.Go
 		return entity.Good{}, entity.ErrProjectNotFound
 	}
 
-	stmt := `INSERT INTO goods(project_id, name, description, priority, removed, created_at)
-             VALUES($1, $2, $3, $4, $5, $6) RETURNING *;`
+	if good.CategoryID != nil {
+		if err := checkCategory(ctx, tx, *good.CategoryID, good.ProjectID); err != nil {
+			return entity.Good{}, err
+		}
+	}
+
+	stmt := `INSERT INTO goods(project_id, name, description, priority, removed, created_at, category_id, attributes, visible_from, visible_until)
+             VALUES($1, $2, $3, $4, $5, $6, $7, COALESCE($8::jsonb, '{}'), $9, $10) RETURNING id;`
 
-	var newGood entity.Good
-	err = g.db.QueryRowContext(ctx, stmt,
+	var id int
+	err = tx.QueryRowContext(ctx, stmt,
 		good.ProjectID,
 		good.Name,
 		good.Description,
 		good.Priority,
 		good.Removed,
 		good.CreatedAt,
-	).Scan(
-		&newGood.ID,
-		&newGood.ProjectID,
-		&newGood.Name,
-		&newGood.Description,
-		&newGood.Priority,
-		&newGood.Removed,
-		&newGood.CreatedAt,
-	)
+		good.CategoryID,
+		jsonArg(good.Attributes),
+		good.VisibleFrom,
+		good.VisibleUntil,
+	).Scan(&id)
+	if err != nil {
+		return entity.Good{}, writeError(err)
+	}
+
+	if err := setTags(ctx, tx, good.ProjectID, id, good.Tags); err != nil {
+		return entity.Good{}, err
+	}
+
+	newGood, err := getGood(ctx, tx, id)
 	if err != nil {
-		return newGood, fmt.Errorf("trouble executing db: %w", err)
+		return entity.Good{}, err
+	}
+
+	if err := writeAudit(ctx, tx, entity.ActionCreate, newGood.ProjectID, newGood.ID, nil, newGood); err != nil {
+		return entity.Good{}, err
 	}
 
 	return newGood, nil
 }
 
 func (g goodRepository) Delete(ctx context.Context, id, projectID int) (entity.Good, error) {
-	tx, err := g.db.BeginTx(ctx, nil)
+	return g.setRemoved(ctx, id, projectID, true, entity.ActionRemove)
+}
+
+func (g goodRepository) Restore(ctx context.Context, id, projectID int) (entity.Good, error) {
+	return g.setRemoved(ctx, id, projectID, false, entity.ActionRestore)
+}
+
+func (g goodRepository) setRemoved(ctx context.Context, id, projectID int, removed bool, action string) (entity.Good, error) {
+	tx, err := beginTx(ctx, g.db, nil)
 	if err != nil {
 		return entity.Good{}, fmt.Errorf("trouble with starting a transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	stmt := `UPDATE goods SET   
-                 removed = $1
-             WHERE id = $2 AND project_id = $3 RETURNING *;`
-
-	var updatedGood entity.Good
-
-	if err := g.db.QueryRowContext(
-		ctx,
-		stmt,
-		true,
-		id,
-		projectID,
-	).Scan(
-		&updatedGood.ID,
-		&updatedGood.ProjectID,
-		&updatedGood.Name,
-		&updatedGood.Description,
-		&updatedGood.Priority,
-		&updatedGood.Removed,
-		&updatedGood.CreatedAt,
-	); err != nil {
-		return entity.Good{}, entity.ErrGoodNotFound
-	}
-
-	err = tx.Commit()
+	updatedGood, err := setGoodRemoved(ctx, tx, id, projectID, removed, action)
 	if err != nil {
-		return entity.Good{}, fmt.Errorf("trouble with committing a transaction: %w", err)
+		return entity.Good{}, err
 	}
 
-	var payload Collection
-
-	payload.Goods = []entity.Good{updatedGood}
-	if len(payload.Goods) != 0 {
-		g.nc.SendJSON(ctx, payload)
+	if err := g.commit(ctx, tx, event(ctx, action, updatedGood)); err != nil {
+		return entity.Good{}, err
 	}
 
 	return updatedGood, nil
 }
 
-func (g goodRepository) UpdateName(ctx context.Context, name string, id, projectID int) (entity.Good, error) {
-	tx, err := g.db.BeginTx(ctx, nil)
+func setGoodRemoved(ctx context.Context, tx *sql.Tx, id, projectID int, removed bool, action string) (entity.Good, error) {
+	before, err := getForUpdate(ctx, tx, id, projectID)
 	if err != nil {
-		return entity.Good{}, fmt.Errorf("trouble with starting a transaction: %w", err)
+		return entity.Good{}, err
 	}
-	defer tx.Rollback()
 
-	stmt := `UPDATE goods SET   
-                 name = $1  
-             WHERE id = $2 AND project_id = $3 RETURNING *;`
+	stmt := `UPDATE goods SET
+                 removed = $1
+             WHERE id = $2 AND project_id = $3 RETURNING ` + goodColumns + `;`
 
 	var updatedGood entity.Good
 
-	if err := g.db.QueryRowContext(
-		ctx,
-		stmt,
-		name,
-		id,
-		projectID,
-	).Scan(
-		&updatedGood.ID,
-		&updatedGood.ProjectID,
-		&updatedGood.Name,
-		&updatedGood.Description,
-		&updatedGood.Priority,
-		&updatedGood.Removed,
-		&updatedGood.CreatedAt,
-	); err != nil {
-		return entity.Good{}, entity.ErrGoodNotFound
+	if err := scanGood(tx.QueryRowContext(ctx, stmt, removed, id, projectID), &updatedGood); err != nil {
+		return entity.Good{}, writeError(err)
 	}
 
-	err = tx.Commit()
+	if err := writeAudit(ctx, tx, action, projectID, id, before, updatedGood); err != nil {
+		return entity.Good{}, err
+	}
+
+	return updatedGood, nil
+}
+
+// Update renames the good and applies the optional parts of input in a
+// single change.
+func (g goodRepository) Update(ctx context.Context, id, projectID int, input entity.GoodInput) (entity.Good, error) {
+	return g.update(ctx, id, projectID, input, entity.ActionUpdate)
+}
+
+// Rollback applies input taken from an earlier revision as a change of its
+// own.
+func (g goodRepository) Rollback(ctx context.Context, id, projectID int, input entity.GoodInput) (entity.Good, error) {
+	return g.update(ctx, id, projectID, input, entity.ActionRollback)
+}
+
+func (g goodRepository) update(ctx context.Context, id, projectID int, input entity.GoodInput, action string) (entity.Good, error) {
+	tx, err := beginTx(ctx, g.db, nil)
 	if err != nil {
-		return entity.Good{}, fmt.Errorf("trouble with committing a transaction: %w", err)
+		return entity.Good{}, fmt.Errorf("trouble with starting a transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	var payload Collection
+	updatedGood, err := updateGood(ctx, tx, id, projectID, input, action)
+	if err != nil {
+		return entity.Good{}, err
+	}
 
-	payload.Goods = []entity.Good{updatedGood}
-	if len(payload.Goods) != 0 {
-		g.nc.SendJSON(ctx, payload)
+	if err := g.commit(ctx, tx, event(ctx, action, updatedGood)); err != nil {
+		return entity.Good{}, err
 	}
 
 	return updatedGood, nil
 }
 
-func (g goodRepository) UpdateDesc(ctx context.Context, desc string, id, projectID int) (entity.Good, error) {
-	tx, err := g.db.BeginTx(ctx, nil)
+func updateGood(ctx context.Context, tx *sql.Tx, id, projectID int, input entity.GoodInput, action string) (entity.Good, error) {
+	before, err := getForUpdate(ctx, tx, id, projectID)
 	if err != nil {
-		return entity.Good{}, fmt.Errorf("trouble with starting a transaction: %w", err)
+		return entity.Good{}, err
 	}
-	defer tx.Rollback()
 
-	stmt := `UPDATE goods SET
-				description = $1   
-             WHERE id = $2 AND project_id = $3 RETURNING *;`
+	categoryID := before.CategoryID
+	if input.CategoryID != nil {
+		categoryID = nil
 
-	var updatedGood entity.Good
+		if *input.CategoryID != 0 {
+			if err := checkCategory(ctx, tx, *input.CategoryID, projectID); err != nil {
+				return entity.Good{}, err
+			}
 
-	if err := g.db.QueryRowContext(
-		ctx,
-		stmt,
-		desc,
+			categoryID = input.CategoryID
+		}
+	}
+
+	visibility := before.Visibility
+	if input.Visibility != nil {
+		visibility = *input.Visibility
+	}
+
+	stmt := `UPDATE goods SET
+                 name = $1,
+                 description = COALESCE($2, description),
+                 category_id = $3,
+                 attributes = COALESCE($4::jsonb, attributes),
+                 visible_from = $5,
+                 visible_until = $6
+             WHERE id = $7 AND project_id = $8;`
+
+	if _, err := tx.ExecContext(ctx, stmt,
+		input.Name,
+		input.Description,
+		categoryID,
+		jsonArg(input.Attributes),
+		visibility.VisibleFrom,
+		visibility.VisibleUntil,
 		id,
 		projectID,
-	).Scan(
-		&updatedGood.ID,
-		&updatedGood.ProjectID,
-		&updatedGood.Name,
-		&updatedGood.Description,
-		&updatedGood.Priority,
-		&updatedGood.Removed,
-		&updatedGood.CreatedAt,
 	); err != nil {
-		return entity.Good{}, entity.ErrGoodNotFound
+		return entity.Good{}, writeError(err)
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		return entity.Good{}, fmt.Errorf("trouble with committing a transaction: %w", err)
+	if input.Tags != nil {
+		if err := setTags(ctx, tx, projectID, id, *input.Tags); err != nil {
+			return entity.Good{}, err
+		}
 	}
 
-	var payload Collection
+	updatedGood, err := getGood(ctx, tx, id)
+	if err != nil {
+		return entity.Good{}, err
+	}
 
-	payload.Goods = []entity.Good{updatedGood}
-	if len(payload.Goods) != 0 {
-		g.nc.SendJSON(ctx, payload)
+	if err := writeAudit(ctx, tx, action, projectID, id, before, updatedGood); err != nil {
+		return entity.Good{}, err
 	}
 
 	return updatedGood, nil
 }
 
 func (g goodRepository) UpdatePriority(ctx context.Context, priority, id, projectID int) ([]entity.Good, error) {
-	tx, err := g.db.BeginTx(ctx, nil)
+	tx, err := beginTx(ctx, g.db, nil)
 	if err != nil {
 		return nil, fmt.Errorf("trouble with starting a transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	result, err := updatePriority(ctx, tx, priority, id, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.commit(ctx, tx, event(ctx, entity.ActionReprioritize, result...)); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// updatePriority moves the good and returns every good of the project in
+// the new order.
+func updatePriority(ctx context.Context, tx *sql.Tx, priority, id, projectID int) ([]entity.Good, error) {
+	before, err := getForUpdate(ctx, tx, id, projectID)
+	if err != nil {
+		return nil, err
+	}
+
 	result := make([]entity.Good, 0)
 
-	stmt := `UPDATE goods SET priority = priority + 1 WHERE priority >= $1 AND project_id = $2;`
-	_, err = tx.ExecContext(ctx, stmt, priority, projectID)
+	priority, err = makeRoom(ctx, tx, projectID, priority, 1)
 	if err != nil {
-		return nil, fmt.Errorf("trouble with updating priorities: %w", err)
+		return nil, err
 	}
 
-	stmt = `UPDATE goods SET priority = $1 WHERE id = $2 AND project_id = $3;`
+	stmt := `UPDATE goods SET priority = $1 WHERE id = $2 AND project_id = $3;`
 	_, err = tx.ExecContext(ctx, stmt, priority, id, projectID)
 	if err != nil {
 		return nil, entity.ErrGoodNotFound
 	}
 
-	stmt = `SELECT * FROM goods WHERE project_id = $1 ORDER BY priority;`
+	stmt = `SELECT ` + goodColumns + ` FROM goods WHERE project_id = $1 ORDER BY priority;`
 
 	rows, err := tx.QueryContext(ctx, stmt, projectID)
 	if err != nil {
@@ -250,20 +586,18 @@ func (g goodRepository) UpdatePriority(ctx context.Context, priority, id, projec
 	}
 	defer rows.Close()
 
+	var after entity.Good
+
 	for rows.Next() {
 		var good entity.Good
-		if err := rows.Scan(
-			&good.ID,
-			&good.ProjectID,
-			&good.Name,
-			&good.Description,
-			&good.Priority,
-			&good.Removed,
-			&good.CreatedAt,
-		); err != nil {
+		if err := scanGood(rows, &good); err != nil {
 			return nil, fmt.Errorf("trouble with scanning row: %w", err)
 		}
 
+		if good.ID == id {
+			after = good
+		}
+
 		result = append(result, good)
 	}
 
@@ -271,36 +605,69 @@ func (g goodRepository) UpdatePriority(ctx context.Context, priority, id, projec
 		return nil, fmt.Errorf("error during iteration: %w", err)
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		return nil, fmt.Errorf("trouble with committing transaction: %w", err)
+	if err := writeAudit(ctx, tx, entity.ActionReprioritize, projectID, id, before, after); err != nil {
+		return nil, err
 	}
 
-	var payload Collection
+	return result, nil
+}
+
+// makeRoom shifts the project's goods from priority on by n places and
+// returns where the n places now start. When the shift would push a good
+// past entity.PriorityMax, the project is first renumbered from 1 in its
+// current order, which moves that position.
+func makeRoom(ctx context.Context, tx *sql.Tx, projectID, priority, n int) (int, error) {
+	var highest int
 
-	for _, v := range result {
-		payload.Goods = append(payload.Goods, v)
+	stmt := `SELECT COALESCE(MAX(priority), 0) FROM goods WHERE project_id = $1 AND priority >= $2;`
+	if err := tx.QueryRowContext(ctx, stmt, projectID, priority).Scan(&highest); err != nil {
+		return 0, fmt.Errorf("trouble reading priorities: %w", err)
 	}
-	if len(payload.Goods) != 0 {
-		g.nc.SendJSON(ctx, payload)
+
+	// The n places themselves must fit too when nothing follows them
+	if highest < priority-1 {
+		highest = priority - 1
 	}
 
-	return result, nil
+	if highest+n > entity.PriorityMax {
+		var before, total int
+
+		stmt = `SELECT COUNT(*) FILTER (WHERE priority < $2), COUNT(*) FROM goods WHERE project_id = $1;`
+		if err := tx.QueryRowContext(ctx, stmt, projectID, priority).Scan(&before, &total); err != nil {
+			return 0, fmt.Errorf("trouble counting goods: %w", err)
+		}
+
+		if total+n > entity.PriorityMax {
+			return 0, entity.NewValidationError(entity.FieldError{Field: "priority", Rule: "max", Param: fmt.Sprint(entity.PriorityMax)})
+		}
+
+		stmt = `UPDATE goods g SET priority = r.rank
+                FROM (SELECT id, row_number() OVER (ORDER BY priority, id) AS rank FROM goods WHERE project_id = $1) r
+                WHERE g.id = r.id AND g.priority <> r.rank;`
+		if _, err := tx.ExecContext(ctx, stmt, projectID); err != nil {
+			return 0, fmt.Errorf("trouble renumbering priorities: %w", err)
+		}
+
+		priority = before + 1
+	}
+
+	stmt = `UPDATE goods SET priority = priority + $3 WHERE project_id = $1 AND priority >= $2;`
+	if _, err := tx.ExecContext(ctx, stmt, projectID, priority, n); err != nil {
+		return 0, fmt.Errorf("trouble with updating priorities: %w", err)
+	}
+
+	return priority, nil
 }
 
+// Get reads a good of the caller's organization, or of any when the caller
+// is not confined to one.
 func (g goodRepository) Get(ctx context.Context, id int) (entity.Good, error) {
-	stmt := `SELECT * FROM goods WHERE id = $1`
+	stmt := `SELECT ` + goodColumns + ` FROM goods WHERE id = $1 AND ($2 = 0 OR organization_id = $2)`
 
 	var good entity.Good
-	err := g.db.QueryRowContext(ctx, stmt, id).Scan(
-		&good.ID,
-		&good.ProjectID,
-		&good.Name,
-		&good.Description,
-		&good.Priority,
-		&good.Removed,
-		&good.CreatedAt,
-	)
+	err := scoped(ctx, g.db, func(tx *sql.Tx) error {
+		return scanGood(tx.QueryRowContext(ctx, stmt, id, entity.OrganizationFromContext(ctx)), &good)
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return entity.Good{}, fmt.Errorf("good with id #%d %w", id, entity.ErrGoodNotFound)
@@ -312,14 +679,19 @@ func (g goodRepository) Get(ctx context.Context, id int) (entity.Good, error) {
 	return good, nil
 }
 
-func (g goodRepository) CountRows(ctx context.Context) (int, error) {
-	stmt := `SELECT COUNT(id) FROM goods;`
+func (g goodRepository) NameExists(ctx context.Context, projectID int, name string, excludeID int) (bool, error) {
+	stmt := `SELECT EXISTS(
+                 SELECT 1 FROM goods
+                 WHERE project_id = $1 AND lower(name) = lower($2) AND id <> $3 AND NOT removed);`
 
-	var count int
+	var exists bool
 
-	if err := g.db.QueryRowContext(ctx, stmt).Scan(&count); err != nil {
-		return 0, fmt.Errorf("query error: %w", err)
+	err := scoped(ctx, g.db, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, stmt, projectID, name, excludeID).Scan(&exists)
+	})
+	if err != nil {
+		return false, fmt.Errorf("query error: %w", err)
 	}
 
-	return count, nil
+	return exists, nil
 }
@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+type sent struct {
+	organizationID int
+	goods          []int
+}
+
+type fakeNC struct {
+	sent *[]sent
+}
+
+func (f fakeNC) SendJSON(ctx context.Context, organizationID int, data any) error {
+	var ids []int
+	for _, good := range data.(entity.Collection).Goods {
+		ids = append(ids, good.ID)
+	}
+
+	*f.sent = append(*f.sent, sent{organizationID, ids})
+
+	return nil
+}
+
+func TestSend(t *testing.T) {
+	good := func(id, organizationID int) entity.Good {
+		return entity.Good{ID: id, OrganizationID: organizationID}
+	}
+
+	tests := []struct {
+		name  string
+		event entity.Collection
+		want  []sent
+	}{
+		{
+			name:  "one organization",
+			event: entity.Collection{Action: entity.ActionUpdate, Goods: []entity.Good{good(1, 1), good(2, 1)}},
+			want:  []sent{{1, []int{1, 2}}},
+		},
+		{
+			name:  "organizations kept apart",
+			event: entity.Collection{Action: entity.ActionUpdate, Goods: []entity.Good{good(1, 2), good(2, 1), good(3, 2)}},
+			want:  []sent{{2, []int{1, 3}}, {1, []int{2}}},
+		},
+		{
+			name: "move within an organization",
+			event: entity.Collection{
+				Action:             entity.ActionMove,
+				Goods:              []entity.Good{good(1, 1), good(2, 1)},
+				FromOrganizationID: 1,
+				Moved:              []int{1},
+			},
+			want: []sent{{1, []int{1, 2}}},
+		},
+		{
+			name: "move to another organization",
+			event: entity.Collection{
+				Action:             entity.ActionMove,
+				Goods:              []entity.Good{good(1, 2), good(2, 2), good(3, 1)},
+				FromOrganizationID: 1,
+				Moved:              []int{1},
+			},
+			want: []sent{{2, []int{1, 2}}, {1, []int{1, 3}}},
+		},
+		{
+			name: "move out of an organization with nothing shifted",
+			event: entity.Collection{
+				Action:             entity.ActionMove,
+				Goods:              []entity.Good{good(1, 2), good(2, 2)},
+				FromOrganizationID: 1,
+				Moved:              []int{1, 2},
+			},
+			want: []sent{{2, []int{1, 2}}, {1, []int{1, 2}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []sent
+
+			goodRepository{nc: fakeNC{sent: &got}}.send(context.Background(), tt.event)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("send() published %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	created := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// Everything belongs to project 1 of organization 1
+	db := openFakeDB(
+		fakeTable{query: "COUNT(r.revision)", organizationID: 1, columns: []string{"count"}, values: [][]driver.Value{{int64(1)}}},
+		fakeTable{
+			query:          "FROM good_revisions r",
+			organizationID: 1,
+			columns:        []string{"good_id", "revision", "project_id", "action", "actor", "good", "created_at"},
+			values:         [][]driver.Value{{int64(1), int64(1), int64(1), "create", "admin", []byte(`{"id":1}`), created}},
+		},
+		fakeTable{
+			query:          "FROM tags t",
+			organizationID: 1,
+			columns:        []string{"id", "project_id", "name", "count"},
+			values:         [][]driver.Value{{int64(1), int64(1), "fruit", int64(1)}},
+		},
+		fakeTable{
+			query:          "FROM categories",
+			organizationID: 1,
+			columns:        []string{"id", "project_id", "parent_id", "name", "created_at"},
+			values:         [][]driver.Value{{int64(1), int64(1), nil, "food", created}},
+		},
+		fakeTable{
+			query:          "FROM changesets",
+			organizationID: 1,
+			columns:        []string{"id", "project_id", "status", "author", "created_at", "closed_at"},
+			values:         [][]driver.Value{{int64(1), int64(1), "draft", "admin", created, nil}},
+		},
+		fakeTable{
+			query:          "FROM changeset_changes",
+			organizationID: 1,
+			columns:        []string{"id", "action", "good_id", "input", "priority", "author", "created_at"},
+		},
+	)
+	defer db.Close()
+
+	revisions := NewRevisionRepository(db)
+	taxonomy := NewTaxonomyRepository(db)
+	changesets := NewChangesetRepository(db, nil)
+
+	// Each read returns how many rows it saw; not found counts as none
+	reads := []struct {
+		name string
+		read func(ctx context.Context) (int, error)
+	}{
+		{name: "revisions", read: func(ctx context.Context) (int, error) {
+			list, _, err := revisions.List(ctx, 1, 1, 10, 0)
+			return len(list), err
+		}},
+		{name: "revision", read: func(ctx context.Context) (int, error) {
+			_, err := revisions.Get(ctx, 1, 1, 1)
+			return 1, err
+		}},
+		{name: "tags", read: func(ctx context.Context) (int, error) {
+			tags, err := taxonomy.ListTags(ctx, 1)
+			return len(tags), err
+		}},
+		{name: "categories", read: func(ctx context.Context) (int, error) {
+			categories, err := taxonomy.ListCategories(ctx, 1)
+			return len(categories), err
+		}},
+		{name: "changesets", read: func(ctx context.Context) (int, error) {
+			list, err := changesets.List(ctx, 1)
+			return len(list), err
+		}},
+		{name: "changeset", read: func(ctx context.Context) (int, error) {
+			_, err := changesets.Get(ctx, 1, 1)
+			return 1, err
+		}},
+	}
+
+	callers := []struct {
+		name     string
+		ctx      context.Context
+		wantSeen bool
+	}{
+		{name: "same organization", ctx: entity.WithPrincipal(context.Background(), entity.Principal{OrganizationID: 1}), wantSeen: true},
+		{name: "unconfined", ctx: context.Background(), wantSeen: true},
+		{name: "other organization", ctx: entity.WithPrincipal(context.Background(), entity.Principal{OrganizationID: 2})},
+	}
+
+	notFound := []error{entity.ErrGoodNotFound, entity.ErrRevisionNotFound, entity.ErrChangesetNotFound}
+
+	for _, caller := range callers {
+		for _, r := range reads {
+			t.Run(caller.name+"/"+r.name, func(t *testing.T) {
+				n, err := r.read(caller.ctx)
+				for _, target := range notFound {
+					if errors.Is(err, target) {
+						n, err = 0, nil
+					}
+				}
+
+				if err != nil {
+					t.Fatalf("read error = %v", err)
+				}
+
+				if seen := n != 0; seen != caller.wantSeen {
+					t.Errorf("read %d rows, want seen = %v", n, caller.wantSeen)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/skantay/hezzl/internal/controller/mq/nats/v"
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+type ProjectRepository interface {
+	AttributesSchema(ctx context.Context, projectID int) (json.RawMessage, error)
+	AttributesSchemaVersion(ctx context.Context, projectID int) (int64, error)
+	SetAttributesSchema(ctx context.Context, projectID int, schema json.RawMessage) error
+	SearchLanguage(ctx context.Context, projectID int) (string, error)
+	SetSearchLanguage(ctx context.Context, projectID int, language string) error
+	SearchLanguages(ctx context.Context) ([]string, error)
+	Clone(ctx context.Context, projectID int, name string) (entity.ProjectClone, error)
+}
+
+// projectRepository shares the connection and the event publisher of the
+// goods, which cloning writes.
+type projectRepository struct {
+	goodRepository
+}
+
+func NewProjectRepository(db *sql.DB, nc v.NC) ProjectRepository {
+	return projectRepository{goodRepository{db, nc}}
+}
+
+// AttributesSchema returns the project's attributes schema, or nil when the
+// project has none.
+func (p projectRepository) AttributesSchema(ctx context.Context, projectID int) (json.RawMessage, error) {
+	var schema json.RawMessage
+
+	err := scoped(ctx, p.db, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, "SELECT attributes_schema FROM projects WHERE id = $1;", projectID).Scan(&schema)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("project #%d %w", projectID, entity.ErrProjectNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+
+	return schema, nil
+}
+
+// AttributesSchemaVersion returns the version of the project's attributes
+// schema, which changes whenever the schema does.
+func (p projectRepository) AttributesSchemaVersion(ctx context.Context, projectID int) (int64, error) {
+	var version int64
+
+	err := scoped(ctx, p.db, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, "SELECT attributes_schema_version FROM projects WHERE id = $1;", projectID).Scan(&version)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("project #%d %w", projectID, entity.ErrProjectNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("query error: %w", err)
+	}
+
+	return version, nil
+}
+
+// SetAttributesSchema replaces the project's attributes schema; nil removes
+// it. Goods already stored are not checked again.
+func (p projectRepository) SetAttributesSchema(ctx context.Context, projectID int, schema json.RawMessage) error {
+	stmt := `UPDATE projects SET attributes_schema = $1::jsonb, attributes_schema_version = attributes_schema_version + 1
+             WHERE id = $2;`
+
+	return scoped(ctx, p.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, stmt, jsonArg(schema), projectID)
+		if err != nil {
+			return fmt.Errorf("trouble updating attributes schema: %w", err)
+		}
+
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("project #%d %w", projectID, entity.ErrProjectNotFound)
+		}
+
+		return nil
+	})
+}
+
+func (p projectRepository) SearchLanguage(ctx context.Context, projectID int) (string, error) {
+	var language string
+
+	err := scoped(ctx, p.db, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, "SELECT search_language::text FROM projects WHERE id = $1;", projectID).Scan(&language)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("project #%d %w", projectID, entity.ErrProjectNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("query error: %w", err)
+	}
+
+	return language, nil
+}
+
+// SetSearchLanguage changes the text search configuration of the project;
+// a trigger re-stems its goods in the same statement.
+func (p projectRepository) SetSearchLanguage(ctx context.Context, projectID int, language string) error {
+	return scoped(ctx, p.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE projects SET search_language = $1::regconfig WHERE id = $2;", language, projectID)
+		if err != nil {
+			return fmt.Errorf("trouble updating search language: %w", err)
+		}
+
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("project #%d %w", projectID, entity.ErrProjectNotFound)
+		}
+
+		return nil
+	})
+}
+
+// SearchLanguages lists the text search configurations the database has.
+func (p projectRepository) SearchLanguages(ctx context.Context) ([]string, error) {
+	rows, err := p.db.QueryContext(ctx, "SELECT cfgname FROM pg_ts_config ORDER BY cfgname;")
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	languages := make([]string, 0)
+
+	for rows.Next() {
+		var language string
+
+		if err := rows.Scan(&language); err != nil {
+			return nil, fmt.Errorf("trouble with scanning row: %w", err)
+		}
+
+		languages = append(languages, language)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	return languages, nil
+}
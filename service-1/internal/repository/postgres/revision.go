@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+type RevisionRepository interface {
+	List(ctx context.Context, goodID, projectID, limit, offset int) ([]entity.Revision, int, error)
+	Get(ctx context.Context, goodID, projectID, revision int) (entity.Revision, error)
+}
+
+type revisionRepository struct {
+	db *sql.DB
+}
+
+func NewRevisionRepository(db *sql.DB) RevisionRepository {
+	return revisionRepository{db}
+}
+
+const revisionColumns = `r.good_id, r.revision, r.project_id, r.action, r.actor, r.good, r.created_at`
+
+func scanRevision(row scanner, revision *entity.Revision) error {
+	var good []byte
+
+	if err := row.Scan(
+		&revision.GoodID,
+		&revision.Revision,
+		&revision.ProjectID,
+		&revision.Action,
+		&revision.Actor,
+		&good,
+		&revision.CreatedAt,
+	); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(good, &revision.Good)
+}
+
+// writeRevision stores the state of a good after a change as its next
+// revision. The good is locked or new, so numbers cannot collide.
+func writeRevision(ctx context.Context, tx *sql.Tx, action string, good entity.Good) error {
+	data, err := json.Marshal(good)
+	if err != nil {
+		return fmt.Errorf("trouble encoding revision: %w", err)
+	}
+
+	stmt := `INSERT INTO good_revisions(good_id, revision, project_id, action, actor, good)
+             SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5
+             FROM good_revisions WHERE good_id = $1;`
+
+	if _, err := tx.ExecContext(ctx, stmt, good.ID, good.ProjectID, action, entity.ActorFromContext(ctx), string(data)); err != nil {
+		return fmt.Errorf("trouble writing revision: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the revisions of a good, newest first, with their total.
+func (r revisionRepository) List(ctx context.Context, goodID, projectID, limit, offset int) ([]entity.Revision, int, error) {
+	var total int
+
+	revisions := make([]entity.Revision, 0)
+
+	err := scoped(ctx, r.db, func(tx *sql.Tx) error {
+		stmt := `SELECT COUNT(r.revision) FROM goods g
+                 LEFT JOIN good_revisions r ON r.good_id = g.id
+                 WHERE g.id = $1 AND g.project_id = $2
+                 GROUP BY g.id;`
+
+		err := tx.QueryRowContext(ctx, stmt, goodID, projectID).Scan(&total)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("good #%d %w", goodID, entity.ErrGoodNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("query error: %w", err)
+		}
+
+		stmt = `SELECT ` + revisionColumns + ` FROM good_revisions r
+                WHERE r.good_id = $1
+                ORDER BY r.revision DESC LIMIT $2 OFFSET $3;`
+
+		rows, err := tx.QueryContext(ctx, stmt, goodID, limit, offset)
+		if err != nil {
+			return fmt.Errorf("query error: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var revision entity.Revision
+
+			if err := scanRevision(rows, &revision); err != nil {
+				return fmt.Errorf("trouble with scanning row: %w", err)
+			}
+
+			revisions = append(revisions, revision)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error during iteration: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return revisions, total, nil
+}
+
+// Get returns a revision of a good that is now in the project. The revision
+// keeps the project the good was in at the time.
+func (r revisionRepository) Get(ctx context.Context, goodID, projectID, revision int) (entity.Revision, error) {
+	stmt := `SELECT ` + revisionColumns + ` FROM good_revisions r
+             JOIN goods g ON g.id = r.good_id
+             WHERE r.good_id = $1 AND g.project_id = $2 AND r.revision = $3;`
+
+	var result entity.Revision
+
+	err := scoped(ctx, r.db, func(tx *sql.Tx) error {
+		return scanRevision(tx.QueryRowContext(ctx, stmt, goodID, projectID, revision), &result)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Revision{}, fmt.Errorf("revision %d of good #%d %w", revision, goodID, entity.ErrRevisionNotFound)
+	}
+	if err != nil {
+		return entity.Revision{}, fmt.Errorf("query error: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+// Crossings announces the visibility boundaries crossed since the previous
+// call and returns the goods concerned. The schedule row is locked for the
+// duration, so with several replicas each boundary is announced once and a
+// replica that finds it busy returns nothing.
+func (g goodRepository) Crossings(ctx context.Context) ([]entity.Good, error) {
+	tx, err := beginTx(ctx, g.db, nil)
+	if err != nil {
+		return nil, fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var checkedAt, now time.Time
+
+	err = tx.QueryRowContext(ctx, `SELECT checked_at, NOW() FROM visibility_schedule FOR UPDATE SKIP LOCKED;`).Scan(&checkedAt, &now)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+
+	stmt := `SELECT ` + goodColumns + `
+             FROM goods
+             WHERE NOT removed
+               AND ((visible_from > $1 AND visible_from <= $2) OR (visible_until > $1 AND visible_until <= $2))
+             ORDER BY id;`
+
+	rows, err := tx.QueryContext(ctx, stmt, checkedAt, now)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var crossed, published, expired []entity.Good
+
+	for rows.Next() {
+		var good entity.Good
+
+		if err := scanGood(rows, &good); err != nil {
+			return nil, fmt.Errorf("trouble with scanning row: %w", err)
+		}
+
+		opened, closed := good.Crossed(checkedAt, now)
+
+		if opened {
+			published = append(published, good)
+		}
+
+		if closed {
+			expired = append(expired, good)
+		}
+
+		crossed = append(crossed, good)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE visibility_schedule SET checked_at = $1;`, now); err != nil {
+		return nil, fmt.Errorf("trouble executing db: %w", err)
+	}
+
+	if err := g.commit(ctx, tx,
+		event(ctx, entity.ActionPublish, published...),
+		event(ctx, entity.ActionExpire, expired...),
+	); err != nil {
+		return nil, err
+	}
+
+	return crossed, nil
+}
@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+// Highlight delimiters put around matches by ts_headline. They cannot occur
+// in names, so the text can be escaped before they are turned into tags.
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+type SearchRepository interface {
+	// Search returns a page of the matching goods, the number of matches and
+	// the text search configuration the query was parsed with. Names at least
+	// similarity alike the query match even without a full-text match.
+	Search(ctx context.Context, query entity.SearchQuery, similarity float64) ([]entity.SearchHit, int, string, error)
+}
+
+type searchRepository struct {
+	db *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) SearchRepository {
+	return searchRepository{db}
+}
+
+func (s searchRepository) Search(ctx context.Context, query entity.SearchQuery, similarity float64) ([]entity.SearchHit, int, string, error) {
+	tx, err := beginTx(ctx, s.db, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var language string
+
+	err = tx.QueryRowContext(ctx, "SELECT search_language::text FROM projects WHERE id = $1;", query.ProjectID).Scan(&language)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, "", entity.ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("query error: %w", err)
+	}
+
+	// <% uses the threshold, which lets the trigram index serve it
+	threshold := strconv.FormatFloat(similarity, 'f', -1, 64)
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('pg_trgm.word_similarity_threshold', $1, true);", threshold); err != nil {
+		return nil, 0, "", fmt.Errorf("trouble setting similarity threshold: %w", err)
+	}
+
+	matches := `FROM goods, (SELECT $3::regconfig AS lang,
+                                 websearch_to_tsquery($3::regconfig, $2) || websearch_to_tsquery('simple', $2) AS query) q
+             WHERE project_id = $1 AND NOT removed
+               AND (search @@ q.query OR $2 <% name)`
+
+	// The window counts every match before LIMIT and OFFSET apply
+	stmt := `SELECT ` + goodColumns + `,
+                    ts_rank(search, q.query) + word_similarity($2, name) AS rank,
+                    ts_headline(q.lang, name, q.query, $5),
+                    ts_headline(q.lang, COALESCE(description, ''), q.query, $6),
+                    count(*) OVER () AS total
+             ` + matches + `
+             ORDER BY rank DESC, id
+             LIMIT $4 OFFSET $7;`
+
+	selectors := `StartSel="` + HighlightStart + `", StopSel="` + HighlightStop + `"`
+
+	rows, err := tx.QueryContext(ctx, stmt,
+		query.ProjectID,
+		query.Query,
+		language,
+		query.Limit,
+		selectors+", HighlightAll=true",
+		selectors+", MaxFragments=2, MaxWords=20, MinWords=5",
+		query.Offset,
+	)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		hits  = make([]entity.SearchHit, 0)
+		total int
+	)
+
+	for rows.Next() {
+		var hit entity.SearchHit
+
+		if err := scanGood(rows, &hit.Good, &hit.Rank, &hit.Highlight.Name, &hit.Highlight.Description, &total); err != nil {
+			return nil, 0, "", fmt.Errorf("trouble with scanning row: %w", err)
+		}
+
+		hits = append(hits, hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, "", fmt.Errorf("error during iteration: %w", err)
+	}
+
+	// A page past the end has no rows to carry the count
+	if len(hits) == 0 && query.Offset > 0 {
+		stmt = `SELECT COUNT(*) ` + matches + `;`
+
+		if err := tx.QueryRowContext(ctx, stmt, query.ProjectID, query.Query, language).Scan(&total); err != nil {
+			return nil, 0, "", fmt.Errorf("trouble counting matches: %w", err)
+		}
+	}
+
+	return hits, total, language, nil
+}
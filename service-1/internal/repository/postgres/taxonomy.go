@@ -0,0 +1,416 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+// uniqueViolation is the Postgres error code of a unique index conflict.
+const uniqueViolation = "23505"
+
+type TaxonomyRepository interface {
+	CreateCategory(ctx context.Context, category entity.Category) (entity.Category, error)
+	ListCategories(ctx context.Context, projectID int) ([]entity.Category, error)
+	DeleteCategory(ctx context.Context, id, projectID int) error
+	ListTags(ctx context.Context, projectID int) ([]entity.Tag, error)
+}
+
+type taxonomyRepository struct {
+	db *sql.DB
+}
+
+func NewTaxonomyRepository(db *sql.DB) TaxonomyRepository {
+	return taxonomyRepository{db}
+}
+
+func (t taxonomyRepository) CreateCategory(ctx context.Context, category entity.Category) (entity.Category, error) {
+	tx, err := beginTx(ctx, t.db, nil)
+	if err != nil {
+		return entity.Category{}, fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", category.ProjectID).Scan(&exists); err != nil {
+		return entity.Category{}, fmt.Errorf("trouble checking project existence: %w", err)
+	}
+	if !exists {
+		return entity.Category{}, entity.ErrProjectNotFound
+	}
+
+	// A parent from another project would leak the tree across projects
+	if category.ParentID != nil {
+		if err := checkCategory(ctx, tx, *category.ParentID, category.ProjectID); err != nil {
+			return entity.Category{}, err
+		}
+	}
+
+	stmt := `INSERT INTO categories(project_id, parent_id, name, created_at)
+             VALUES($1, $2, $3, $4) RETURNING id;`
+
+	if err := tx.QueryRowContext(ctx, stmt,
+		category.ProjectID,
+		category.ParentID,
+		category.Name,
+		category.CreatedAt,
+	).Scan(&category.ID); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return entity.Category{}, entity.ErrCategoryNameTaken
+		}
+
+		return entity.Category{}, fmt.Errorf("trouble executing db: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.Category{}, fmt.Errorf("trouble with committing a transaction: %w", err)
+	}
+
+	return category, nil
+}
+
+func (t taxonomyRepository) ListCategories(ctx context.Context, projectID int) ([]entity.Category, error) {
+	stmt := `SELECT id, project_id, parent_id, name, created_at FROM categories
+             WHERE project_id = $1 ORDER BY id;`
+
+	categories := make([]entity.Category, 0)
+
+	err := scoped(ctx, t.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, stmt, projectID)
+		if err != nil {
+			return fmt.Errorf("query error: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var category entity.Category
+
+			if err := rows.Scan(&category.ID, &category.ProjectID, &category.ParentID, &category.Name, &category.CreatedAt); err != nil {
+				return fmt.Errorf("trouble with scanning row: %w", err)
+			}
+
+			categories = append(categories, category)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error during iteration: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// DeleteCategory removes the category with its subtree. Goods in it are
+// left without a category.
+func (t taxonomyRepository) DeleteCategory(ctx context.Context, id, projectID int) error {
+	return scoped(ctx, t.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM categories WHERE id = $1 AND project_id = $2;", id, projectID)
+		if err != nil {
+			return fmt.Errorf("trouble deleting category: %w", err)
+		}
+
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("category #%d %w", id, entity.ErrCategoryNotFound)
+		}
+
+		return nil
+	})
+}
+
+func (t taxonomyRepository) ListTags(ctx context.Context, projectID int) ([]entity.Tag, error) {
+	stmt := `SELECT t.id, t.project_id, t.name, count(gt.good_id) FROM tags t
+             LEFT JOIN good_tags gt ON gt.tag_id = t.id
+             WHERE t.project_id = $1
+             GROUP BY t.id ORDER BY lower(t.name);`
+
+	tags := make([]entity.Tag, 0)
+
+	err := scoped(ctx, t.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, stmt, projectID)
+		if err != nil {
+			return fmt.Errorf("query error: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var tag entity.Tag
+
+			if err := rows.Scan(&tag.ID, &tag.ProjectID, &tag.Name, &tag.Goods); err != nil {
+				return fmt.Errorf("trouble with scanning row: %w", err)
+			}
+
+			tags = append(tags, tag)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error during iteration: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// checkCategory makes sure the category belongs to the project.
+func checkCategory(ctx context.Context, tx *sql.Tx, id, projectID int) error {
+	var exists bool
+
+	stmt := `SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1 AND project_id = $2);`
+
+	if err := tx.QueryRowContext(ctx, stmt, id, projectID).Scan(&exists); err != nil {
+		return fmt.Errorf("trouble checking category existence: %w", err)
+	}
+
+	if !exists {
+		return fmt.Errorf("category #%d %w", id, entity.ErrCategoryNotFound)
+	}
+
+	return nil
+}
+
+// ensureTags creates the project's missing tags. Existing tags keep their
+// spelling.
+func ensureTags(ctx context.Context, tx *sql.Tx, projectID int, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	stmt := `INSERT INTO tags(project_id, name)
+             SELECT $1, unnest($2::text[])
+             ON CONFLICT (project_id, lower(name)) DO NOTHING;`
+
+	if _, err := tx.ExecContext(ctx, stmt, projectID, pq.Array(tags)); err != nil {
+		return fmt.Errorf("trouble creating tags: %w", err)
+	}
+
+	return nil
+}
+
+// setTags replaces the tags of a good.
+func setTags(ctx context.Context, tx *sql.Tx, projectID, goodID int, tags []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM good_tags WHERE good_id = $1;", goodID); err != nil {
+		return fmt.Errorf("trouble clearing tags: %w", err)
+	}
+
+	return addTags(ctx, tx, projectID, []int{goodID}, tags)
+}
+
+// addTags attaches tags to goods, creating the tags as needed.
+func addTags(ctx context.Context, tx *sql.Tx, projectID int, goodIDs []int, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if err := ensureTags(ctx, tx, projectID, tags); err != nil {
+		return err
+	}
+
+	stmt := `INSERT INTO good_tags(good_id, tag_id)
+             SELECT g, t.id FROM unnest($2::int[]) g, tags t
+             WHERE t.project_id = $1 AND lower(t.name) = ANY($3)
+             ON CONFLICT DO NOTHING;`
+
+	if _, err := tx.ExecContext(ctx, stmt, projectID, pq.Array(goodIDs), pq.Array(lowerAll(tags))); err != nil {
+		return fmt.Errorf("trouble attaching tags: %w", err)
+	}
+
+	return nil
+}
+
+// Retag adds and removes tags on several goods of a project in one change.
+// Tags in both lists end up added.
+func (g goodRepository) Retag(ctx context.Context, projectID int, ids []int, add, remove []string) ([]entity.Good, error) {
+	return g.bulkUpdate(ctx, projectID, ids, func(tx *sql.Tx) error {
+		if len(remove) != 0 {
+			stmt := `DELETE FROM good_tags gt USING tags t
+                     WHERE gt.tag_id = t.id AND gt.good_id = ANY($1) AND lower(t.name) = ANY($2);`
+
+			if _, err := tx.ExecContext(ctx, stmt, pq.Array(ids), pq.Array(lowerAll(remove))); err != nil {
+				return fmt.Errorf("trouble detaching tags: %w", err)
+			}
+		}
+
+		return addTags(ctx, tx, projectID, ids, add)
+	})
+}
+
+// Recategorize moves several goods of a project to a category. A zero
+// categoryID removes their category.
+func (g goodRepository) Recategorize(ctx context.Context, projectID int, ids []int, categoryID int) ([]entity.Good, error) {
+	return g.bulkUpdate(ctx, projectID, ids, func(tx *sql.Tx) error {
+		var category *int
+
+		if categoryID != 0 {
+			if err := checkCategory(ctx, tx, categoryID, projectID); err != nil {
+				return err
+			}
+
+			category = &categoryID
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE goods SET category_id = $1 WHERE id = ANY($2);", category, pq.Array(ids)); err != nil {
+			return fmt.Errorf("trouble updating categories: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// bulkUpdate locks the goods, applies change and records an update for each
+// good in one transaction. Every id must belong to the project.
+func (g goodRepository) bulkUpdate(ctx context.Context, projectID int, ids []int, change func(tx *sql.Tx) error) ([]entity.Good, error) {
+	tx, err := beginTx(ctx, g.db, nil)
+	if err != nil {
+		return nil, fmt.Errorf("trouble with starting a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before := make([]entity.Good, 0, len(ids))
+
+	for _, id := range ids {
+		good, err := getForUpdate(ctx, tx, id, projectID)
+		if err != nil {
+			return nil, err
+		}
+
+		before = append(before, good)
+	}
+
+	if err := change(tx); err != nil {
+		return nil, err
+	}
+
+	updated := make([]entity.Good, 0, len(before))
+
+	for _, old := range before {
+		good, err := getGood(ctx, tx, old.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeAudit(ctx, tx, entity.ActionUpdate, projectID, good.ID, old, good); err != nil {
+			return nil, err
+		}
+
+		updated = append(updated, good)
+	}
+
+	if err := g.commit(ctx, tx, event(ctx, entity.ActionUpdate, updated...)); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// listSubtree and listConditions filter goods by a ListFilter, taking
+// listArgs as $1 to $9. Offset is the first good id.
+const (
+	listSubtree = `WITH RECURSIVE subtree AS (
+                 SELECT id FROM categories WHERE id = $4
+                 UNION ALL
+                 SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+             )`
+	listConditions = `id >= $1
+               AND ($2::int = 0 OR project_id = $2)
+               AND (cardinality($3::text[]) = 0 OR (
+                   SELECT count(*) FROM good_tags gt JOIN tags t ON t.id = gt.tag_id
+                   WHERE gt.good_id = goods.id AND lower(t.name) = ANY($3)) >= $5)
+               AND ($4::int = 0 OR category_id IN (SELECT id FROM subtree))
+               AND ($6::jsonb IS NULL OR attributes @> $6::jsonb)
+               AND ($7::timestamptz IS NULL OR ((visible_from IS NULL OR visible_from <= $7)
+                                                AND (visible_until IS NULL OR visible_until > $7)))
+               AND ($8::int = 0 OR organization_id = $8)
+               AND ($9::bigint[] IS NULL OR project_id = ANY($9))`
+)
+
+func listArgs(filter entity.ListFilter) []any {
+	minTags := 1
+	if filter.MatchAll {
+		minTags = len(filter.Tags)
+	}
+
+	var projectIDs pq.Int64Array
+	if filter.ProjectIDs != nil {
+		projectIDs = make(pq.Int64Array, len(filter.ProjectIDs))
+		for i, id := range filter.ProjectIDs {
+			projectIDs[i] = int64(id)
+		}
+	}
+
+	return []any{
+		filter.Offset,
+		filter.ProjectID,
+		pq.Array(lowerAll(filter.Tags)),
+		filter.CategoryID,
+		minTags,
+		jsonArg(filter.Attributes),
+		filter.VisibleAt,
+		filter.OrganizationID,
+		projectIDs,
+	}
+}
+
+// List returns goods by id from filter.Offset on, narrowed to an
+// organization, a project, tags, a category subtree and contained attributes.
+func (g goodRepository) List(ctx context.Context, filter entity.ListFilter) ([]entity.Good, error) {
+	stmt := listSubtree + `
+             SELECT ` + goodColumns + ` FROM goods
+             WHERE ` + listConditions + `
+             ORDER BY id
+             LIMIT $10;`
+
+	goods := make([]entity.Good, 0)
+
+	err := scoped(ctx, g.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, stmt, append(listArgs(filter), filter.Limit)...)
+		if err != nil {
+			return fmt.Errorf("query error: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var good entity.Good
+
+			if err := scanGood(rows, &good); err != nil {
+				return fmt.Errorf("trouble with scanning row: %w", err)
+			}
+
+			goods = append(goods, good)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error during iteration: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return goods, nil
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+
+	return lowered
+}
@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+func TestListArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter entity.ListFilter
+		// wantTags and wantMinTags are $3 and $5: how many of the tags a
+		// good needs
+		wantTags    []string
+		wantMinTags int
+		// wantProjects is $9, NULL for every project
+		wantProjects pq.Int64Array
+	}{
+		{name: "no tags", wantTags: []string{}, wantMinTags: 1},
+		{
+			name:        "any tag",
+			filter:      entity.ListFilter{Tags: []string{"Red", "sweet"}},
+			wantTags:    []string{"red", "sweet"},
+			wantMinTags: 1,
+		},
+		{
+			name:        "every tag",
+			filter:      entity.ListFilter{Tags: []string{"Red", "sweet", "ripe"}, MatchAll: true},
+			wantTags:    []string{"red", "sweet", "ripe"},
+			wantMinTags: 3,
+		},
+		{
+			name:         "visible projects",
+			filter:       entity.ListFilter{ProjectIDs: []int{1, 3}},
+			wantTags:     []string{},
+			wantMinTags:  1,
+			wantProjects: pq.Int64Array{1, 3},
+		},
+		{
+			name:         "no visible project",
+			filter:       entity.ListFilter{ProjectIDs: []int{}},
+			wantTags:     []string{},
+			wantMinTags:  1,
+			wantProjects: pq.Int64Array{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := listArgs(tt.filter)
+			if len(args) != 9 {
+				t.Fatalf("listArgs() = %d arguments, want $1 to $9", len(args))
+			}
+
+			tags, _ := args[2].(driver.Valuer).Value()
+			if want, _ := pq.Array(tt.wantTags).Value(); tags != want {
+				t.Errorf("tags = %v, want %v", tags, want)
+			}
+
+			if args[4] != tt.wantMinTags {
+				t.Errorf("tags needed = %v, want %d", args[4], tt.wantMinTags)
+			}
+
+			projects := args[8].(pq.Int64Array)
+			if !reflect.DeepEqual(projects, tt.wantProjects) {
+				t.Errorf("projects = %#v, want %#v", projects, tt.wantProjects)
+			}
+
+			// NULL and an empty array differ: the first allows every project
+			if value, _ := projects.Value(); (value == nil) != (tt.wantProjects == nil) {
+				t.Errorf("projects encode as %v", value)
+			}
+		})
+	}
+}
+
+func TestListQuery(t *testing.T) {
+	db, committed := openRecordingDB(fakeTable{query: "FROM goods", organizationID: 1, columns: []string{"id"}})
+	defer db.Close()
+
+	filter := entity.ListFilter{Offset: 11, CategoryID: 4, ProjectIDs: []int{2}, Limit: 10}
+
+	if _, err := New(db, nil).List(context.Background(), filter); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	if len(*committed) != 1 {
+		t.Fatalf("ran %d statements, want one", len(*committed))
+	}
+
+	statement := (*committed)[0]
+
+	// The category's subtree is walked from $4 and restricts the goods
+	for _, part := range []string{
+		"WITH RECURSIVE subtree AS",
+		"SELECT id FROM categories WHERE id = $4",
+		"category_id IN (SELECT id FROM subtree)",
+		"project_id = ANY($9)",
+		"LIMIT $10",
+	} {
+		if !strings.Contains(statement.query, part) {
+			t.Errorf("query lacks %q", part)
+		}
+	}
+
+	if got := statement.args[0]; got != int64(11) {
+		t.Errorf("first id = %v, want 11", got)
+	}
+
+	if got := statement.args[3]; got != int64(4) {
+		t.Errorf("category = %v, want 4", got)
+	}
+
+	if got := statement.args[8]; got != "{2}" {
+		t.Errorf("projects = %v, want {2}", got)
+	}
+
+	if got := statement.args[9]; got != int64(10) {
+		t.Errorf("limit = %v, want 10", got)
+	}
+}
+
+func TestCheckCategory(t *testing.T) {
+	tests := []struct {
+		name    string
+		exists  bool
+		wantErr error
+	}{
+		{name: "category of the project", exists: true},
+		{name: "category of another project", wantErr: entity.ErrCategoryNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := openRecordingDB(fakeTable{
+				query:          "FROM categories WHERE id = $1 AND project_id = $2",
+				organizationID: 1,
+				columns:        []string{"exists"},
+				values:         [][]driver.Value{{tt.exists}},
+			})
+			defer db.Close()
+
+			ctx := entity.WithPrincipal(context.Background(), entity.Principal{OrganizationID: 1})
+
+			tx, err := beginTx(ctx, db, nil)
+			if err != nil {
+				t.Fatalf("beginTx() error = %v", err)
+			}
+			defer tx.Rollback()
+
+			if err := checkCategory(ctx, tx, 5, 1); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkCategory() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, hook entity.Webhook) (entity.Webhook, error)
+	List(ctx context.Context, projectID int) ([]entity.Webhook, error)
+	Delete(ctx context.Context, id, projectID int) error
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]entity.DueDelivery, error)
+	Record(ctx context.Context, delivery entity.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, projectID, webhookID, limit, offset int) ([]entity.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id int64, projectID int) (entity.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return webhookRepository{db}
+}
+
+const deliveryColumns = `d.id, d.webhook_id, d.action, d.payload, d.status, d.attempts,
+                         d.response_code, d.last_error, d.next_attempt_at, d.created_at, d.delivered_at`
+
+func (w webhookRepository) Create(ctx context.Context, hook entity.Webhook) (entity.Webhook, error) {
+	err := scoped(ctx, w.db, func(tx *sql.Tx) error {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1)", hook.ProjectID).Scan(&exists); err != nil {
+			return fmt.Errorf("trouble checking project existence: %w", err)
+		}
+		if !exists {
+			return entity.ErrProjectNotFound
+		}
+
+		stmt := `INSERT INTO webhooks(project_id, url, secret, events, created_at)
+                 VALUES($1, $2, $3, $4, $5) RETURNING id;`
+
+		if err := tx.QueryRowContext(ctx, stmt,
+			hook.ProjectID,
+			hook.URL,
+			hook.Secret,
+			pq.Array(hook.Events),
+			hook.CreatedAt,
+		).Scan(&hook.ID); err != nil {
+			return fmt.Errorf("trouble executing db: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return entity.Webhook{}, err
+	}
+
+	return hook, nil
+}
+
+func (w webhookRepository) List(ctx context.Context, projectID int) ([]entity.Webhook, error) {
+	stmt := `SELECT id, project_id, url, events, created_at FROM webhooks
+             WHERE project_id = $1 ORDER BY id;`
+
+	hooks := make([]entity.Webhook, 0)
+
+	err := scoped(ctx, w.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, stmt, projectID)
+		if err != nil {
+			return fmt.Errorf("query error: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var hook entity.Webhook
+
+			if err := rows.Scan(&hook.ID, &hook.ProjectID, &hook.URL, pq.Array(&hook.Events), &hook.CreatedAt); err != nil {
+				return fmt.Errorf("trouble with scanning row: %w", err)
+			}
+
+			hooks = append(hooks, hook)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error during iteration: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hooks, nil
+}
+
+func (w webhookRepository) Delete(ctx context.Context, id, projectID int) error {
+	return scoped(ctx, w.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1 AND project_id = $2;", id, projectID)
+		if err != nil {
+			return fmt.Errorf("trouble deleting webhook: %w", err)
+		}
+
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("webhook #%d %w", id, entity.ErrWebhookNotFound)
+		}
+
+		return nil
+	})
+}
+
+// enqueueWebhooks queues the event for every webhook of the projects it
+// touches that subscribes to its action. It runs in the transaction making
+// the change, so the deliveries are stored if and only if the change is.
+func enqueueWebhooks(ctx context.Context, tx *sql.Tx, event entity.Collection, occurredAt time.Time) error {
+	stmt := `INSERT INTO webhook_deliveries(webhook_id, action, payload)
+             SELECT id, $2, $3 FROM webhooks
+             WHERE project_id = $1 AND (cardinality(events) = 0 OR $2 = ANY(events));`
+
+	for _, payload := range entity.NewWebhookPayloads(event, occurredAt) {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("trouble encoding webhook payload: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, stmt, payload.ProjectID, payload.Event, data); err != nil {
+			return fmt.Errorf("trouble queueing webhooks of project #%d: %w", payload.ProjectID, err)
+		}
+	}
+
+	return nil
+}
+
+// ClaimDue takes up to limit pending deliveries whose attempt is due and
+// hides them from other replicas for lease. SKIP LOCKED lets replicas claim
+// disjoint batches concurrently.
+func (w webhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]entity.DueDelivery, error) {
+	stmt := `WITH due AS (
+                 SELECT id FROM webhook_deliveries
+                 WHERE status = 'pending' AND next_attempt_at <= now()
+                 ORDER BY next_attempt_at
+                 LIMIT $1
+                 FOR UPDATE SKIP LOCKED
+             ), claimed AS (
+                 UPDATE webhook_deliveries d SET next_attempt_at = now() + $2 * interval '1 millisecond'
+                 FROM due WHERE d.id = due.id
+                 RETURNING d.*
+             )
+             SELECT ` + deliveryColumns + `, w.id, w.project_id, w.url, w.secret, w.events, w.created_at
+             FROM claimed d JOIN webhooks w ON w.id = d.webhook_id;`
+
+	var due []entity.DueDelivery
+
+	err := scoped(ctx, w.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, stmt, limit, lease.Milliseconds())
+		if err != nil {
+			return fmt.Errorf("trouble claiming deliveries: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				d    entity.DueDelivery
+				hook = &d.Webhook
+			)
+
+			if err := rows.Scan(append(deliveryFields(&d.Delivery),
+				&hook.ID,
+				&hook.ProjectID,
+				&hook.URL,
+				&hook.Secret,
+				pq.Array(&hook.Events),
+				&hook.CreatedAt,
+			)...); err != nil {
+				return fmt.Errorf("trouble with scanning row: %w", err)
+			}
+
+			due = append(due, d)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error during iteration: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return due, nil
+}
+
+// Record stores the outcome of a delivery attempt.
+func (w webhookRepository) Record(ctx context.Context, d entity.WebhookDelivery) error {
+	stmt := `UPDATE webhook_deliveries SET
+                 status = $1,
+                 attempts = $2,
+                 response_code = $3,
+                 last_error = $4,
+                 next_attempt_at = $5,
+                 delivered_at = $6
+             WHERE id = $7;`
+
+	return scoped(ctx, w.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, stmt,
+			d.Status,
+			d.Attempts,
+			d.ResponseCode,
+			d.LastError,
+			d.NextAttemptAt,
+			d.DeliveredAt,
+			d.ID,
+		); err != nil {
+			return fmt.Errorf("trouble recording delivery: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ListDeliveries returns the project's deliveries, newest first. A zero
+// webhookID spans every webhook of the project.
+func (w webhookRepository) ListDeliveries(ctx context.Context, projectID, webhookID, limit, offset int) ([]entity.WebhookDelivery, error) {
+	stmt := `SELECT ` + deliveryColumns + `
+             FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+             WHERE w.project_id = $1 AND ($2 = 0 OR d.webhook_id = $2)
+             ORDER BY d.id DESC LIMIT $3 OFFSET $4;`
+
+	deliveries := make([]entity.WebhookDelivery, 0)
+
+	err := scoped(ctx, w.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, stmt, projectID, webhookID, limit, offset)
+		if err != nil {
+			return fmt.Errorf("query error: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var d entity.WebhookDelivery
+
+			if err := rows.Scan(deliveryFields(&d)...); err != nil {
+				return fmt.Errorf("trouble with scanning row: %w", err)
+			}
+
+			deliveries = append(deliveries, d)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error during iteration: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver queues the delivery again with a fresh retry budget.
+func (w webhookRepository) Redeliver(ctx context.Context, id int64, projectID int) (entity.WebhookDelivery, error) {
+	stmt := `UPDATE webhook_deliveries d SET
+                 status = 'pending',
+                 attempts = 0,
+                 next_attempt_at = now()
+             FROM webhooks w
+             WHERE d.id = $1 AND w.id = d.webhook_id AND w.project_id = $2
+             RETURNING ` + deliveryColumns + `;`
+
+	var d entity.WebhookDelivery
+
+	err := scoped(ctx, w.db, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, stmt, id, projectID).Scan(deliveryFields(&d)...)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.WebhookDelivery{}, fmt.Errorf("delivery #%d %w", id, entity.ErrDeliveryNotFound)
+	}
+	if err != nil {
+		return entity.WebhookDelivery{}, fmt.Errorf("query error: %w", err)
+	}
+
+	return d, nil
+}
+
+// deliveryFields lists the scan targets matching deliveryColumns.
+func deliveryFields(d *entity.WebhookDelivery) []any {
+	return []any{
+		&d.ID,
+		&d.WebhookID,
+		&d.Action,
+		// Scanned as []byte so the driver's buffer is copied
+		(*[]byte)(&d.Payload),
+		&d.Status,
+		&d.Attempts,
+		&d.ResponseCode,
+		&d.LastError,
+		&d.NextAttemptAt,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	}
+}
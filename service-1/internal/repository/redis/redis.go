@@ -2,17 +2,52 @@ package cache
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/go-redis/redis"
 	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/pkg/metrics"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/skantay/hezzl/internal/repository/redis")
+
+// startSpan is used because the v6 client does not accept a context.
+func startSpan(ctx context.Context, operation string) trace.Span {
+	_, span := tracer.Start(ctx, "redis "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemRedis,
+			semconv.DBOperation(operation),
+		))
+
+	return span
+}
+
+// GoodCacheRepository caches goods and list pages per project. Entries are
+// keyed by the project's generation, so bumping it drops them all at once;
+// the orphaned entries run out with their TTL. They are kept apart per
+// organization of the caller, see entity.OrganizationFromContext, so that a
+// confined caller never reads what an unconfined one cached.
 type GoodCacheRepository interface {
-	Create(ctx context.Context, good entity.Good, key string, duration time.Duration) error
-	Delete(ctx context.Context, key string) error
-	Get(ctx context.Context, key string) (entity.Good, error)
+	// Generation returns the project's current generation, zero before the
+	// first bump
+	Generation(ctx context.Context, projectID int) (int64, error)
+	// Bump moves each project to a new generation
+	Bump(ctx context.Context, projectIDs ...int) error
+	// GetGood and GetPage report a miss as entity.ErrGoodNotFound
+	GetGood(ctx context.Context, projectID int, generation int64, id int) (entity.Good, error)
+	SetGood(ctx context.Context, good entity.Good, generation int64, ttl time.Duration) error
+	GetPage(ctx context.Context, projectID int, generation int64, query string) ([]entity.Good, error)
+	SetPage(ctx context.Context, projectID int, generation int64, query string, goods []entity.Good, ttl time.Duration) error
 }
 
 type goodRepository struct {
@@ -23,38 +58,118 @@ func New(db *redis.Client) GoodCacheRepository {
 	return goodRepository{db}
 }
 
-func (g goodRepository) Create(ctx context.Context, good entity.Good, key string, duration time.Duration) error {
-	err := g.db.Set(key, good, duration)
-	if err.Err() != nil {
-		return err.Err()
+func generationKey(projectID int) string {
+	return fmt.Sprintf("project:%d:generation", projectID)
+}
+
+// goodKey and pageKey take zero for unconfined callers.
+func goodKey(organizationID, projectID int, generation int64, id int) string {
+	return fmt.Sprintf("org:%d:project:%d:gen:%d:good:%d", organizationID, projectID, generation, id)
+}
+
+// pageKey hashes the query, which may be long and hold any characters.
+func pageKey(organizationID, projectID int, generation int64, query string) string {
+	sum := sha256.Sum256([]byte(query))
+
+	return fmt.Sprintf("org:%d:project:%d:gen:%d:page:%s", organizationID, projectID, generation, hex.EncodeToString(sum[:16]))
+}
+
+func (g goodRepository) Generation(ctx context.Context, projectID int) (int64, error) {
+	span := startSpan(ctx, "GET")
+	defer span.End()
+
+	generation, err := g.db.Get(generationKey(projectID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	} else if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+
+	return generation, nil
+}
+
+func (g goodRepository) Bump(ctx context.Context, projectIDs ...int) error {
+	span := startSpan(ctx, "INCR")
+	defer span.End()
+
+	for _, projectID := range projectIDs {
+		if err := g.db.Incr(generationKey(projectID)).Err(); err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (g goodRepository) Delete(ctx context.Context, key string) error {
-	err := g.db.Del(key)
-	if err.Err() != nil {
-		return err.Err()
+func (g goodRepository) GetGood(ctx context.Context, projectID int, generation int64, id int) (entity.Good, error) {
+	var good entity.Good
+
+	if err := g.get(ctx, goodKey(entity.OrganizationFromContext(ctx), projectID, generation, id), &good); err != nil {
+		return entity.Good{}, err
+	}
+
+	return good, nil
+}
+
+func (g goodRepository) SetGood(ctx context.Context, good entity.Good, generation int64, ttl time.Duration) error {
+	return g.set(ctx, goodKey(entity.OrganizationFromContext(ctx), good.ProjectID, generation, good.ID), good, ttl)
+}
+
+func (g goodRepository) GetPage(ctx context.Context, projectID int, generation int64, query string) ([]entity.Good, error) {
+	var goods []entity.Good
+
+	if err := g.get(ctx, pageKey(entity.OrganizationFromContext(ctx), projectID, generation, query), &goods); err != nil {
+		return nil, err
+	}
+
+	return goods, nil
+}
+
+func (g goodRepository) SetPage(ctx context.Context, projectID int, generation int64, query string, goods []entity.Good, ttl time.Duration) error {
+	return g.set(ctx, pageKey(entity.OrganizationFromContext(ctx), projectID, generation, query), goods, ttl)
+}
+
+func (g goodRepository) set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	span := startSpan(ctx, "SET")
+	defer span.End()
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	if err := g.db.Set(key, data, ttl).Err(); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
 	}
 
 	return nil
 }
 
-func (g goodRepository) Get(ctx context.Context, key string) (entity.Good, error) {
-	data, err := g.db.Get(key).Result()
+func (g goodRepository) get(ctx context.Context, key string, dst any) error {
+	span := startSpan(ctx, "GET")
+	defer span.End()
+
+	data, err := g.db.Get(key).Bytes()
 
 	if err == redis.Nil {
-		return entity.Good{}, entity.ErrGoodNotFound
+		metrics.CacheRequests.WithLabelValues("miss").Inc()
+		return entity.ErrGoodNotFound
 	} else if err != nil {
-		return entity.Good{}, err
+		metrics.CacheRequests.WithLabelValues("error").Inc()
+		span.SetStatus(codes.Error, err.Error())
+		return err
 	}
 
-	var goods entity.Good
-	err = json.Unmarshal([]byte(data), &goods)
-	if err != nil {
-		return entity.Good{}, err
+	if err := json.Unmarshal(data, dst); err != nil {
+		metrics.CacheRequests.WithLabelValues("error").Inc()
+		return err
 	}
 
-	return goods, nil
+	metrics.CacheRequests.WithLabelValues("hit").Inc()
+
+	return nil
 }
@@ -0,0 +1,24 @@
+package cache
+
+import "testing"
+
+func TestKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "good", got: goodKey(2, 1, 3, 4), want: "org:2:project:1:gen:3:good:4"},
+		{name: "good of an unconfined caller", got: goodKey(0, 1, 3, 4), want: "org:0:project:1:gen:3:good:4"},
+		{name: "page", got: pageKey(2, 1, 3, `{}`), want: "org:2:project:1:gen:3:page:44136fa355b3678a1146ad16f7e8649e"},
+		{name: "generation", got: generationKey(1), want: "project:1:generation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("key = %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
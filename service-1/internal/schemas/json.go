@@ -1,11 +1,21 @@
 package schemas
 
-import "github.com/skantay/hezzl/internal/entity"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+)
 
 // Requests
 
 type CreateRequest struct {
-	Name string `json:"name" validate:"required"`
+	Name       string          `json:"name" validate:"required"`
+	Tags       []string        `json:"tags"`
+	CategoryID *int            `json:"category_id"`
+	Attributes json.RawMessage `json:"attributes"`
+	// Visibility is the window the good is shown in; omitted ends are open
+	Visibility *entity.Visibility `json:"visibility"`
 }
 
 type UpdatePriorityRequest struct {
@@ -15,6 +25,74 @@ type UpdatePriorityRequest struct {
 type UpdateGoodRequest struct {
 	Name        string  `json:"name" validate:"required"`
 	Description *string `json:"description" validate:"required"`
+	// Tags replace the current ones when present
+	Tags *[]string `json:"tags"`
+	// CategoryID 0 removes the category
+	CategoryID *int `json:"category_id"`
+	// Attributes replace the current ones when present
+	Attributes json.RawMessage `json:"attributes"`
+	// Visibility replaces both ends of the window when present
+	Visibility *entity.Visibility `json:"visibility"`
+}
+
+type RollbackGoodRequest struct {
+	Revision int `json:"revision" validate:"required"`
+}
+
+type RetagGoodsRequest struct {
+	IDs    []int    `json:"ids" validate:"required"`
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+type RecategorizeGoodsRequest struct {
+	IDs []int `json:"ids" validate:"required"`
+	// CategoryID 0 removes the category
+	CategoryID *int `json:"category_id" validate:"required"`
+}
+
+type MoveGoodsRequest struct {
+	IDs       []int `json:"ids" validate:"required"`
+	ProjectID int   `json:"project_id" validate:"required"`
+	// Priority of the first moved good; they go after the target's last good when absent
+	Priority *int `json:"priority"`
+}
+
+type CreateCategoryRequest struct {
+	Name     string `json:"name" validate:"required"`
+	ParentID *int   `json:"parent_id"`
+}
+
+type SearchLanguageRequest struct {
+	// Language names a text search configuration, e.g. "russian"
+	Language string `json:"language" validate:"required"`
+}
+
+type CloneProjectRequest struct {
+	// Name defaults to the source project's with " (copy)" appended
+	Name string `json:"name"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name       string     `json:"name" validate:"required"`
+	ProjectIDs []int      `json:"project_ids" validate:"required,min=1"`
+	Role       string     `json:"role" validate:"omitempty,oneof=viewer editor admin"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type AssignProjectRequest struct {
+	OrganizationID int `json:"organization_id" validate:"required"`
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" validate:"required"`
+	// Secret is generated when empty
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
 }
 
 // Responses
@@ -31,8 +109,70 @@ type ListResponse struct {
 	} `json:"goods"`
 }
 
+type SearchResponse struct {
+	Meta struct {
+		Query    string `json:"query"`
+		Language string `json:"language"`
+		Total    int    `json:"total"`
+		Limit    int    `json:"limit"`
+		Offset   int    `json:"offset"`
+	} `json:"meta"`
+	Hits []entity.SearchHit `json:"hits"`
+}
+
+type SearchLanguageResponse struct {
+	Language string `json:"language"`
+}
+
+type AuditListResponse struct {
+	Meta struct {
+		Total  int `json:"total"`
+		Limit  int `json:"limit"`
+		Offset int `json:"offset"`
+	} `json:"meta"`
+	Entries []entity.AuditEntry `json:"entries"`
+}
+
+type RevisionListResponse struct {
+	Meta struct {
+		Total  int `json:"total"`
+		Limit  int `json:"limit"`
+		Offset int `json:"offset"`
+	} `json:"meta"`
+	Revisions []entity.Revision `json:"revisions"`
+}
+
+type ChangesetPreviewResponse struct {
+	Meta struct {
+		Limit  int `json:"limit"`
+		Offset int `json:"offset"`
+	} `json:"meta"`
+	// Goods are the project's goods by priority as publishing would leave them
+	Goods []entity.Good `json:"goods"`
+	// Changed lists the ids of the goods the changeset touches
+	Changed []int `json:"changed"`
+}
+
+type ChangesetPublishResponse struct {
+	Goods []entity.Good `json:"goods"`
+}
+
 type DeletedListResponse struct {
 	Id         int  `json:"id"`
 	CampaignID int  `json:"campignID"`
 	Removed    bool `json:"removed"`
 }
+
+type CreateAPIKeyResponse struct {
+	entity.APIKey
+	// Key is the plaintext secret, returned only once
+	Key string `json:"key"`
+}
+
+// GoodsEvent is a change event on the SSE and WebSocket feeds.
+type GoodsEvent struct {
+	ID     uint64        `json:"id"`
+	Action string        `json:"action"`
+	Actor  string        `json:"actor"`
+	Goods  []entity.Good `json:"goods"`
+}
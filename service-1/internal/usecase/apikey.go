@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+)
+
+// keyPrefix marks plaintext API keys so they are easy to spot in leaks.
+const keyPrefix = "hz_"
+
+// defaultKeyRole is granted to keys issued without a role.
+const defaultKeyRole = entity.RoleEditor
+
+type APIKeyUsecase interface {
+	// Issue creates a key with role in each of the projects; an empty role
+	// means editor
+	Issue(ctx context.Context, name string, projectIDs []int, role string, expiresAt *time.Time) (entity.APIKey, string, error)
+	Authenticate(ctx context.Context, raw string) (entity.Principal, error)
+	List(ctx context.Context) ([]entity.APIKey, error)
+	Revoke(ctx context.Context, id int) (entity.APIKey, error)
+}
+
+type apiKeyUsecase struct {
+	repo postgres.APIKeyRepository
+}
+
+func NewAPIKeyUsecase(repo postgres.APIKeyRepository) APIKeyUsecase {
+	return apiKeyUsecase{repo}
+}
+
+func (a apiKeyUsecase) Issue(ctx context.Context, name string, projectIDs []int, role string, expiresAt *time.Time) (entity.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+
+	var fields []entity.FieldError
+
+	if name == "" {
+		fields = append(fields, entity.FieldError{Field: "name", Rule: "required"})
+	}
+
+	if len(projectIDs) == 0 {
+		fields = append(fields, entity.FieldError{Field: "project_ids", Rule: "required"})
+	}
+
+	keyRole := defaultKeyRole
+	if role != "" {
+		var err error
+		if keyRole, err = entity.ParseRole(role); err != nil {
+			fields = append(fields, entity.FieldError{Field: "role", Rule: "oneof", Param: "viewer editor admin"})
+		}
+	}
+
+	now := time.Now()
+
+	if expiresAt != nil && !expiresAt.After(now) {
+		fields = append(fields, entity.FieldError{Field: "expires_at", Rule: "future"})
+	}
+
+	if len(fields) != 0 {
+		return entity.APIKey{}, "", entity.NewValidationError(fields...)
+	}
+
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return entity.APIKey{}, "", fmt.Errorf("trouble generating api key: %w", err)
+	}
+
+	raw := keyPrefix + hex.EncodeToString(secret)
+
+	key, err := a.repo.Create(ctx, entity.APIKey{
+		Name:       name,
+		Prefix:     raw[:len(keyPrefix)+8],
+		ProjectIDs: projectIDs,
+		Role:       keyRole,
+		CreatedAt:  now,
+		ExpiresAt:  expiresAt,
+	}, hashKey(raw))
+	if err != nil {
+		return entity.APIKey{}, "", fmt.Errorf("trouble creating an api key: %w", err)
+	}
+
+	return key, raw, nil
+}
+
+func (a apiKeyUsecase) Authenticate(ctx context.Context, raw string) (entity.Principal, error) {
+	if !strings.HasPrefix(raw, keyPrefix) {
+		return entity.Principal{}, entity.ErrUnauthorized
+	}
+
+	key, err := a.repo.GetByHash(ctx, hashKey(raw))
+	if err != nil {
+		if errors.Is(err, entity.ErrAPIKeyNotFound) {
+			return entity.Principal{}, entity.ErrUnauthorized
+		}
+
+		return entity.Principal{}, fmt.Errorf("trouble getting an api key: %w", err)
+	}
+
+	if !key.Active(time.Now()) {
+		return entity.Principal{}, fmt.Errorf("api key #%d is inactive: %w", key.ID, entity.ErrUnauthorized)
+	}
+
+	roles := make(map[int]entity.Role, len(key.ProjectIDs))
+	for _, projectID := range key.ProjectIDs {
+		roles[projectID] = key.Role
+	}
+
+	return entity.Principal{
+		Subject:        "apikey:" + strconv.Itoa(key.ID),
+		Roles:          roles,
+		OrganizationID: key.OrganizationID,
+	}, nil
+}
+
+func (a apiKeyUsecase) List(ctx context.Context) ([]entity.APIKey, error) {
+	keys, err := a.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("trouble listing api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (a apiKeyUsecase) Revoke(ctx context.Context, id int) (entity.APIKey, error) {
+	key, err := a.repo.Revoke(ctx, id, time.Now())
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("trouble revoking an api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// hashKey is a plain SHA-256 since keys carry 160 random bits and must be
+// looked up by hash.
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
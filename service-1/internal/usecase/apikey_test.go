@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+func TestAPIKeyIssue(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		keyName    string
+		projectIDs []int
+		role       string
+		expiresAt  *time.Time
+		wantRole   entity.Role
+		wantFields []string
+	}{
+		{name: "default role", keyName: "ci", projectIDs: []int{1, 2}, wantRole: entity.RoleEditor},
+		{name: "viewer", keyName: "dashboard", projectIDs: []int{1}, role: "viewer", wantRole: entity.RoleViewer},
+		{name: "admin", keyName: "ops", projectIDs: []int{1}, role: "admin", wantRole: entity.RoleAdmin},
+		{name: "unknown role", keyName: "ci", projectIDs: []int{1}, role: "owner", wantFields: []string{"role"}},
+		{
+			name:       "everything wrong",
+			keyName:    " ",
+			expiresAt:  &past,
+			wantFields: []string{"name", "project_ids", "expires_at"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAPIKeys{byHash: map[string]entity.APIKey{}}
+
+			key, raw, err := NewAPIKeyUsecase(repo).Issue(context.Background(), tt.keyName, tt.projectIDs, tt.role, tt.expiresAt)
+			if tt.wantFields != nil {
+				var validation *entity.ValidationError
+				if !errors.As(err, &validation) {
+					t.Fatalf("Issue() error = %v, want a validation error", err)
+				}
+
+				var fields []string
+				for _, field := range validation.Fields {
+					fields = append(fields, field.Field)
+				}
+
+				if !reflect.DeepEqual(fields, tt.wantFields) {
+					t.Errorf("invalid fields %q, want %q", fields, tt.wantFields)
+				}
+
+				if len(repo.byHash) != 0 {
+					t.Errorf("stored %d keys, want none", len(repo.byHash))
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Issue() error = %v", err)
+			}
+
+			if !strings.HasPrefix(raw, keyPrefix) || !strings.HasPrefix(raw, key.Prefix) {
+				t.Errorf("key %q, want it to start with %q and the prefix %q", raw, keyPrefix, key.Prefix)
+			}
+
+			// Only the hash of the secret is stored
+			stored, ok := repo.byHash[hashKey(raw)]
+			if !ok {
+				t.Fatalf("key not stored under the hash of %q", raw)
+			}
+
+			if stored.Role != tt.wantRole || key.Role != tt.wantRole {
+				t.Errorf("role = %v, want %v", stored.Role, tt.wantRole)
+			}
+
+			if !reflect.DeepEqual(stored.ProjectIDs, tt.projectIDs) {
+				t.Errorf("projects = %v, want %v", stored.ProjectIDs, tt.projectIDs)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	repo := &fakeAPIKeys{byHash: map[string]entity.APIKey{}}
+	keys := NewAPIKeyUsecase(repo)
+	ctx := context.Background()
+
+	issue := func(role string, expiresAt *time.Time) (entity.APIKey, string) {
+		key, raw, err := keys.Issue(ctx, "key", []int{1, 2}, role, expiresAt)
+		if err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+
+		return key, raw
+	}
+
+	soon := time.Now().Add(time.Hour)
+
+	_, editorRaw := issue("", nil)
+	_, viewerRaw := issue("viewer", &soon)
+	revoked, revokedRaw := issue("admin", nil)
+
+	if _, err := keys.Revoke(ctx, revoked.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	// Issue refuses past expiries, so the key expires in the repository
+	expired, expiredRaw := issue("admin", &soon)
+	expired.ExpiresAt = &time.Time{}
+	repo.byHash[hashKey(expiredRaw)] = expired
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    entity.Principal
+		wantErr error
+	}{
+		{
+			name: "default role",
+			raw:  editorRaw,
+			want: entity.Principal{
+				Subject:        "apikey:1",
+				Roles:          map[int]entity.Role{1: entity.RoleEditor, 2: entity.RoleEditor},
+				OrganizationID: 1,
+			},
+		},
+		{
+			name: "viewer",
+			raw:  viewerRaw,
+			want: entity.Principal{
+				Subject:        "apikey:2",
+				Roles:          map[int]entity.Role{1: entity.RoleViewer, 2: entity.RoleViewer},
+				OrganizationID: 1,
+			},
+		},
+		{name: "revoked", raw: revokedRaw, wantErr: entity.ErrUnauthorized},
+		{name: "expired", raw: expiredRaw, wantErr: entity.ErrUnauthorized},
+		{name: "unknown", raw: keyPrefix + strings.Repeat("0", 40), wantErr: entity.ErrUnauthorized},
+		{name: "without the prefix", raw: strings.TrimPrefix(editorRaw, keyPrefix), wantErr: entity.ErrUnauthorized},
+		{name: "hash instead of the key", raw: hashKey(editorRaw), wantErr: entity.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := keys.Authenticate(ctx, tt.raw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && !reflect.DeepEqual(principal, tt.want) {
+				t.Errorf("Authenticate() = %+v, want %+v", principal, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyRevoke(t *testing.T) {
+	repo := &fakeAPIKeys{byHash: map[string]entity.APIKey{}}
+	keys := NewAPIKeyUsecase(repo)
+	ctx := context.Background()
+
+	key, raw, err := keys.Issue(ctx, "key", []int{1}, "", nil)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	revoked, err := keys.Revoke(ctx, key.ID)
+	if err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	if revoked.RevokedAt == nil {
+		t.Fatal("Revoke() returned a key without revoked_at")
+	}
+
+	if _, err := keys.Authenticate(ctx, raw); !errors.Is(err, entity.ErrUnauthorized) {
+		t.Errorf("Authenticate() after Revoke() error = %v, want %v", err, entity.ErrUnauthorized)
+	}
+
+	if _, err := keys.Revoke(ctx, key.ID+1); !errors.Is(err, entity.ErrAPIKeyNotFound) {
+		t.Errorf("Revoke() of an unknown key error = %v, want %v", err, entity.ErrAPIKeyNotFound)
+	}
+}
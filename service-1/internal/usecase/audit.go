@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+)
+
+// auditMaxLimit caps a single page of audit entries.
+const auditMaxLimit = 500
+
+type AuditUsecase interface {
+	List(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, int, error)
+}
+
+type auditUsecase struct {
+	repo postgres.AuditRepository
+}
+
+func NewAuditUsecase(repo postgres.AuditRepository) AuditUsecase {
+	return auditUsecase{repo}
+}
+
+func (a auditUsecase) List(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, int, error) {
+	ctx, span := tracer.Start(ctx, "auditUsecase.List")
+	defer span.End()
+
+	var fields []entity.FieldError
+
+	if filter.Limit < 1 || filter.Limit > auditMaxLimit {
+		fields = append(fields, entity.FieldError{Field: "limit", Rule: "range", Param: fmt.Sprintf("1-%d", auditMaxLimit)})
+	}
+
+	if filter.Offset < 0 {
+		fields = append(fields, entity.FieldError{Field: "offset", Rule: "min", Param: "0"})
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		fields = append(fields, entity.FieldError{Field: "to", Rule: "gtefield", Param: "from"})
+	}
+
+	if len(fields) != 0 {
+		return nil, 0, entity.NewQueryError(fields...)
+	}
+
+	filter.OrganizationID = entity.OrganizationFromContext(ctx)
+
+	entries, total, err := a.repo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("trouble listing audit entries: %w", err)
+	}
+
+	return entries, total, nil
+}
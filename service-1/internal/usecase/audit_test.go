@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+func TestAuditList(t *testing.T) {
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tenant := entity.WithPrincipal(context.Background(), entity.Principal{OrganizationID: 3})
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		filter     entity.AuditFilter
+		wantErr    error
+		wantFilter *entity.AuditFilter
+	}{
+		{
+			name:       "unconfined",
+			ctx:        context.Background(),
+			filter:     entity.AuditFilter{ProjectID: 1, Limit: 50},
+			wantFilter: &entity.AuditFilter{ProjectID: 1, Limit: 50},
+		},
+		{
+			name:       "confined to the organization",
+			ctx:        tenant,
+			filter:     entity.AuditFilter{Limit: 10},
+			wantFilter: &entity.AuditFilter{OrganizationID: 3, Limit: 10},
+		},
+		{
+			name:       "organization cannot be widened",
+			ctx:        tenant,
+			filter:     entity.AuditFilter{OrganizationID: 4, ProjectID: 1, Limit: 10},
+			wantFilter: &entity.AuditFilter{OrganizationID: 3, ProjectID: 1, Limit: 10},
+		},
+		{name: "no limit", ctx: tenant, filter: entity.AuditFilter{}, wantErr: entity.ErrInvalidQuery},
+		{name: "limit too high", ctx: tenant, filter: entity.AuditFilter{Limit: auditMaxLimit + 1}, wantErr: entity.ErrInvalidQuery},
+		{name: "negative offset", ctx: tenant, filter: entity.AuditFilter{Limit: 1, Offset: -1}, wantErr: entity.ErrInvalidQuery},
+		{
+			name:    "to before from",
+			ctx:     tenant,
+			filter:  entity.AuditFilter{Limit: 1, From: from, To: from.Add(-time.Hour)},
+			wantErr: entity.ErrInvalidQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAudit{}
+
+			_, _, err := NewAuditUsecase(repo).List(tt.ctx, tt.filter)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("List() error = %v, want %v", err, tt.wantErr)
+			}
+
+			switch {
+			case tt.wantFilter == nil && repo.filter != nil:
+				t.Errorf("repository listed %+v, want no query", *repo.filter)
+			case tt.wantFilter != nil && (repo.filter == nil || *repo.filter != *tt.wantFilter):
+				t.Errorf("repository listed %+v, want %+v", repo.filter, *tt.wantFilter)
+			}
+		})
+	}
+}
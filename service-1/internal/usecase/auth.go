@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+	"github.com/skantay/hezzl/pkg/jwtauth"
+)
+
+// allProjects is the key in the roles claim that applies to every project.
+const allProjects = "*"
+
+// AuthUsecase turns the credentials of any transport into a principal.
+type AuthUsecase interface {
+	// Enabled reports whether any authentication method is configured
+	Enabled() bool
+	// Authenticate prefers the bearer token over the API key when both are set
+	Authenticate(ctx context.Context, bearer, apiKey string) (entity.Principal, error)
+}
+
+type authUsecase struct {
+	apiKeys       APIKeyUsecase
+	apiKeysOn     bool
+	verifier      *jwtauth.Verifier
+	rolesClaim    string
+	tenantClaim   string
+	organizations postgres.OrganizationRepository
+}
+
+// NewAuthUsecase accepts API keys when apiKeysOn is set and JWT bearer
+// tokens when verifier is not nil. rolesClaim names the claim mapping
+// project ids (or "*") to role names; tenantClaim, when set, names the
+// required claim holding the caller's organization id.
+func NewAuthUsecase(apiKeys APIKeyUsecase, apiKeysOn bool, verifier *jwtauth.Verifier, rolesClaim, tenantClaim string, organizations postgres.OrganizationRepository) AuthUsecase {
+	return authUsecase{
+		apiKeys:       apiKeys,
+		apiKeysOn:     apiKeysOn,
+		verifier:      verifier,
+		rolesClaim:    rolesClaim,
+		tenantClaim:   tenantClaim,
+		organizations: organizations,
+	}
+}
+
+func (a authUsecase) Enabled() bool {
+	return a.apiKeysOn || a.verifier != nil
+}
+
+func (a authUsecase) Authenticate(ctx context.Context, bearer, apiKey string) (entity.Principal, error) {
+	var (
+		principal entity.Principal
+		err       error
+	)
+
+	switch {
+	case bearer != "" && a.verifier != nil:
+		principal, err = a.authenticateToken(ctx, bearer)
+	case apiKey != "" && a.apiKeysOn:
+		principal, err = a.apiKeys.Authenticate(ctx, apiKey)
+	default:
+		return entity.Principal{}, fmt.Errorf("%w: missing credentials", entity.ErrUnauthorized)
+	}
+
+	if err != nil {
+		return entity.Principal{}, err
+	}
+
+	if principal.OrganizationID == 0 {
+		return principal, nil
+	}
+
+	// Roles, the global one included, apply only inside the organization
+	projects, err := a.organizations.Projects(ctx, principal.OrganizationID)
+	if err != nil {
+		return entity.Principal{}, fmt.Errorf("trouble listing organization projects: %w", err)
+	}
+
+	principal.Projects = make(map[int]bool, len(projects))
+	for _, id := range projects {
+		principal.Projects[id] = true
+	}
+
+	return principal, nil
+}
+
+func (a authUsecase) authenticateToken(ctx context.Context, token string) (entity.Principal, error) {
+	var custom map[string]json.RawMessage
+
+	claims, err := a.verifier.Verify(ctx, token, &custom)
+	if err != nil {
+		if errors.Is(err, jwtauth.ErrInvalidToken) {
+			return entity.Principal{}, fmt.Errorf("%w: %v", entity.ErrUnauthorized, err)
+		}
+
+		return entity.Principal{}, err
+	}
+
+	if claims.Subject == "" {
+		return entity.Principal{}, fmt.Errorf("%w: token has no subject", entity.ErrUnauthorized)
+	}
+
+	principal := entity.Principal{
+		Subject: claims.Subject,
+		Roles:   make(map[int]entity.Role),
+	}
+
+	if a.tenantClaim != "" {
+		raw, ok := custom[a.tenantClaim]
+		if !ok {
+			return entity.Principal{}, fmt.Errorf("%w: token has no organization", entity.ErrUnauthorized)
+		}
+
+		if principal.OrganizationID, err = parseOrganizationClaim(raw); err != nil {
+			return entity.Principal{}, fmt.Errorf("%w: malformed organization claim", entity.ErrUnauthorized)
+		}
+	}
+
+	var roles map[string]string
+
+	if raw, ok := custom[a.rolesClaim]; ok {
+		if err := json.Unmarshal(raw, &roles); err != nil {
+			return entity.Principal{}, fmt.Errorf("%w: malformed roles claim", entity.ErrUnauthorized)
+		}
+	}
+
+	for project, name := range roles {
+		role, err := entity.ParseRole(name)
+		if err != nil {
+			// Unknown roles grant nothing rather than failing the request
+			continue
+		}
+
+		if project == allProjects {
+			principal.GlobalRole = role
+
+			continue
+		}
+
+		projectID, err := strconv.Atoi(project)
+		if err != nil {
+			continue
+		}
+
+		principal.Roles[projectID] = role
+	}
+
+	return principal, nil
+}
+
+// parseOrganizationClaim accepts a positive organization id as a JSON number
+// or string.
+func parseOrganizationClaim(raw json.RawMessage) (int, error) {
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return 0, err
+	}
+
+	var id int
+
+	switch v := value.(type) {
+	case float64:
+		id = int(v)
+		if float64(id) != v {
+			return 0, fmt.Errorf("organization id %v is not an integer", v)
+		}
+	case string:
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, err
+		}
+
+		id = parsed
+	default:
+		return 0, fmt.Errorf("organization id has type %T", value)
+	}
+
+	if id < 1 {
+		return 0, fmt.Errorf("organization id %d is not positive", id)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/pkg/jwtauth"
+)
+
+// newTokenIssuer returns a verifier trusting a fresh key and a function
+// signing tokens with it for the subject and custom claims.
+func newTokenIssuer(t *testing.T) (*jwtauth.Verifier, func(subject string, custom map[string]any) string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "k", Algorithm: string(jose.ES256)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, set, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := jwtauth.NewVerifier(context.Background(), jwtauth.Options{JWKS: path, Issuer: "issuer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: jose.JSONWebKey{Key: key, KeyID: "k"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(subject string, custom map[string]any) string {
+		claims := jwt.Claims{Subject: subject, Issuer: "issuer", Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+
+		token, err := jwt.Signed(signer).Claims(claims).Claims(custom).CompactSerialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	}
+
+	return verifier, sign
+}
+
+func TestAuthenticateToken(t *testing.T) {
+	verifier, sign := newTokenIssuer(t)
+	_, foreign := newTokenIssuer(t)
+
+	organizations := fakeOrganizations{projects: map[int][]int{5: {10, 11}}}
+
+	tests := []struct {
+		name        string
+		tenantClaim string
+		token       string
+		want        entity.Principal
+		wantErr     error
+	}{
+		{
+			name:  "project roles",
+			token: sign("user", map[string]any{"roles": map[string]string{"1": "admin", "2": "viewer"}}),
+			want:  entity.Principal{Subject: "user", Roles: map[int]entity.Role{1: entity.RoleAdmin, 2: entity.RoleViewer}},
+		},
+		{
+			name:  "every project",
+			token: sign("user", map[string]any{"roles": map[string]string{"*": "editor", "3": "admin"}}),
+			want:  entity.Principal{Subject: "user", Roles: map[int]entity.Role{3: entity.RoleAdmin}, GlobalRole: entity.RoleEditor},
+		},
+		{
+			name:  "unknown roles and projects grant nothing",
+			token: sign("user", map[string]any{"roles": map[string]string{"1": "owner", "x": "admin"}}),
+			want:  entity.Principal{Subject: "user", Roles: map[int]entity.Role{}},
+		},
+		{
+			name:  "no roles",
+			token: sign("user", nil),
+			want:  entity.Principal{Subject: "user", Roles: map[int]entity.Role{}},
+		},
+		{
+			name:    "malformed roles",
+			token:   sign("user", map[string]any{"roles": []string{"admin"}}),
+			wantErr: entity.ErrUnauthorized,
+		},
+		{
+			name:    "no subject",
+			token:   sign("", map[string]any{"roles": map[string]string{"*": "admin"}}),
+			wantErr: entity.ErrUnauthorized,
+		},
+		{
+			name:    "signed by another issuer",
+			token:   foreign("user", map[string]any{"roles": map[string]string{"*": "admin"}}),
+			wantErr: entity.ErrUnauthorized,
+		},
+		{
+			name:        "organization as a number",
+			tenantClaim: "org",
+			token:       sign("user", map[string]any{"org": 5, "roles": map[string]string{"*": "viewer"}}),
+			want: entity.Principal{
+				Subject:        "user",
+				Roles:          map[int]entity.Role{},
+				GlobalRole:     entity.RoleViewer,
+				OrganizationID: 5,
+				Projects:       map[int]bool{10: true, 11: true},
+			},
+		},
+		{
+			name:        "organization as a string",
+			tenantClaim: "org",
+			token:       sign("user", map[string]any{"org": "5"}),
+			want:        entity.Principal{Subject: "user", Roles: map[int]entity.Role{}, OrganizationID: 5, Projects: map[int]bool{10: true, 11: true}},
+		},
+		{
+			name:        "organization missing",
+			tenantClaim: "org",
+			token:       sign("user", map[string]any{"roles": map[string]string{"*": "admin"}}),
+			wantErr:     entity.ErrUnauthorized,
+		},
+		{name: "organization fractional", tenantClaim: "org", token: sign("user", map[string]any{"org": 5.5}), wantErr: entity.ErrUnauthorized},
+		{name: "organization zero", tenantClaim: "org", token: sign("user", map[string]any{"org": 0}), wantErr: entity.ErrUnauthorized},
+		{name: "organization negative", tenantClaim: "org", token: sign("user", map[string]any{"org": "-1"}), wantErr: entity.ErrUnauthorized},
+		{name: "organization boolean", tenantClaim: "org", token: sign("user", map[string]any{"org": true}), wantErr: entity.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := NewAuthUsecase(nil, false, verifier, "roles", tt.tenantClaim, organizations)
+
+			principal, err := auth.Authenticate(context.Background(), tt.token, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && !reflect.DeepEqual(principal, tt.want) {
+				t.Errorf("Authenticate() = %+v, want %+v", principal, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+	cache "github.com/skantay/hezzl/internal/repository/redis"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// previewMaxLimit caps a page of a changeset preview.
+const previewMaxLimit = 500
+
+// ChangesetUsecase stages changes to a project's goods in a draft that is
+// published at once or discarded. Staged changes are validated like direct
+// ones, and again when the draft is previewed or published.
+type ChangesetUsecase interface {
+	Create(ctx context.Context, projectID int) (entity.Changeset, error)
+	List(ctx context.Context, projectID int) ([]entity.Changeset, error)
+	StageCreate(ctx context.Context, changesetID, projectID int, input entity.GoodInput) (entity.Change, error)
+	StageUpdate(ctx context.Context, changesetID, id, projectID int, input entity.GoodInput) (entity.Change, error)
+	StageRemove(ctx context.Context, changesetID, id, projectID int) (entity.Change, error)
+	StageReprioritize(ctx context.Context, changesetID, priority, id, projectID int) (entity.Change, error)
+	Preview(ctx context.Context, changesetID, projectID, limit, offset int) ([]entity.Good, []int, error)
+	Publish(ctx context.Context, changesetID, projectID int) ([]entity.Good, error)
+	Discard(ctx context.Context, changesetID, projectID int) error
+}
+
+type changesetUsecase struct {
+	repo  postgres.ChangesetRepository
+	goods GoodUsecase
+	cache cache.GoodCacheRepository
+	log   *zap.Logger
+}
+
+func NewChangesetUsecase(repo postgres.ChangesetRepository, goods GoodUsecase, cache cache.GoodCacheRepository, log *zap.Logger) ChangesetUsecase {
+	return changesetUsecase{
+		repo:  repo,
+		goods: goods,
+		cache: cache,
+		log:   log,
+	}
+}
+
+func (c changesetUsecase) Create(ctx context.Context, projectID int) (entity.Changeset, error) {
+	changeset, err := c.repo.Create(ctx, projectID)
+	if err != nil {
+		return entity.Changeset{}, fmt.Errorf("trouble creating a changeset: %w", err)
+	}
+
+	return changeset, nil
+}
+
+func (c changesetUsecase) List(ctx context.Context, projectID int) ([]entity.Changeset, error) {
+	changesets, err := c.repo.List(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("trouble listing changesets: %w", err)
+	}
+
+	return changesets, nil
+}
+
+func (c changesetUsecase) StageCreate(ctx context.Context, changesetID, projectID int, input entity.GoodInput) (entity.Change, error) {
+	if err := c.goods.Validate(ctx, projectID, 0, &input); err != nil {
+		return entity.Change{}, err
+	}
+
+	return c.stage(ctx, changesetID, projectID, entity.Change{Action: entity.ActionCreate, Input: &input})
+}
+
+func (c changesetUsecase) StageUpdate(ctx context.Context, changesetID, id, projectID int, input entity.GoodInput) (entity.Change, error) {
+	if err := c.goods.Validate(ctx, projectID, id, &input); err != nil {
+		return entity.Change{}, err
+	}
+
+	return c.stage(ctx, changesetID, projectID, entity.Change{Action: entity.ActionUpdate, GoodID: &id, Input: &input})
+}
+
+func (c changesetUsecase) StageRemove(ctx context.Context, changesetID, id, projectID int) (entity.Change, error) {
+	return c.stage(ctx, changesetID, projectID, entity.Change{Action: entity.ActionRemove, GoodID: &id})
+}
+
+func (c changesetUsecase) StageReprioritize(ctx context.Context, changesetID, priority, id, projectID int) (entity.Change, error) {
+	if fields := entity.ValidatePriority(priority); len(fields) != 0 {
+		return entity.Change{}, entity.NewValidationError(fields...)
+	}
+
+	return c.stage(ctx, changesetID, projectID, entity.Change{Action: entity.ActionReprioritize, GoodID: &id, Priority: &priority})
+}
+
+func (c changesetUsecase) stage(ctx context.Context, changesetID, projectID int, change entity.Change) (entity.Change, error) {
+	ctx, span := tracer.Start(ctx, "changesetUsecase.stage", trace.WithAttributes(
+		attribute.Int("changeset.id", changesetID),
+		attribute.Int("project.id", projectID),
+		attribute.String("action", change.Action)))
+	defer span.End()
+
+	staged, err := c.repo.Stage(ctx, changesetID, projectID, change)
+	if err != nil {
+		return entity.Change{}, fmt.Errorf("trouble staging a change: %w", err)
+	}
+
+	return staged, nil
+}
+
+func (c changesetUsecase) Preview(ctx context.Context, changesetID, projectID, limit, offset int) ([]entity.Good, []int, error) {
+	ctx, span := tracer.Start(ctx, "changesetUsecase.Preview", trace.WithAttributes(attribute.Int("changeset.id", changesetID)))
+	defer span.End()
+
+	var fields []entity.FieldError
+
+	if limit < 1 || limit > previewMaxLimit {
+		fields = append(fields, entity.FieldError{Field: "limit", Rule: "range", Param: fmt.Sprintf("1-%d", previewMaxLimit)})
+	}
+
+	if offset < 0 {
+		fields = append(fields, entity.FieldError{Field: "offset", Rule: "min", Param: "0"})
+	}
+
+	if len(fields) != 0 {
+		return nil, nil, entity.NewQueryError(fields...)
+	}
+
+	goods, changed, err := c.repo.Preview(ctx, changesetID, projectID, limit, offset, c.recheck(projectID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("trouble previewing a changeset: %w", err)
+	}
+
+	return goods, changed, nil
+}
+
+// Publish applies the changeset and drops the project's cache.
+func (c changesetUsecase) Publish(ctx context.Context, changesetID, projectID int) ([]entity.Good, error) {
+	ctx, span := tracer.Start(ctx, "changesetUsecase.Publish", trace.WithAttributes(attribute.Int("changeset.id", changesetID)))
+	defer span.End()
+
+	goods, err := c.repo.Publish(ctx, changesetID, projectID, c.recheck(projectID))
+	if err != nil {
+		return nil, fmt.Errorf("trouble publishing a changeset: %w", err)
+	}
+
+	forgetProjects(ctx, c.cache, c.log, projectID)
+
+	return goods, nil
+}
+
+// recheck validates a change as staging it did. A change that no longer
+// passes conflicts with the catalogue.
+func (c changesetUsecase) recheck(projectID int) postgres.ChangeCheck {
+	return func(ctx context.Context, change *entity.Change) error {
+		var err error
+
+		switch change.Action {
+		case entity.ActionCreate:
+			err = c.goods.Validate(ctx, projectID, 0, change.Input)
+		case entity.ActionUpdate:
+			err = c.goods.Validate(ctx, projectID, *change.GoodID, change.Input)
+		case entity.ActionReprioritize:
+			if fields := entity.ValidatePriority(*change.Priority); len(fields) != 0 {
+				err = entity.NewValidationError(fields...)
+			}
+		}
+
+		if errors.Is(err, entity.ErrValidation) || errors.Is(err, entity.ErrGoodNameTaken) {
+			return fmt.Errorf("%w: change #%d: %v", entity.ErrChangesetConflict, change.ID, err)
+		}
+		if err != nil {
+			return fmt.Errorf("trouble checking change #%d: %w", change.ID, err)
+		}
+
+		return nil
+	}
+}
+
+func (c changesetUsecase) Discard(ctx context.Context, changesetID, projectID int) error {
+	if err := c.repo.Discard(ctx, changesetID, projectID); err != nil {
+		return fmt.Errorf("trouble discarding a changeset: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/skantay/hezzl/internal/entity"
+
+	"go.uber.org/zap"
+)
+
+func TestChangesetPublish(t *testing.T) {
+	sizeSchema := json.RawMessage(`{"type":"object","required":["size"]}`)
+
+	create := func(name string, attrs string) entity.Change {
+		input := &entity.GoodInput{Name: name}
+		if attrs != "" {
+			input.Attributes = json.RawMessage(attrs)
+		}
+
+		return entity.Change{ID: 1, Action: entity.ActionCreate, Input: input}
+	}
+	update := func(id int, name string) entity.Change {
+		return entity.Change{ID: 2, Action: entity.ActionUpdate, GoodID: &id, Input: &entity.GoodInput{Name: name}}
+	}
+	reprioritize := func(id, priority int) entity.Change {
+		return entity.Change{ID: 3, Action: entity.ActionReprioritize, GoodID: &id, Priority: &priority}
+	}
+	remove := func(id int) entity.Change {
+		return entity.Change{ID: 4, Action: entity.ActionRemove, GoodID: &id}
+	}
+
+	tests := []struct {
+		name      string
+		rules     Rules
+		schema    json.RawMessage
+		existing  map[string]int
+		changes   []entity.Change
+		wantErr   error
+		wantNames []string
+	}{
+		{
+			name:      "every action applied",
+			changes:   []entity.Change{create("apple", ""), update(1, "pear"), reprioritize(1, 5), remove(2)},
+			wantNames: []string{"apple", "pear", "", ""},
+		},
+		{
+			name:      "names normalized again",
+			changes:   []entity.Change{create("  apple ", "")},
+			wantNames: []string{"apple"},
+		},
+		{
+			name:      "name taken since staging",
+			rules:     Rules{UniqueNames: true},
+			existing:  map[string]int{"apple": 9},
+			changes:   []entity.Change{create("pear", ""), create("apple", "")},
+			wantErr:   entity.ErrChangesetConflict,
+			wantNames: nil,
+		},
+		{
+			name:      "renaming a good to its own name",
+			rules:     Rules{UniqueNames: true},
+			existing:  map[string]int{"apple": 1},
+			changes:   []entity.Change{update(1, "apple")},
+			wantNames: []string{"apple"},
+		},
+		{
+			name:    "schema tightened since staging",
+			schema:  sizeSchema,
+			changes: []entity.Change{create("apple", `{"color":"red"}`)},
+			wantErr: entity.ErrChangesetConflict,
+		},
+		{
+			name:      "schema still satisfied",
+			schema:    sizeSchema,
+			changes:   []entity.Change{create("apple", `{"size":"M"}`)},
+			wantNames: []string{"apple"},
+		},
+		{
+			name:    "priority out of range",
+			changes: []entity.Change{reprioritize(1, 0)},
+			wantErr: entity.ErrChangesetConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goods := NewGoodUsecase(&fakeGoods{existing: tt.existing}, fakeProjects{schema: tt.schema}, nil, nil, tt.rules, zap.NewNop())
+			repo := &fakeChangesets{changes: tt.changes}
+			cache := newFakeCache()
+
+			published, err := NewChangesetUsecase(repo, goods, cache, zap.NewNop()).Publish(context.Background(), 1, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Publish() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if errors.Is(err, entity.ErrValidation) {
+					t.Errorf("Publish() error = %v, want a conflict rather than a validation error", err)
+				}
+
+				if repo.published || len(cache.bumps) != 0 || published != nil {
+					t.Errorf("failed publish: published = %v, bumps = %v, goods = %v, want nothing", repo.published, cache.bumps, published)
+				}
+
+				return
+			}
+
+			names := make([]string, 0, len(published))
+			for _, good := range published {
+				names = append(names, good.Name)
+			}
+
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("Publish() names = %q, want %q", names, tt.wantNames)
+			}
+
+			if !repo.published || !reflect.DeepEqual(cache.bumps, []int{1}) {
+				t.Errorf("published = %v, bumps = %v, want the project's cache dropped", repo.published, cache.bumps)
+			}
+		})
+	}
+}
+
+func TestChangesetPreview(t *testing.T) {
+	goods := NewGoodUsecase(&fakeGoods{existing: map[string]int{"apple": 9}}, fakeProjects{}, nil, nil, Rules{UniqueNames: true}, zap.NewNop())
+
+	repo := &fakeChangesets{changes: []entity.Change{{ID: 1, Action: entity.ActionCreate, Input: &entity.GoodInput{Name: "apple"}}}}
+	cache := newFakeCache()
+	uc := NewChangesetUsecase(repo, goods, cache, zap.NewNop())
+
+	if _, _, err := uc.Preview(context.Background(), 1, 1, 10, 0); !errors.Is(err, entity.ErrChangesetConflict) {
+		t.Errorf("Preview() error = %v, want the conflict publishing would hit", err)
+	}
+
+	if _, _, err := uc.Preview(context.Background(), 1, 1, previewMaxLimit+1, 0); !errors.Is(err, entity.ErrInvalidQuery) {
+		t.Errorf("Preview() error = %v, want %v for a page too large", err, entity.ErrInvalidQuery)
+	}
+
+	repo.changes[0].Input.Name = "pear"
+
+	previewed, ids, err := uc.Preview(context.Background(), 1, 1, 10, 0)
+	if err != nil || len(previewed) != 1 || len(ids) != 1 {
+		t.Fatalf("Preview() = %v, %v, %v, want the created good", previewed, ids, err)
+	}
+
+	if repo.published || len(cache.bumps) != 0 {
+		t.Errorf("Preview() published = %v, bumps = %v, want nothing written", repo.published, cache.bumps)
+	}
+}
+
+func TestChangesetStage(t *testing.T) {
+	goods := NewGoodUsecase(&fakeGoods{}, fakeProjects{}, nil, nil, Rules{}, zap.NewNop())
+	repo := &fakeChangesets{}
+	uc := NewChangesetUsecase(repo, goods, newFakeCache(), zap.NewNop())
+	ctx := context.Background()
+
+	if _, err := uc.StageCreate(ctx, 1, 1, entity.GoodInput{Name: ""}); !errors.Is(err, entity.ErrValidation) {
+		t.Errorf("StageCreate() error = %v, want %v", err, entity.ErrValidation)
+	}
+
+	if _, err := uc.StageReprioritize(ctx, 1, 0, 1, 1); !errors.Is(err, entity.ErrValidation) {
+		t.Errorf("StageReprioritize() error = %v, want %v", err, entity.ErrValidation)
+	}
+
+	if len(repo.staged) != 0 {
+		t.Fatalf("invalid changes staged: %+v", repo.staged)
+	}
+
+	change, err := uc.StageCreate(ctx, 1, 1, entity.GoodInput{Name: " apple "})
+	if err != nil || change.Action != entity.ActionCreate || change.Input.Name != "apple" {
+		t.Errorf("StageCreate() = %+v, %v, want a normalized create", change, err)
+	}
+}
+
+func TestChangesetDiscard(t *testing.T) {
+	repo := &fakeChangesets{}
+	uc := NewChangesetUsecase(repo, nil, newFakeCache(), zap.NewNop())
+
+	if err := uc.Discard(context.Background(), 1, 1); err != nil || !repo.discarded {
+		t.Fatalf("Discard() error = %v, discarded = %v", err, repo.discarded)
+	}
+
+	if err := uc.Discard(context.Background(), 1, 1); !errors.Is(err, entity.ErrChangesetNotFound) {
+		t.Errorf("second Discard() error = %v, want %v", err, entity.ErrChangesetNotFound)
+	}
+}
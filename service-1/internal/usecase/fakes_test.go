@@ -0,0 +1,458 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+)
+
+// fakeGoods records imports and moves. Methods the tests do not override panic
+// through the nil embedded interface.
+type fakeGoods struct {
+	postgres.GoodRepository
+
+	// existing maps names to the ids of goods already in the project
+	existing map[string]int
+	imports  [][]entity.ImportRow
+	opts     []entity.ImportOptions
+	// goods are the goods Get, List, Delete and Move can find, by id
+	goods map[int]entity.Good
+	moved []int
+	// reads counts the calls to Get and List
+	reads int
+	// listed are the filters List was called with
+	listed []entity.ListFilter
+	// categories maps category ids to their project
+	categories map[int]int
+}
+
+func (f *fakeGoods) Get(_ context.Context, id int) (entity.Good, error) {
+	f.reads++
+
+	return f.goods[id], nil
+}
+
+// List returns a page of the goods of the project, or of every project,
+// and of filter.ProjectIDs by id, ignoring the other filters.
+func (f *fakeGoods) List(_ context.Context, filter entity.ListFilter) ([]entity.Good, error) {
+	f.reads++
+	f.listed = append(f.listed, filter)
+
+	projects := make(map[int]bool, len(filter.ProjectIDs))
+	for _, id := range filter.ProjectIDs {
+		projects[id] = true
+	}
+
+	var goods []entity.Good
+	for _, good := range f.goods {
+		if good.Removed || filter.ProjectID != 0 && good.ProjectID != filter.ProjectID {
+			continue
+		}
+
+		if filter.ProjectIDs != nil && !projects[good.ProjectID] {
+			continue
+		}
+
+		goods = append(goods, good)
+	}
+
+	sort.Slice(goods, func(i, j int) bool { return goods[i].ID < goods[j].ID })
+
+	if filter.Limit > 0 && len(goods) > filter.Limit {
+		goods = goods[:filter.Limit]
+	}
+
+	return goods, nil
+}
+
+// bulkUpdate applies change to each good of the project, failing as the
+// repository does when one belongs elsewhere.
+func (f *fakeGoods) bulkUpdate(projectID int, ids []int, change func(*entity.Good)) ([]entity.Good, error) {
+	for _, id := range ids {
+		if good, ok := f.goods[id]; !ok || good.ProjectID != projectID {
+			return nil, fmt.Errorf("good with id #%d %w", id, entity.ErrGoodNotFound)
+		}
+	}
+
+	updated := make([]entity.Good, 0, len(ids))
+
+	for _, id := range ids {
+		good := f.goods[id]
+		change(&good)
+		f.goods[id] = good
+
+		updated = append(updated, good)
+	}
+
+	return updated, nil
+}
+
+// Retag removes tags case-insensitively before adding the missing ones.
+func (f *fakeGoods) Retag(_ context.Context, projectID int, ids []int, add, remove []string) ([]entity.Good, error) {
+	return f.bulkUpdate(projectID, ids, func(good *entity.Good) {
+		removed := make(map[string]bool, len(remove))
+		for _, tag := range remove {
+			removed[strings.ToLower(tag)] = true
+		}
+
+		tags := make([]string, 0, len(good.Tags)+len(add))
+		for _, tag := range good.Tags {
+			if !removed[strings.ToLower(tag)] {
+				tags = append(tags, tag)
+			}
+		}
+
+		good.Tags = entity.NormalizeTags(append(tags, add...))
+	})
+}
+
+// Recategorize accepts the categories of the project only.
+func (f *fakeGoods) Recategorize(_ context.Context, projectID int, ids []int, categoryID int) ([]entity.Good, error) {
+	var category *int
+
+	if categoryID != 0 {
+		if f.categories[categoryID] != projectID {
+			return nil, fmt.Errorf("category #%d %w", categoryID, entity.ErrCategoryNotFound)
+		}
+
+		category = &categoryID
+	}
+
+	return f.bulkUpdate(projectID, ids, func(good *entity.Good) {
+		good.CategoryID = category
+	})
+}
+
+func (f *fakeGoods) Delete(_ context.Context, id, projectID int) (entity.Good, error) {
+	good, ok := f.goods[id]
+	if !ok || good.ProjectID != projectID {
+		return entity.Good{}, fmt.Errorf("good with id #%d %w", id, entity.ErrGoodNotFound)
+	}
+
+	good.Removed = true
+	f.goods[id] = good
+
+	return good, nil
+}
+
+// Move checks each good of the project and records the ids it moved.
+func (f *fakeGoods) Move(ctx context.Context, projectID int, ids []int, targetID int, _ *int, check postgres.GoodCheck) (entity.GoodsMove, error) {
+	move := entity.GoodsMove{FromProjectID: projectID, ToProjectID: targetID, Shifted: []int{}}
+
+	for _, id := range ids {
+		good, ok := f.goods[id]
+		if !ok || good.ProjectID != projectID {
+			return entity.GoodsMove{}, fmt.Errorf("good with id #%d %w", id, entity.ErrGoodNotFound)
+		}
+
+		if err := check(ctx, good); err != nil {
+			return entity.GoodsMove{}, err
+		}
+
+		good.ProjectID = targetID
+		move.Goods = append(move.Goods, good)
+	}
+
+	f.moved = append(f.moved, ids...)
+
+	return move, nil
+}
+
+func (f *fakeGoods) Import(_ context.Context, projectID int, rows []entity.ImportRow, opts entity.ImportOptions, uniqueNames bool) ([]entity.ImportOutcome, error) {
+	f.imports = append(f.imports, append([]entity.ImportRow(nil), rows...))
+	f.opts = append(f.opts, opts)
+
+	outcomes := make([]entity.ImportOutcome, 0, len(rows))
+
+	for _, row := range rows {
+		outcome := entity.ImportOutcome{Row: row.Row, Good: entity.Good{ProjectID: projectID, Name: row.Name}}
+
+		id, exists := f.existing[row.Name]
+
+		switch {
+		case exists && opts.Upsert:
+			outcome.Good.ID = id
+			outcome.Updated = true
+		case exists && uniqueNames:
+			outcome.Err = entity.ErrGoodNameTaken
+		}
+
+		outcomes = append(outcomes, outcome)
+	}
+
+	return outcomes, nil
+}
+
+func (f *fakeGoods) NameExists(_ context.Context, _ int, name string, excludeID int) (bool, error) {
+	id, exists := f.existing[name]
+
+	return exists && id != excludeID, nil
+}
+
+// fakeChangesets holds one draft and applies it by running the check on
+// each change, as the repository does before writing it.
+type fakeChangesets struct {
+	postgres.ChangesetRepository
+
+	changes   []entity.Change
+	staged    []entity.Change
+	applied   []entity.Change
+	published bool
+	discarded bool
+}
+
+func (f *fakeChangesets) Stage(_ context.Context, _, _ int, change entity.Change) (entity.Change, error) {
+	f.staged = append(f.staged, change)
+
+	return change, nil
+}
+
+func (f *fakeChangesets) apply(ctx context.Context, projectID int, check postgres.ChangeCheck) ([]entity.Good, error) {
+	var (
+		applied []entity.Change
+		goods   []entity.Good
+	)
+
+	for _, change := range f.changes {
+		if err := check(ctx, &change); err != nil {
+			return nil, err
+		}
+
+		applied = append(applied, change)
+
+		good := entity.Good{ProjectID: projectID}
+		if change.GoodID != nil {
+			good.ID = *change.GoodID
+		}
+		if change.Input != nil {
+			good.Name = change.Input.Name
+		}
+
+		goods = append(goods, good)
+	}
+
+	f.applied = applied
+
+	return goods, nil
+}
+
+func (f *fakeChangesets) Preview(ctx context.Context, _, projectID, _, _ int, check postgres.ChangeCheck) ([]entity.Good, []int, error) {
+	goods, err := f.apply(ctx, projectID, check)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]int, 0, len(goods))
+	for _, good := range goods {
+		ids = append(ids, good.ID)
+	}
+
+	return goods, ids, nil
+}
+
+func (f *fakeChangesets) Publish(ctx context.Context, _, projectID int, check postgres.ChangeCheck) ([]entity.Good, error) {
+	goods, err := f.apply(ctx, projectID, check)
+	if err != nil {
+		return nil, err
+	}
+
+	f.published = true
+
+	return goods, nil
+}
+
+func (f *fakeChangesets) Discard(_ context.Context, id, _ int) error {
+	if f.published || f.discarded {
+		return fmt.Errorf("draft changeset #%d %w", id, entity.ErrChangesetNotFound)
+	}
+
+	f.discarded = true
+
+	return nil
+}
+
+// fakeProjects serves one attributes schema for every project; only
+// project 1 can be cloned.
+type fakeProjects struct {
+	postgres.ProjectRepository
+
+	schema  json.RawMessage
+	version int64
+	// loads counts how often the schema was read
+	loads *int
+	// clones collects the names projects were cloned with
+	clones *[]string
+}
+
+func (f fakeProjects) AttributesSchema(context.Context, int) (json.RawMessage, error) {
+	if f.loads != nil {
+		*f.loads++
+	}
+
+	return f.schema, nil
+}
+
+// Clone records the name and fails for a project that does not exist.
+func (f fakeProjects) Clone(_ context.Context, projectID int, name string) (entity.ProjectClone, error) {
+	if f.clones != nil {
+		*f.clones = append(*f.clones, name)
+	}
+
+	if projectID != 1 {
+		return entity.ProjectClone{}, fmt.Errorf("project #%d %w", projectID, entity.ErrProjectNotFound)
+	}
+
+	return entity.ProjectClone{Project: entity.Project{ID: 2, Name: name}, SourceID: projectID}, nil
+}
+
+func (f fakeProjects) AttributesSchemaVersion(context.Context, int) (int64, error) {
+	return f.version, nil
+}
+
+// fakeCache is an in-memory goods cache, kept apart per organization as
+// the Redis one is. Bump fails with bumpErr when it is set.
+type fakeCache struct {
+	generations map[int]int64
+	goods       map[string]entity.Good
+	pages       map[string][]entity.Good
+	bumps       []int
+	bumpErr     error
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{
+		generations: make(map[int]int64),
+		goods:       make(map[string]entity.Good),
+		pages:       make(map[string][]entity.Good),
+	}
+}
+
+func (f *fakeCache) Generation(_ context.Context, projectID int) (int64, error) {
+	return f.generations[projectID], nil
+}
+
+func (f *fakeCache) Bump(_ context.Context, projectIDs ...int) error {
+	if f.bumpErr != nil {
+		return f.bumpErr
+	}
+
+	for _, projectID := range projectIDs {
+		f.generations[projectID]++
+	}
+
+	f.bumps = append(f.bumps, projectIDs...)
+
+	return nil
+}
+
+func (f *fakeCache) GetGood(ctx context.Context, projectID int, generation int64, id int) (entity.Good, error) {
+	good, ok := f.goods[cacheKey(ctx, projectID, generation, id)]
+	if !ok {
+		return entity.Good{}, entity.ErrGoodNotFound
+	}
+
+	return good, nil
+}
+
+func (f *fakeCache) SetGood(ctx context.Context, good entity.Good, generation int64, _ time.Duration) error {
+	f.goods[cacheKey(ctx, good.ProjectID, generation, good.ID)] = good
+
+	return nil
+}
+
+func (f *fakeCache) GetPage(ctx context.Context, projectID int, generation int64, query string) ([]entity.Good, error) {
+	goods, ok := f.pages[cacheKey(ctx, projectID, generation, query)]
+	if !ok {
+		return nil, entity.ErrGoodNotFound
+	}
+
+	return goods, nil
+}
+
+func (f *fakeCache) SetPage(ctx context.Context, projectID int, generation int64, query string, goods []entity.Good, _ time.Duration) error {
+	f.pages[cacheKey(ctx, projectID, generation, query)] = goods
+
+	return nil
+}
+
+func cacheKey(ctx context.Context, projectID int, generation int64, id any) string {
+	data, _ := json.Marshal([]any{entity.OrganizationFromContext(ctx), projectID, generation, id})
+
+	return string(data)
+}
+
+// fakeOrganizations records the names organizations were created with and
+// lists the projects of each organization.
+type fakeOrganizations struct {
+	postgres.OrganizationRepository
+
+	created  *[]string
+	projects map[int][]int
+}
+
+func (f fakeOrganizations) Projects(_ context.Context, organizationID int) ([]int, error) {
+	return f.projects[organizationID], nil
+}
+
+func (f fakeOrganizations) Create(_ context.Context, name string) (entity.Organization, error) {
+	*f.created = append(*f.created, name)
+
+	return entity.Organization{ID: len(*f.created), Name: name}, nil
+}
+
+// fakeAudit keeps the filter of the last List.
+type fakeAudit struct {
+	filter *entity.AuditFilter
+}
+
+func (f *fakeAudit) List(_ context.Context, filter entity.AuditFilter) ([]entity.AuditEntry, int, error) {
+	f.filter = &filter
+
+	return []entity.AuditEntry{}, 0, nil
+}
+
+// fakeAPIKeys stores keys by the hash they were created with.
+type fakeAPIKeys struct {
+	postgres.APIKeyRepository
+
+	byHash map[string]entity.APIKey
+}
+
+func (f *fakeAPIKeys) Create(_ context.Context, key entity.APIKey, hash string) (entity.APIKey, error) {
+	key.ID = len(f.byHash) + 1
+	key.OrganizationID = 1
+	f.byHash[hash] = key
+
+	return key, nil
+}
+
+func (f *fakeAPIKeys) GetByHash(_ context.Context, hash string) (entity.APIKey, error) {
+	key, ok := f.byHash[hash]
+	if !ok {
+		return entity.APIKey{}, entity.ErrAPIKeyNotFound
+	}
+
+	return key, nil
+}
+
+func (f *fakeAPIKeys) Revoke(_ context.Context, id int, at time.Time) (entity.APIKey, error) {
+	for hash, key := range f.byHash {
+		if key.ID == id {
+			if key.RevokedAt == nil {
+				key.RevokedAt = &at
+			}
+
+			f.byHash[hash] = key
+
+			return key, nil
+		}
+	}
+
+	return entity.APIKey{}, entity.ErrAPIKeyNotFound
+}
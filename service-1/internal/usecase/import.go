@@ -0,0 +1,310 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/skantay/hezzl/internal/entity"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultImportChunk = 500
+	maxImportChunk     = 5000
+	// maxImportLine bounds a single NDJSON line
+	maxImportLine = 1 << 20
+)
+
+// ImportSource yields rows one at a time. Next returns io.EOF at the end and
+// an *entity.RowError for a row it could not parse; any other error stops
+// the import.
+type ImportSource interface {
+	Next() (entity.ImportRow, error)
+}
+
+// importFields are the columns an import may carry.
+var importFields = []string{"name", "description", "priority", "attributes"}
+
+type csvSource struct {
+	r       *csv.Reader
+	columns map[string]int
+	row     int
+}
+
+// NewCSVSource reads CSV whose first record is a header. mapping renames
+// columns, from field (name, description, priority, attributes) to header;
+// unmapped
+// fields are looked up by their own name, case-insensitively.
+func NewCSVSource(r io.Reader, mapping map[string]string) (ImportSource, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, entity.NewValidationError(entity.FieldError{Field: "header", Rule: "required"})
+	}
+
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))] = i
+	}
+
+	columns := make(map[string]int)
+
+	for _, field := range importFields {
+		column := field
+		if mapped, ok := mapping[field]; ok {
+			column = mapped
+		}
+
+		if i, ok := index[strings.ToLower(column)]; ok {
+			columns[field] = i
+		}
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, entity.NewValidationError(entity.FieldError{Field: "header", Rule: "required", Param: "name"})
+	}
+
+	return &csvSource{r: reader, columns: columns}, nil
+}
+
+func (s *csvSource) Next() (entity.ImportRow, error) {
+	record, err := s.r.Read()
+	if errors.Is(err, io.EOF) {
+		return entity.ImportRow{}, io.EOF
+	}
+
+	s.row++
+
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return entity.ImportRow{}, &entity.RowError{Row: s.row, Err: entity.ErrMalformedBody}
+		}
+
+		return entity.ImportRow{}, fmt.Errorf("trouble reading csv: %w", err)
+	}
+
+	value := func(field string) (string, bool) {
+		i, ok := s.columns[field]
+		if !ok || i >= len(record) {
+			return "", false
+		}
+
+		return record[i], true
+	}
+
+	row := entity.ImportRow{Row: s.row}
+	row.Name, _ = value("name")
+
+	if desc, ok := value("description"); ok {
+		row.Description = &desc
+	}
+
+	if raw, ok := value("priority"); ok && strings.TrimSpace(raw) != "" {
+		priority, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return entity.ImportRow{}, &entity.RowError{Row: s.row, Err: entity.NewValidationError(
+				entity.FieldError{Field: "priority", Rule: "integer"})}
+		}
+
+		row.Priority = &priority
+	}
+
+	if raw, ok := value("attributes"); ok && strings.TrimSpace(raw) != "" {
+		row.Attributes = json.RawMessage(raw)
+	}
+
+	return row, nil
+}
+
+type ndjsonSource struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+// NewNDJSONSource reads one JSON object per line with name, description,
+// priority and attributes keys. Blank lines are skipped.
+func NewNDJSONSource(r io.Reader) ImportSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxImportLine)
+
+	return &ndjsonSource{scanner: scanner}
+}
+
+func (s *ndjsonSource) Next() (entity.ImportRow, error) {
+	for s.scanner.Scan() {
+		line := strings.TrimSpace(s.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		s.row++
+
+		var record struct {
+			Name        string          `json:"name"`
+			Description *string         `json:"description"`
+			Priority    *int            `json:"priority"`
+			Attributes  json.RawMessage `json:"attributes"`
+		}
+
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return entity.ImportRow{}, &entity.RowError{Row: s.row, Err: entity.ErrMalformedBody}
+		}
+
+		// An explicit null means no attributes, as an absent key does
+		if string(record.Attributes) == "null" {
+			record.Attributes = nil
+		}
+
+		return entity.ImportRow{
+			Row:         s.row,
+			Name:        record.Name,
+			Description: record.Description,
+			Priority:    record.Priority,
+			Attributes:  record.Attributes,
+		}, nil
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return entity.ImportRow{}, fmt.Errorf("trouble reading ndjson: %w", err)
+	}
+
+	return entity.ImportRow{}, io.EOF
+}
+
+// Import validates every row with the create rules and writes the valid ones
+// in chunked transactions. Rejected rows are listed in the report; only
+// errors that stop the whole import are returned.
+func (g goodUsecase) Import(ctx context.Context, projectID int, src ImportSource, opts entity.ImportOptions) (entity.ImportReport, error) {
+	ctx, span := tracer.Start(ctx, "goodUsecase.Import", trace.WithAttributes(
+		attribute.Int("project.id", projectID),
+		attribute.Bool("import.upsert", opts.Upsert),
+		attribute.Bool("import.dry_run", opts.DryRun)))
+	defer span.End()
+
+	if opts.ChunkSize == 0 {
+		opts.ChunkSize = defaultImportChunk
+	}
+
+	report := entity.ImportReport{DryRun: opts.DryRun, Errors: []entity.ImportRowError{}}
+
+	if opts.ChunkSize < 1 || opts.ChunkSize > maxImportChunk {
+		return report, entity.NewQueryError(entity.FieldError{Field: "chunk", Rule: "range", Param: fmt.Sprintf("1-%d", maxImportChunk)})
+	}
+
+	chunk := make([]entity.ImportRow, 0, opts.ChunkSize)
+
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		outcomes, err := g.repo.Import(ctx, projectID, chunk, opts, g.rules.UniqueNames)
+		if err != nil {
+			return fmt.Errorf("trouble importing goods: %w", err)
+		}
+
+		changed := false
+
+		for _, outcome := range outcomes {
+			switch {
+			case outcome.Err != nil:
+				report.Reject(outcome.Row, outcome.Err)
+			case outcome.Updated:
+				report.Updated++
+				changed = true
+			default:
+				report.Created++
+				changed = true
+			}
+		}
+
+		chunk = chunk[:0]
+
+		if changed && !opts.DryRun {
+			forgetProjects(ctx, g.cache, g.log, projectID)
+		}
+
+		return nil
+	}
+
+	for {
+		row, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var rowErr *entity.RowError
+		if errors.As(err, &rowErr) {
+			report.Total++
+			report.Reject(rowErr.Row, rowErr.Err)
+
+			continue
+		}
+
+		if err != nil {
+			return report, err
+		}
+
+		report.Total++
+
+		if err := g.validateImportRow(ctx, projectID, &row); err != nil {
+			report.Reject(row.Row, err)
+
+			continue
+		}
+
+		chunk = append(chunk, row)
+
+		if len(chunk) == opts.ChunkSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// validateImportRow applies the create rules and normalizes the row. Name
+// uniqueness is left to the repository, which knows whether an upsert
+// updates the good holding the name.
+func (g goodUsecase) validateImportRow(ctx context.Context, projectID int, row *entity.ImportRow) error {
+	row.Name = entity.NormalizeName(row.Name)
+
+	if fields := entity.ValidateName(row.Name); len(fields) != 0 {
+		return entity.NewValidationError(fields...)
+	}
+
+	if row.Priority != nil {
+		if fields := entity.ValidatePriority(*row.Priority); len(fields) != 0 {
+			fields[0].Field = "priority"
+
+			return entity.NewValidationError(fields...)
+		}
+	}
+
+	input := entity.GoodInput{
+		Name:        row.Name,
+		Description: row.Description,
+		Attributes:  row.Attributes,
+	}
+
+	return g.checkInput(ctx, projectID, 0, &input)
+}
@@ -0,0 +1,251 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skantay/hezzl/internal/entity"
+
+	"go.uber.org/zap"
+)
+
+// readAll drains src, keeping row errors in place of their rows.
+func readAll(t *testing.T, src ImportSource) ([]entity.ImportRow, []error) {
+	t.Helper()
+
+	var (
+		rows []entity.ImportRow
+		errs []error
+	)
+
+	for {
+		row, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			return rows, errs
+		}
+
+		var rowErr *entity.RowError
+		if err != nil && !errors.As(err, &rowErr) {
+			t.Fatalf("Next: %v", err)
+		}
+
+		rows = append(rows, row)
+		errs = append(errs, err)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestCSVSource(t *testing.T) {
+	input := "\ufeffTitle,Priority,Details,attributes\n" +
+		"Apple,3,Red,\"{\"\"color\"\":\"\"red\"\"}\"\n" +
+		"Pear,,,\n" +
+		"Plum,high,x,\n" +
+		"Short\n"
+
+	src, err := NewCSVSource(strings.NewReader(input), map[string]string{"name": "title", "description": "Details"})
+	if err != nil {
+		t.Fatalf("NewCSVSource: %v", err)
+	}
+
+	rows, errs := readAll(t, src)
+
+	want := []entity.ImportRow{
+		{Row: 1, Name: "Apple", Description: ptr("Red"), Priority: ptr(3), Attributes: json.RawMessage(`{"color":"red"}`)},
+		{Row: 2, Name: "Pear", Description: ptr("")},
+		{},
+		{Row: 4, Name: "Short"},
+	}
+
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %+v, want %+v", rows, want)
+	}
+
+	var rowErr *entity.RowError
+	if !errors.As(errs[2], &rowErr) || rowErr.Row != 3 {
+		t.Errorf("row 3 error = %v, want a RowError for row 3", errs[2])
+	}
+
+	for _, i := range []int{0, 1, 3} {
+		if errs[i] != nil {
+			t.Errorf("row %d error = %v", i+1, errs[i])
+		}
+	}
+}
+
+func TestCSVSourceRequiresNameColumn(t *testing.T) {
+	for name, input := range map[string]string{
+		"empty":    "",
+		"no name":  "description,priority\nx,1\n",
+		"unmapped": "title\nApple\n",
+	} {
+		if _, err := NewCSVSource(strings.NewReader(input), nil); !errors.Is(err, entity.ErrValidation) {
+			t.Errorf("%s: err = %v, want a validation error", name, err)
+		}
+	}
+}
+
+func TestNDJSONSource(t *testing.T) {
+	input := `{"name":"Apple","description":"Red","priority":2,"attributes":{"color":"red"}}
+
+{"name":"Pear","attributes":null}
+not json
+{"name":"Plum"}
+`
+
+	rows, errs := readAll(t, NewNDJSONSource(strings.NewReader(input)))
+
+	want := []entity.ImportRow{
+		{Row: 1, Name: "Apple", Description: ptr("Red"), Priority: ptr(2), Attributes: json.RawMessage(`{"color":"red"}`)},
+		{Row: 2, Name: "Pear"},
+		{},
+		{Row: 4, Name: "Plum"},
+	}
+
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %+v, want %+v", rows, want)
+	}
+
+	if !errors.Is(errs[2], entity.ErrMalformedBody) {
+		t.Errorf("row 3 error = %v, want %v", errs[2], entity.ErrMalformedBody)
+	}
+}
+
+// sliceSource yields fixed rows.
+type sliceSource []entity.ImportRow
+
+func (s *sliceSource) Next() (entity.ImportRow, error) {
+	if len(*s) == 0 {
+		return entity.ImportRow{}, io.EOF
+	}
+
+	row := (*s)[0]
+	*s = (*s)[1:]
+
+	return row, nil
+}
+
+func TestImport(t *testing.T) {
+	schema := json.RawMessage(`{"type":"object","properties":{"color":{"type":"string"}},"required":["color"]}`)
+
+	rows := []entity.ImportRow{
+		{Row: 1, Name: "  Apple  ", Attributes: json.RawMessage(`{"color":"red"}`)},
+		{Row: 2, Name: "Pear", Attributes: json.RawMessage(`{"color":1}`)},
+		{Row: 3, Name: "Plum"},
+		{Row: 4, Name: "Fig", Attributes: json.RawMessage(`[1]`), Priority: ptr(1)},
+		{Row: 5, Name: "", Attributes: json.RawMessage(`{"color":"green"}`)},
+		{Row: 6, Name: "Kiwi", Priority: ptr(-1), Attributes: json.RawMessage(`{"color":"green"}`)},
+		{Row: 7, Name: "Lime", Attributes: json.RawMessage(`{"color":"green"}`)},
+	}
+
+	tests := []struct {
+		name    string
+		opts    entity.ImportOptions
+		rules   Rules
+		report  entity.ImportReport
+		written []string
+		bumped  bool
+	}{
+		{
+			name:    "create",
+			opts:    entity.ImportOptions{ChunkSize: 1},
+			report:  entity.ImportReport{Total: 7, Created: 2, Failed: 5},
+			written: []string{"Apple", "Lime"},
+			bumped:  true,
+		},
+		{
+			name:    "dry run",
+			opts:    entity.ImportOptions{DryRun: true},
+			report:  entity.ImportReport{Total: 7, Created: 2, Failed: 5, DryRun: true},
+			written: []string{"Apple", "Lime"},
+		},
+		{
+			name:    "upsert",
+			opts:    entity.ImportOptions{Upsert: true},
+			report:  entity.ImportReport{Total: 7, Created: 1, Updated: 1, Failed: 5},
+			written: []string{"Apple", "Lime"},
+			bumped:  true,
+		},
+		{
+			name:    "taken name",
+			rules:   Rules{UniqueNames: true},
+			report:  entity.ImportReport{Total: 7, Created: 1, Failed: 6},
+			written: []string{"Apple", "Lime"},
+			bumped:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeGoods{existing: map[string]int{"Lime": 9}}
+			cache := newFakeCache()
+			uc := NewGoodUsecase(repo, fakeProjects{schema: schema}, nil, cache, tt.rules, zap.NewNop())
+
+			src := sliceSource(append([]entity.ImportRow(nil), rows...))
+
+			report, err := uc.Import(context.Background(), 1, &src, tt.opts)
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+
+			var failed []int
+			for _, rowErr := range report.Errors {
+				failed = append(failed, rowErr.Row)
+			}
+
+			report.Errors = nil
+			if !reflect.DeepEqual(report, tt.report) {
+				t.Errorf("report = %+v, want %+v", report, tt.report)
+			}
+
+			if len(failed) < 5 || !reflect.DeepEqual(failed[:5], []int{2, 3, 4, 5, 6}) {
+				t.Errorf("failed rows = %v, want 2 to 6 first", failed)
+			}
+
+			var written []string
+			for i, chunk := range repo.imports {
+				if opts := repo.opts[i]; opts.Upsert != tt.opts.Upsert || opts.DryRun != tt.opts.DryRun {
+					t.Errorf("options = %+v, want %+v", opts, tt.opts)
+				}
+
+				for _, row := range chunk {
+					written = append(written, row.Name)
+				}
+			}
+
+			if !reflect.DeepEqual(written, tt.written) {
+				t.Errorf("written = %v, want %v", written, tt.written)
+			}
+
+			if got := len(cache.bumps) != 0; got != tt.bumped {
+				t.Errorf("cache bumped = %v, want %v", got, tt.bumped)
+			}
+		})
+	}
+}
+
+func TestImportChunks(t *testing.T) {
+	repo := &fakeGoods{}
+	uc := NewGoodUsecase(repo, fakeProjects{}, nil, newFakeCache(), Rules{}, zap.NewNop())
+
+	src := sliceSource{{Row: 1, Name: "a"}, {Row: 2, Name: "b"}, {Row: 3, Name: "c"}}
+
+	if _, err := uc.Import(context.Background(), 1, &src, entity.ImportOptions{ChunkSize: 2}); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	if got := []int{len(repo.imports[0]), len(repo.imports[1])}; len(repo.imports) != 2 || !reflect.DeepEqual(got, []int{2, 1}) {
+		t.Errorf("chunks = %v, want [2 1]", repo.imports)
+	}
+
+	if _, err := uc.Import(context.Background(), 1, &src, entity.ImportOptions{ChunkSize: maxImportChunk + 1}); !errors.Is(err, entity.ErrInvalidQuery) {
+		t.Errorf("oversized chunk: err = %v, want %v", err, entity.ErrInvalidQuery)
+	}
+}
@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+	cache "github.com/skantay/hezzl/internal/repository/redis"
+
+	"go.uber.org/zap"
+)
+
+// OrganizationUsecase manages the tenants owning projects.
+type OrganizationUsecase interface {
+	Create(ctx context.Context, name string) (entity.Organization, error)
+	List(ctx context.Context) ([]entity.Organization, error)
+	AssignProject(ctx context.Context, projectID, organizationID int) error
+}
+
+type organizationUsecase struct {
+	repo  postgres.OrganizationRepository
+	cache cache.GoodCacheRepository
+	log   *zap.Logger
+}
+
+func NewOrganizationUsecase(repo postgres.OrganizationRepository, cache cache.GoodCacheRepository, log *zap.Logger) OrganizationUsecase {
+	return organizationUsecase{repo, cache, log}
+}
+
+func (o organizationUsecase) Create(ctx context.Context, name string) (entity.Organization, error) {
+	ctx, span := tracer.Start(ctx, "organizationUsecase.Create")
+	defer span.End()
+
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return entity.Organization{}, entity.NewValidationError(entity.FieldError{Field: "name", Rule: "required"})
+	}
+
+	if utf8.RuneCountInString(name) > entity.OrganizationNameMaxLength {
+		return entity.Organization{}, entity.NewValidationError(entity.FieldError{Field: "name", Rule: "max", Param: fmt.Sprint(entity.OrganizationNameMaxLength)})
+	}
+
+	organization, err := o.repo.Create(ctx, name)
+	if err != nil {
+		return entity.Organization{}, fmt.Errorf("trouble creating an organization: %w", err)
+	}
+
+	return organization, nil
+}
+
+func (o organizationUsecase) List(ctx context.Context) ([]entity.Organization, error) {
+	organizations, err := o.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("trouble listing organizations: %w", err)
+	}
+
+	return organizations, nil
+}
+
+// AssignProject hands a project and its goods to another organization.
+// Callers confined to the old one lose access on their next request.
+func (o organizationUsecase) AssignProject(ctx context.Context, projectID, organizationID int) error {
+	ctx, span := tracer.Start(ctx, "organizationUsecase.AssignProject")
+	defer span.End()
+
+	if err := o.repo.AssignProject(ctx, projectID, organizationID); err != nil {
+		return fmt.Errorf("trouble assigning project: %w", err)
+	}
+
+	forgetProjects(ctx, o.cache, o.log, projectID)
+
+	return nil
+}
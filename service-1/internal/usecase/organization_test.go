@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skantay/hezzl/internal/entity"
+
+	"go.uber.org/zap"
+)
+
+func TestOrganizationCreate(t *testing.T) {
+	longest := strings.Repeat("я", entity.OrganizationNameMaxLength)
+
+	tests := []struct {
+		name        string
+		orgName     string
+		wantErr     error
+		wantRule    string
+		wantCreated []string
+	}{
+		{name: "named", orgName: "Acme", wantCreated: []string{"Acme"}},
+		{name: "trimmed", orgName: "  Acme \t", wantCreated: []string{"Acme"}},
+		{name: "blank", orgName: " \t ", wantErr: entity.ErrValidation, wantRule: "required"},
+		{name: "longest counted in characters", orgName: longest, wantCreated: []string{longest}},
+		{name: "too long", orgName: strings.Repeat("a", entity.OrganizationNameMaxLength+1), wantErr: entity.ErrValidation, wantRule: "max"},
+		{name: "too long in characters", orgName: longest + "я", wantErr: entity.ErrValidation, wantRule: "max"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created []string
+
+			uc := NewOrganizationUsecase(fakeOrganizations{created: &created}, newFakeCache(), zap.NewNop())
+
+			organization, err := uc.Create(context.Background(), tt.orgName)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(created, tt.wantCreated) {
+				t.Errorf("repository created %q, want %q", created, tt.wantCreated)
+			}
+
+			if tt.wantErr == nil {
+				if organization.ID == 0 || organization.Name != tt.wantCreated[0] {
+					t.Errorf("Create() = %+v, want the created organization", organization)
+				}
+
+				return
+			}
+
+			var validation *entity.ValidationError
+			if !errors.As(err, &validation) || len(validation.Fields) != 1 || validation.Fields[0].Rule != tt.wantRule {
+				t.Errorf("Create() error = %v, want one %q field", err, tt.wantRule)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+	"github.com/skantay/hezzl/pkg/jsonschema"
+)
+
+// schemaMaxSize caps the size of an attributes schema document.
+const schemaMaxSize = 64 << 10
+
+// ProjectUsecase manages per-project settings.
+type ProjectUsecase interface {
+	AttributesSchema(ctx context.Context, projectID int) (json.RawMessage, error)
+	SetAttributesSchema(ctx context.Context, projectID int, schema json.RawMessage) error
+	SearchLanguage(ctx context.Context, projectID int) (string, error)
+	SetSearchLanguage(ctx context.Context, projectID int, language string) error
+	Clone(ctx context.Context, projectID int, name string) (entity.ProjectClone, error)
+}
+
+type projectUsecase struct {
+	repo postgres.ProjectRepository
+}
+
+func NewProjectUsecase(repo postgres.ProjectRepository) ProjectUsecase {
+	return projectUsecase{repo}
+}
+
+func (p projectUsecase) AttributesSchema(ctx context.Context, projectID int) (json.RawMessage, error) {
+	schema, err := p.repo.AttributesSchema(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("trouble getting attributes schema: %w", err)
+	}
+
+	return schema, nil
+}
+
+// SetAttributesSchema stores a schema after checking that it compiles; a
+// nil schema removes it.
+func (p projectUsecase) SetAttributesSchema(ctx context.Context, projectID int, schema json.RawMessage) error {
+	ctx, span := tracer.Start(ctx, "projectUsecase.SetAttributesSchema")
+	defer span.End()
+
+	if schema != nil {
+		if len(schema) > schemaMaxSize {
+			return entity.NewValidationError(entity.FieldError{Field: "schema", Rule: "max", Param: fmt.Sprint(schemaMaxSize)})
+		}
+
+		if _, err := jsonschema.Compile(schema); err != nil {
+			return entity.NewValidationError(entity.FieldError{Field: "schema", Rule: "jsonschema", Param: err.Error()})
+		}
+	}
+
+	if err := p.repo.SetAttributesSchema(ctx, projectID, schema); err != nil {
+		return fmt.Errorf("trouble setting attributes schema: %w", err)
+	}
+
+	return nil
+}
+
+func (p projectUsecase) SearchLanguage(ctx context.Context, projectID int) (string, error) {
+	language, err := p.repo.SearchLanguage(ctx, projectID)
+	if err != nil {
+		return "", fmt.Errorf("trouble getting search language: %w", err)
+	}
+
+	return language, nil
+}
+
+// SetSearchLanguage switches the project to one of the database's text
+// search configurations, such as "russian" or "simple".
+func (p projectUsecase) SetSearchLanguage(ctx context.Context, projectID int, language string) error {
+	ctx, span := tracer.Start(ctx, "projectUsecase.SetSearchLanguage")
+	defer span.End()
+
+	languages, err := p.repo.SearchLanguages(ctx)
+	if err != nil {
+		return fmt.Errorf("trouble listing search languages: %w", err)
+	}
+
+	language = strings.ToLower(strings.TrimSpace(language))
+
+	known := false
+	for _, l := range languages {
+		known = known || l == language
+	}
+
+	if !known {
+		return entity.NewValidationError(entity.FieldError{Field: "language", Rule: "oneof", Param: strings.Join(languages, " ")})
+	}
+
+	if err := p.repo.SetSearchLanguage(ctx, projectID, language); err != nil {
+		return fmt.Errorf("trouble setting search language: %w", err)
+	}
+
+	return nil
+}
+
+// Clone copies the project with its non-removed goods into a new project.
+// An empty name derives one from the source project.
+func (p projectUsecase) Clone(ctx context.Context, projectID int, name string) (entity.ProjectClone, error) {
+	ctx, span := tracer.Start(ctx, "projectUsecase.Clone")
+	defer span.End()
+
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > entity.ProjectNameMaxLength {
+		return entity.ProjectClone{}, entity.NewValidationError(entity.FieldError{Field: "name", Rule: "max", Param: fmt.Sprint(entity.ProjectNameMaxLength)})
+	}
+
+	clone, err := p.repo.Clone(ctx, projectID, name)
+	if err != nil {
+		return entity.ProjectClone{}, fmt.Errorf("trouble cloning project: %w", err)
+	}
+
+	return clone, nil
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skantay/hezzl/internal/entity"
+)
+
+func TestProjectClone(t *testing.T) {
+	tests := []struct {
+		name       string
+		projectID  int
+		cloneName  string
+		wantErr    error
+		wantCloned []string
+	}{
+		{name: "named", projectID: 1, cloneName: "Spring catalogue", wantCloned: []string{"Spring catalogue"}},
+		{name: "trimmed", projectID: 1, cloneName: "  Spring  ", wantCloned: []string{"Spring"}},
+		{name: "derived", projectID: 1, cloneName: "", wantCloned: []string{""}},
+		{name: "blank is derived", projectID: 1, cloneName: " \t ", wantCloned: []string{""}},
+		{name: "longest", projectID: 1, cloneName: strings.Repeat("a", entity.ProjectNameMaxLength), wantCloned: []string{strings.Repeat("a", entity.ProjectNameMaxLength)}},
+		{
+			name:       "longest counted in characters",
+			projectID:  1,
+			cloneName:  strings.Repeat("я", entity.ProjectNameMaxLength),
+			wantCloned: []string{strings.Repeat("я", entity.ProjectNameMaxLength)},
+		},
+		{name: "too long", projectID: 1, cloneName: strings.Repeat("a", entity.ProjectNameMaxLength+1), wantErr: entity.ErrValidation},
+		{name: "too long after trimming spaces", projectID: 1, cloneName: " " + strings.Repeat("я", entity.ProjectNameMaxLength+1) + " ", wantErr: entity.ErrValidation},
+		{name: "missing project", projectID: 7, cloneName: "copy", wantErr: entity.ErrProjectNotFound, wantCloned: []string{"copy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cloned []string
+
+			clone, err := NewProjectUsecase(fakeProjects{clones: &cloned}).Clone(context.Background(), tt.projectID, tt.cloneName)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Clone() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(cloned, tt.wantCloned) {
+				t.Errorf("repository cloned with %q, want %q", cloned, tt.wantCloned)
+			}
+
+			if tt.wantErr == nil && (clone.SourceID != tt.projectID || clone.Project.ID == 0) {
+				t.Errorf("Clone() = %+v, want a new project copied from #%d", clone, tt.projectID)
+			}
+
+			var validation *entity.ValidationError
+			if errors.As(err, &validation) && (len(validation.Fields) != 1 || validation.Fields[0].Field != "name" || validation.Fields[0].Rule != "max") {
+				t.Errorf("Clone() fields = %+v, want name max", validation.Fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+)
+
+// revisionsMaxLimit caps a single page of revisions.
+const revisionsMaxLimit = 100
+
+// RevisionUsecase reads the revision history of goods. Rolling back is a
+// change to the good, see GoodUsecase.Rollback.
+type RevisionUsecase interface {
+	List(ctx context.Context, goodID, projectID, limit, offset int) ([]entity.Revision, int, error)
+	Diff(ctx context.Context, goodID, projectID, from, to int) (entity.RevisionDiff, error)
+}
+
+type revisionUsecase struct {
+	repo postgres.RevisionRepository
+}
+
+func NewRevisionUsecase(repo postgres.RevisionRepository) RevisionUsecase {
+	return revisionUsecase{repo}
+}
+
+func (r revisionUsecase) List(ctx context.Context, goodID, projectID, limit, offset int) ([]entity.Revision, int, error) {
+	var fields []entity.FieldError
+
+	if limit < 1 || limit > revisionsMaxLimit {
+		fields = append(fields, entity.FieldError{Field: "limit", Rule: "range", Param: fmt.Sprintf("1-%d", revisionsMaxLimit)})
+	}
+
+	if offset < 0 {
+		fields = append(fields, entity.FieldError{Field: "offset", Rule: "min", Param: "0"})
+	}
+
+	if len(fields) != 0 {
+		return nil, 0, entity.NewQueryError(fields...)
+	}
+
+	revisions, total, err := r.repo.List(ctx, goodID, projectID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("trouble listing revisions: %w", err)
+	}
+
+	return revisions, total, nil
+}
+
+// Diff compares any two revisions of a good; from may be the later one.
+func (r revisionUsecase) Diff(ctx context.Context, goodID, projectID, from, to int) (entity.RevisionDiff, error) {
+	ctx, span := tracer.Start(ctx, "revisionUsecase.Diff")
+	defer span.End()
+
+	var fields []entity.FieldError
+
+	if from < 1 {
+		fields = append(fields, entity.FieldError{Field: "from", Rule: "min", Param: "1"})
+	}
+
+	if to < 1 {
+		fields = append(fields, entity.FieldError{Field: "to", Rule: "min", Param: "1"})
+	}
+
+	if len(fields) != 0 {
+		return entity.RevisionDiff{}, entity.NewQueryError(fields...)
+	}
+
+	older, err := r.repo.Get(ctx, goodID, projectID, from)
+	if err != nil {
+		return entity.RevisionDiff{}, fmt.Errorf("trouble getting a revision: %w", err)
+	}
+
+	newer, err := r.repo.Get(ctx, goodID, projectID, to)
+	if err != nil {
+		return entity.RevisionDiff{}, fmt.Errorf("trouble getting a revision: %w", err)
+	}
+
+	changes, err := entity.DiffGoods(older.Good, newer.Good)
+	if err != nil {
+		return entity.RevisionDiff{}, fmt.Errorf("trouble comparing revisions: %w", err)
+	}
+
+	return entity.RevisionDiff{GoodID: goodID, From: from, To: to, Changes: changes}, nil
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+	cache "github.com/skantay/hezzl/internal/repository/redis"
+
+	"go.uber.org/zap"
+)
+
+// scheduleActor is recorded as the author of scheduler events.
+const scheduleActor = "scheduler"
+
+// ScheduleUsecase emits publish and expire events as the visibility windows
+// of goods open and close.
+type ScheduleUsecase interface {
+	Run(ctx context.Context)
+}
+
+type scheduleUsecase struct {
+	repo     postgres.GoodRepository
+	cache    cache.GoodCacheRepository
+	interval time.Duration
+	log      *zap.Logger
+}
+
+func NewScheduleUsecase(repo postgres.GoodRepository, cache cache.GoodCacheRepository, interval time.Duration, log *zap.Logger) ScheduleUsecase {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	return scheduleUsecase{
+		repo:     repo,
+		cache:    cache,
+		interval: interval,
+		log:      log,
+	}
+}
+
+func (s scheduleUsecase) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.announce(ctx); err != nil {
+				s.log.Error("Visibility schedule failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// announce emits the boundaries crossed since the last run and drops the
+// cache of the projects concerned.
+func (s scheduleUsecase) announce(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "scheduleUsecase.announce")
+	defer span.End()
+
+	ctx = entity.WithPrincipal(ctx, entity.Principal{Subject: scheduleActor})
+
+	goods, err := s.repo.Crossings(ctx)
+	if err != nil {
+		return fmt.Errorf("trouble finding visibility crossings: %w", err)
+	}
+
+	projectIDs := make([]int, 0, len(goods))
+	for _, good := range goods {
+		projectIDs = append(projectIDs, good.ProjectID)
+	}
+
+	forgetProjects(ctx, s.cache, s.log, projectIDs...)
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SearchOptions tune full-text search.
+type SearchOptions struct {
+	// Similarity is the trigram word similarity, from 0 to 1, at which a
+	// name matches a misspelled query
+	Similarity float64
+	// MaxLimit caps the page size; it defaults to DefaultSearchMaxLimit
+	MaxLimit int
+}
+
+const DefaultSearchMaxLimit = 100
+
+type SearchUsecase interface {
+	// Search returns a page of hits, the number of matches and the text
+	// search configuration used.
+	Search(ctx context.Context, query entity.SearchQuery) ([]entity.SearchHit, int, string, error)
+}
+
+type searchUsecase struct {
+	repo postgres.SearchRepository
+	opts SearchOptions
+}
+
+func NewSearchUsecase(repo postgres.SearchRepository, opts SearchOptions) SearchUsecase {
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = DefaultSearchMaxLimit
+	}
+
+	return searchUsecase{repo, opts}
+}
+
+func (s searchUsecase) Search(ctx context.Context, query entity.SearchQuery) ([]entity.SearchHit, int, string, error) {
+	ctx, span := tracer.Start(ctx, "searchUsecase.Search", trace.WithAttributes(attribute.Int("project.id", query.ProjectID)))
+	defer span.End()
+
+	query.Query = strings.TrimSpace(query.Query)
+
+	var fields []entity.FieldError
+
+	if query.Query == "" {
+		fields = append(fields, entity.FieldError{Field: "q", Rule: "required"})
+	} else if utf8.RuneCountInString(query.Query) > entity.SearchQueryMaxLength {
+		fields = append(fields, entity.FieldError{Field: "q", Rule: "max", Param: fmt.Sprint(entity.SearchQueryMaxLength)})
+	}
+
+	if query.Limit < 1 || query.Limit > s.opts.MaxLimit {
+		fields = append(fields, entity.FieldError{Field: "limit", Rule: "range", Param: fmt.Sprintf("1-%d", s.opts.MaxLimit)})
+	}
+
+	if query.Offset < 0 {
+		fields = append(fields, entity.FieldError{Field: "offset", Rule: "min", Param: "0"})
+	}
+
+	if len(fields) != 0 {
+		return nil, 0, "", entity.NewQueryError(fields...)
+	}
+
+	hits, total, language, err := s.repo.Search(ctx, query, s.opts.Similarity)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("trouble searching goods: %w", err)
+	}
+
+	for i := range hits {
+		hits[i].Highlight.Name = markHighlights(hits[i].Highlight.Name)
+		hits[i].Highlight.Description = markHighlights(hits[i].Highlight.Description)
+	}
+
+	return hits, total, language, nil
+}
+
+var highlightTags = strings.NewReplacer(postgres.HighlightStart, "<mark>", postgres.HighlightStop, "</mark>")
+
+// markHighlights escapes the text for HTML and then turns the database's
+// highlight delimiters into <mark> tags.
+func markHighlights(text string) string {
+	return highlightTags.Replace(html.EscapeString(text))
+}
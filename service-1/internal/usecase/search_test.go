@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+)
+
+type fakeSearch struct {
+	hits  []entity.SearchHit
+	total int
+	query *entity.SearchQuery
+}
+
+func (f fakeSearch) Search(ctx context.Context, query entity.SearchQuery, similarity float64) ([]entity.SearchHit, int, string, error) {
+	if f.query != nil {
+		*f.query = query
+	}
+
+	return f.hits, f.total, "english", nil
+}
+
+func TestMarkHighlights(t *testing.T) {
+	start, stop := postgres.HighlightStart, postgres.HighlightStop
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "empty", text: "", want: ""},
+		{name: "no match", text: "plain text", want: "plain text"},
+		{name: "match", text: "red " + start + "apple" + stop, want: "red <mark>apple</mark>"},
+		{name: "several matches", text: start + "a" + stop + " b " + start + "c" + stop, want: "<mark>a</mark> b <mark>c</mark>"},
+		{name: "escaped", text: `<b>"x" & 'y'</b>`, want: "&lt;b&gt;&#34;x&#34; &amp; &#39;y&#39;&lt;/b&gt;"},
+		{name: "escaped inside a match", text: start + "<script>" + stop, want: "<mark>&lt;script&gt;</mark>"},
+		{name: "literal tags stay escaped", text: "<mark>x</mark>", want: "&lt;mark&gt;x&lt;/mark&gt;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markHighlights(tt.text); got != tt.want {
+				t.Errorf("markHighlights(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     SearchOptions
+		query    entity.SearchQuery
+		wantErr  error
+		wantRule string
+	}{
+		{name: "default max limit", query: entity.SearchQuery{Query: "apple", Limit: 20}},
+		{name: "default max limit upper bound", query: entity.SearchQuery{Query: "apple", Limit: DefaultSearchMaxLimit}},
+		{name: "above default max limit", query: entity.SearchQuery{Query: "apple", Limit: DefaultSearchMaxLimit + 1}, wantErr: entity.ErrInvalidQuery, wantRule: "range"},
+		{name: "configured max limit", opts: SearchOptions{MaxLimit: 10}, query: entity.SearchQuery{Query: "apple", Limit: 11}, wantErr: entity.ErrInvalidQuery, wantRule: "range"},
+		{name: "zero limit", query: entity.SearchQuery{Query: "apple"}, wantErr: entity.ErrInvalidQuery, wantRule: "range"},
+		{name: "blank query", query: entity.SearchQuery{Query: "  ", Limit: 20}, wantErr: entity.ErrInvalidQuery, wantRule: "required"},
+		{name: "negative offset", query: entity.SearchQuery{Query: "apple", Limit: 20, Offset: -1}, wantErr: entity.ErrInvalidQuery, wantRule: "min"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewSearchUsecase(fakeSearch{}, tt.opts)
+
+			_, _, _, err := uc.Search(context.Background(), tt.query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Search() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil {
+				return
+			}
+
+			var validation *entity.ValidationError
+			if !errors.As(err, &validation) || len(validation.Fields) != 1 || validation.Fields[0].Rule != tt.wantRule {
+				t.Errorf("Search() error = %v, want one %q field", err, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestSearchReturnsTotalAndMarks(t *testing.T) {
+	var query entity.SearchQuery
+
+	repo := fakeSearch{
+		hits: []entity.SearchHit{{
+			Good:      entity.Good{ID: 1},
+			Highlight: entity.SearchHighlight{Name: postgres.HighlightStart + "a&b" + postgres.HighlightStop, Description: "<i>"},
+		}},
+		total: 42,
+		query: &query,
+	}
+
+	hits, total, language, err := NewSearchUsecase(repo, SearchOptions{}).Search(context.Background(), entity.SearchQuery{ProjectID: 1, Query: " apple ", Limit: 1})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	if total != 42 || language != "english" {
+		t.Errorf("Search() total, language = %d, %q, want 42, english", total, language)
+	}
+
+	want := entity.SearchHighlight{Name: "<mark>a&amp;b</mark>", Description: "&lt;i&gt;"}
+	if len(hits) != 1 || !reflect.DeepEqual(hits[0].Highlight, want) {
+		t.Errorf("Search() hits = %+v, want highlight %+v", hits, want)
+	}
+
+	if query.Query != "apple" {
+		t.Errorf("repository query = %q, want it trimmed", query.Query)
+	}
+}
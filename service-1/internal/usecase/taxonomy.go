@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+	cache "github.com/skantay/hezzl/internal/repository/redis"
+
+	"go.uber.org/zap"
+)
+
+// TaxonomyUsecase manages the category tree and tags of projects. Tags are
+// created on first use, so they are only listed here.
+type TaxonomyUsecase interface {
+	CreateCategory(ctx context.Context, projectID int, name string, parentID *int) (entity.Category, error)
+	Categories(ctx context.Context, projectID int) ([]entity.Category, error)
+	DeleteCategory(ctx context.Context, id, projectID int) error
+	Tags(ctx context.Context, projectID int) ([]entity.Tag, error)
+}
+
+type taxonomyUsecase struct {
+	repo  postgres.TaxonomyRepository
+	cache cache.GoodCacheRepository
+	log   *zap.Logger
+}
+
+func NewTaxonomyUsecase(repo postgres.TaxonomyRepository, cache cache.GoodCacheRepository, log *zap.Logger) TaxonomyUsecase {
+	return taxonomyUsecase{repo, cache, log}
+}
+
+func (t taxonomyUsecase) CreateCategory(ctx context.Context, projectID int, name string, parentID *int) (entity.Category, error) {
+	ctx, span := tracer.Start(ctx, "taxonomyUsecase.CreateCategory")
+	defer span.End()
+
+	name = strings.TrimSpace(name)
+
+	fields := entity.ValidateCategoryName(name)
+	if parentID != nil && *parentID < 1 {
+		fields = append(fields, entity.FieldError{Field: "parent_id", Rule: "min", Param: "1"})
+	}
+
+	if len(fields) != 0 {
+		return entity.Category{}, entity.NewValidationError(fields...)
+	}
+
+	category, err := t.repo.CreateCategory(ctx, entity.Category{
+		ProjectID: projectID,
+		ParentID:  parentID,
+		Name:      name,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return entity.Category{}, fmt.Errorf("trouble creating a category: %w", err)
+	}
+
+	return category, nil
+}
+
+func (t taxonomyUsecase) Categories(ctx context.Context, projectID int) ([]entity.Category, error) {
+	categories, err := t.repo.ListCategories(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("trouble listing categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+// DeleteCategory leaves the category's goods without one, so the project's
+// cache is dropped as well.
+func (t taxonomyUsecase) DeleteCategory(ctx context.Context, id, projectID int) error {
+	if err := t.repo.DeleteCategory(ctx, id, projectID); err != nil {
+		return fmt.Errorf("trouble deleting a category: %w", err)
+	}
+
+	forgetProjects(ctx, t.cache, t.log, projectID)
+
+	return nil
+}
+
+func (t taxonomyUsecase) Tags(ctx context.Context, projectID int) ([]entity.Tag, error) {
+	tags, err := t.repo.ListTags(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("trouble listing tags: %w", err)
+	}
+
+	return tags, nil
+}
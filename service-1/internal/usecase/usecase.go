@@ -2,149 +2,805 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"math"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/skantay/hezzl/internal/entity"
 	"github.com/skantay/hezzl/internal/repository/postgres"
 	cache "github.com/skantay/hezzl/internal/repository/redis"
+	"github.com/skantay/hezzl/pkg/jsonschema"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
+var tracer = otel.Tracer("github.com/skantay/hezzl/internal/usecase")
+
+// bulkMaxGoods caps how many goods one bulk change may touch.
+const bulkMaxGoods = 500
+
 type Service struct {
-	Good GoodUsecase
+	Good         GoodUsecase
+	APIKey       APIKeyUsecase
+	Audit        AuditUsecase
+	Auth         AuthUsecase
+	Webhook      WebhookUsecase
+	Taxonomy     TaxonomyUsecase
+	Project      ProjectUsecase
+	Search       SearchUsecase
+	Revision     RevisionUsecase
+	Changeset    ChangesetUsecase
+	Organization OrganizationUsecase
 }
 
-func NewService(good GoodUsecase) Service {
-	return Service{good}
+func NewService(good GoodUsecase, apiKey APIKeyUsecase, audit AuditUsecase, auth AuthUsecase, webhook WebhookUsecase, taxonomy TaxonomyUsecase, project ProjectUsecase, search SearchUsecase, revision RevisionUsecase, changeset ChangesetUsecase, organization OrganizationUsecase) Service {
+	return Service{good, apiKey, audit, auth, webhook, taxonomy, project, search, revision, changeset, organization}
 }
 
 type GoodUsecase interface {
-	Create(ctx context.Context, projectID int, name string) (entity.Good, error)
+	Create(ctx context.Context, projectID int, input entity.GoodInput) (entity.Good, error)
+	Get(ctx context.Context, id, projectID int) (entity.Good, error)
 	Delete(ctx context.Context, id, projectID int) (entity.Good, error)
-	Update(ctx context.Context, id, projectID int, name, desc string, emptyDesc bool) (entity.Good, error)
-	List(ctx context.Context, limit, offset int) ([]entity.Good, error)
+	Restore(ctx context.Context, id, projectID int) (entity.Good, error)
+	Update(ctx context.Context, id, projectID int, input entity.GoodInput) (entity.Good, error)
+	Validate(ctx context.Context, projectID, id int, input *entity.GoodInput) error
+	Rollback(ctx context.Context, id, projectID, revision int) (entity.Good, error)
+	List(ctx context.Context, filter entity.ListFilter) ([]entity.Good, error)
+	Retag(ctx context.Context, projectID int, ids []int, add, remove []string) ([]entity.Good, error)
+	Recategorize(ctx context.Context, projectID int, ids []int, categoryID int) ([]entity.Good, error)
 	Reprioritiize(ctx context.Context, priority, id, projectID int) ([]entity.Good, error)
+	Move(ctx context.Context, projectID int, ids []int, targetID int, priority *int) (entity.GoodsMove, error)
+	Import(ctx context.Context, projectID int, src ImportSource, opts entity.ImportOptions) (entity.ImportReport, error)
+	Export(ctx context.Context, projectID int, filter entity.ExportFilter, fn func(entity.Good) error) error
+}
+
+// Rules holds the optional domain rules that can be switched per deployment.
+type Rules struct {
+	// UniqueNames forbids two non-removed goods with the same name in a project
+	UniqueNames bool
 }
 
 type goodUsecase struct {
-	repo  postgres.GoodRepository
-	cache cache.GoodCacheRepository
+	repo      postgres.GoodRepository
+	projects  postgres.ProjectRepository
+	revisions postgres.RevisionRepository
+	cache     cache.GoodCacheRepository
+	rules     Rules
+	schemas   *schemaCache
+	log       *zap.Logger
 }
 
-func NewGoodUsecase(repo postgres.GoodRepository, cache cache.GoodCacheRepository) GoodUsecase {
+func NewGoodUsecase(repo postgres.GoodRepository, projects postgres.ProjectRepository, revisions postgres.RevisionRepository, cache cache.GoodCacheRepository, rules Rules, log *zap.Logger) GoodUsecase {
 	return goodUsecase{
-		repo:  repo,
-		cache: cache,
+		repo:      repo,
+		projects:  projects,
+		revisions: revisions,
+		cache:     cache,
+		rules:     rules,
+		schemas:   &schemaCache{schemas: make(map[int]compiledSchema)},
+		log:       log,
+	}
+}
+
+// checkName validates a normalized name and, when enabled, its uniqueness
+// within the project. excludeID is the good being renamed, if any.
+func (g goodUsecase) checkName(ctx context.Context, projectID int, name string, excludeID int) error {
+	if fields := entity.ValidateName(name); len(fields) != 0 {
+		return entity.NewValidationError(fields...)
+	}
+
+	if !g.rules.UniqueNames {
+		return nil
 	}
+
+	exists, err := g.repo.NameExists(ctx, projectID, name, excludeID)
+	if err != nil {
+		return fmt.Errorf("repository name exists error: %w", err)
+	}
+
+	if exists {
+		return entity.ErrGoodNameTaken
+	}
+
+	return nil
 }
 
-func (g goodUsecase) Create(ctx context.Context, projectID int, name string) (entity.Good, error) {
+// Validate normalizes input and checks it as Create (id 0) or Update of the
+// good would, without writing anything.
+func (g goodUsecase) Validate(ctx context.Context, projectID, id int, input *entity.GoodInput) error {
+	input.Name = entity.NormalizeName(input.Name)
+
+	if err := g.checkName(ctx, projectID, input.Name, id); err != nil {
+		return err
+	}
+
+	return g.checkInput(ctx, projectID, id, input)
+}
+
+// checkInput applies every rule of Validate but the name ones.
+func (g goodUsecase) checkInput(ctx context.Context, projectID, id int, input *entity.GoodInput) error {
+	if input.Description != nil {
+		if fields := entity.ValidateDescription(*input.Description); len(fields) != 0 {
+			return entity.NewValidationError(fields...)
+		}
+	}
+
+	if err := checkTaxonomy(input); err != nil {
+		return err
+	}
+
+	// A schema may require attributes, so their absence on create is checked too
+	if id == 0 && input.Attributes == nil {
+		input.Attributes = json.RawMessage("{}")
+	}
+
+	if err := g.checkAttributes(ctx, projectID, input.Attributes); err != nil {
+		return err
+	}
+
+	return checkVisibility(*input)
+}
+
+// checkTaxonomy normalizes and validates the tags of input, if any.
+func checkTaxonomy(input *entity.GoodInput) error {
+	var fields []entity.FieldError
+
+	if input.Tags != nil {
+		tags := entity.NormalizeTags(*input.Tags)
+		input.Tags = &tags
+
+		fields = append(fields, entity.ValidateTags(tags)...)
+	}
+
+	if input.CategoryID != nil && *input.CategoryID < 0 {
+		fields = append(fields, entity.FieldError{Field: "category_id", Rule: "min", Param: "0"})
+	}
+
+	if len(fields) != 0 {
+		return entity.NewValidationError(fields...)
+	}
+
+	return nil
+}
+
+// checkVisibility validates the visibility window of input, if any.
+func checkVisibility(input entity.GoodInput) error {
+	if input.Visibility == nil {
+		return nil
+	}
+
+	if fields := entity.ValidateVisibility(*input.Visibility); len(fields) != 0 {
+		return entity.NewValidationError(fields...)
+	}
+
+	return nil
+}
+
+// schemaCache keeps the compiled attributes schema of each project for as
+// long as its version stays the same.
+type schemaCache struct {
+	mu      sync.Mutex
+	schemas map[int]compiledSchema
+}
+
+type compiledSchema struct {
+	version int64
+	// schema is nil when the project has none
+	schema *jsonschema.Schema
+}
+
+// get returns the project's current schema, compiling it only when the
+// version changed since it was last seen.
+func (c *schemaCache) get(ctx context.Context, projects postgres.ProjectRepository, projectID int) (*jsonschema.Schema, error) {
+	version, err := projects.AttributesSchemaVersion(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("repository attributes schema version error: %w", err)
+	}
+
+	c.mu.Lock()
+	cached, ok := c.schemas[projectID]
+	c.mu.Unlock()
+
+	if ok && cached.version == version {
+		return cached.schema, nil
+	}
+
+	raw, err := projects.AttributesSchema(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("repository attributes schema error: %w", err)
+	}
+
+	var schema *jsonschema.Schema
+
+	if raw != nil {
+		if schema, err = jsonschema.Compile(raw); err != nil {
+			return nil, fmt.Errorf("project #%d attributes schema: %w", projectID, err)
+		}
+	}
+
+	c.mu.Lock()
+	c.schemas[projectID] = compiledSchema{version: version, schema: schema}
+	c.mu.Unlock()
+
+	return schema, nil
+}
+
+// checkAttributes validates attributes against the project's schema, if
+// the project has one. Nil attributes are left unchanged and not checked.
+func (g goodUsecase) checkAttributes(ctx context.Context, projectID int, attrs json.RawMessage) error {
+	if attrs == nil {
+		return nil
+	}
+
+	if fields := entity.ValidateAttributes(attrs); len(fields) != 0 {
+		return entity.NewValidationError(fields...)
+	}
+
+	schema, err := g.schemas.get(ctx, g.projects, projectID)
+	if err != nil {
+		return err
+	}
+
+	if schema == nil {
+		return nil
+	}
+
+	errs, err := schema.Validate(attrs)
+	if err != nil {
+		return entity.NewValidationError(entity.FieldError{Field: "attributes", Rule: "object"})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	fields := make([]entity.FieldError, 0, len(errs))
+	for _, e := range errs {
+		field := "attributes"
+		if e.Path != "" {
+			field += "." + e.Path
+		}
+
+		fields = append(fields, entity.FieldError{Field: field, Rule: e.Rule, Param: e.Param})
+	}
+
+	return entity.NewValidationError(fields...)
+}
+
+func (g goodUsecase) Create(ctx context.Context, projectID int, input entity.GoodInput) (entity.Good, error) {
+	ctx, span := tracer.Start(ctx, "goodUsecase.Create", trace.WithAttributes(attribute.Int("project.id", projectID)))
+	defer span.End()
+
+	if err := g.Validate(ctx, projectID, 0, &input); err != nil {
+		return entity.Good{}, err
+	}
+
+	var tags []string
+	if input.Tags != nil {
+		tags = *input.Tags
+	}
+
+	var categoryID *int
+	if input.CategoryID != nil && *input.CategoryID != 0 {
+		categoryID = input.CategoryID
+	}
+
 	maxPriority, err := g.repo.GetMaxPriority(ctx, projectID)
 	if err != nil {
 		return entity.Good{}, fmt.Errorf("repository get max priority error: %w", err)
 	}
 
+	var visibility entity.Visibility
+	if input.Visibility != nil {
+		visibility = *input.Visibility
+	}
+
 	good, err := g.repo.Create(ctx, entity.Good{
-		Name:      name,
-		ProjectID: projectID,
-		Priority:  maxPriority + 1,
-		Removed:   false,
-		CreatedAt: time.Now(),
+		Name:       input.Name,
+		ProjectID:  projectID,
+		Priority:   maxPriority + 1,
+		Removed:    false,
+		CreatedAt:  time.Now(),
+		CategoryID: categoryID,
+		Tags:       tags,
+		Attributes: input.Attributes,
+		Visibility: visibility,
 	})
 	if err != nil {
 		return good, fmt.Errorf("trouble creating a good: %w", err)
 	}
 
+	forgetProjects(ctx, g.cache, g.log, projectID)
+
+	return good, nil
+}
+
+func (g goodUsecase) Get(ctx context.Context, id, projectID int) (entity.Good, error) {
+	ctx, span := tracer.Start(ctx, "goodUsecase.Get", trace.WithAttributes(attribute.Int("good.id", id), attribute.Int("project.id", projectID)))
+	defer span.End()
+
+	generation, err := g.cache.Generation(ctx, projectID)
+	if err != nil {
+		return entity.Good{}, fmt.Errorf("cache error generation: %w", err)
+	}
+
+	good, err := g.cache.GetGood(ctx, projectID, generation, id)
+	if err == nil {
+		return good, nil
+	} else if !errors.Is(err, entity.ErrGoodNotFound) {
+		return entity.Good{}, fmt.Errorf("cache error get: %w", err)
+	}
+
+	good, err = g.repo.Get(ctx, id)
+	if err != nil {
+		return entity.Good{}, fmt.Errorf("repository error get: %w", err)
+	}
+
+	if good.ProjectID != projectID {
+		return entity.Good{}, fmt.Errorf("good with id #%d in project #%d %w", id, projectID, entity.ErrGoodNotFound)
+	}
+
+	if ttl := goodCacheTTL(good, time.Now()); ttl > 0 {
+		if err := g.cache.SetGood(ctx, good, generation, ttl); err != nil {
+			return entity.Good{}, fmt.Errorf("cache error set: %w", err)
+		}
+	}
+
 	return good, nil
 }
 
 func (g goodUsecase) Delete(ctx context.Context, id, projectID int) (entity.Good, error) {
+	ctx, span := tracer.Start(ctx, "goodUsecase.Delete", trace.WithAttributes(attribute.Int("good.id", id), attribute.Int("project.id", projectID)))
+	defer span.End()
+
 	deleted, err := g.repo.Delete(ctx, id, projectID)
 	if err != nil {
 		return entity.Good{}, fmt.Errorf("trouble deleting a good: %w", err)
 	}
 
-	key := fmt.Sprintf("goods_%d", deleted.ID)
+	forgetProjects(ctx, g.cache, g.log, deleted.ProjectID)
+
+	return deleted, nil
+}
+
+func (g goodUsecase) Restore(ctx context.Context, id, projectID int) (entity.Good, error) {
+	ctx, span := tracer.Start(ctx, "goodUsecase.Restore", trace.WithAttributes(attribute.Int("good.id", id), attribute.Int("project.id", projectID)))
+	defer span.End()
+
+	restored, err := g.repo.Restore(ctx, id, projectID)
+	if err != nil {
+		return entity.Good{}, fmt.Errorf("trouble restoring a good: %w", err)
+	}
+
+	forgetProjects(ctx, g.cache, g.log, restored.ProjectID)
 
-	return deleted, g.cache.Delete(ctx, key)
+	return restored, nil
 }
 
-func (g goodUsecase) Update(ctx context.Context, id, projectID int, name, desc string, emptyDesc bool) (entity.Good, error) {
-	updated, err := g.repo.UpdateName(ctx, name, id, projectID)
+func (g goodUsecase) Update(ctx context.Context, id, projectID int, input entity.GoodInput) (entity.Good, error) {
+	ctx, span := tracer.Start(ctx, "goodUsecase.Update", trace.WithAttributes(attribute.Int("good.id", id), attribute.Int("project.id", projectID)))
+	defer span.End()
+
+	if err := g.Validate(ctx, projectID, id, &input); err != nil {
+		return entity.Good{}, err
+	}
+
+	updated, err := g.repo.Update(ctx, id, projectID, input)
 	if err != nil {
 		return entity.Good{}, fmt.Errorf("trouble updating a good: %w", err)
 	}
 
-	if !emptyDesc {
-		updated, err = g.repo.UpdateDesc(ctx, desc, id, projectID)
-		if err != nil {
-			return entity.Good{}, fmt.Errorf("trouble updating a good: %w", err)
-		}
+	forgetProjects(ctx, g.cache, g.log, updated.ProjectID)
+
+	return updated, nil
+}
+
+// Rollback restores the name, description, category, tags, attributes and
+// visibility window a good had at an earlier revision, as a new revision. Priority and removal
+// are left alone; they have their own operations.
+func (g goodUsecase) Rollback(ctx context.Context, id, projectID, revision int) (entity.Good, error) {
+	ctx, span := tracer.Start(ctx, "goodUsecase.Rollback", trace.WithAttributes(attribute.Int("good.id", id), attribute.Int("project.id", projectID)))
+	defer span.End()
+
+	if revision < 1 {
+		return entity.Good{}, entity.NewQueryError(entity.FieldError{Field: "revision", Rule: "min", Param: "1"})
+	}
+
+	rev, err := g.revisions.Get(ctx, id, projectID, revision)
+	if err != nil {
+		return entity.Good{}, fmt.Errorf("trouble getting a revision: %w", err)
 	}
 
-	key := fmt.Sprintf("goods_%d", id)
+	input := rollbackInput(rev.Good)
+
+	// The rules may have changed since the revision was made
+	if err := g.checkName(ctx, projectID, input.Name, id); err != nil {
+		return entity.Good{}, err
+	}
 
-	return updated, g.cache.Delete(ctx, key)
+	if err := g.checkAttributes(ctx, projectID, input.Attributes); err != nil {
+		return entity.Good{}, err
+	}
+
+	rolledBack, err := g.repo.Rollback(ctx, id, projectID, input)
+	if err != nil {
+		return entity.Good{}, fmt.Errorf("trouble rolling back a good: %w", err)
+	}
+
+	forgetProjects(ctx, g.cache, g.log, rolledBack.ProjectID)
+
+	return rolledBack, nil
+}
+
+// rollbackInput sets every field a rollback restores, so that the fields
+// the revision had empty are cleared rather than left unchanged.
+func rollbackInput(old entity.Good) entity.GoodInput {
+	tags := old.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	// Zero removes the category
+	var categoryID int
+	if old.CategoryID != nil {
+		categoryID = *old.CategoryID
+	}
+
+	input := entity.GoodInput{
+		Name:        old.Name,
+		Description: &old.Description,
+		Tags:        &tags,
+		CategoryID:  &categoryID,
+		Attributes:  old.Attributes,
+		Visibility:  &old.Visibility,
+	}
+
+	if input.Attributes == nil {
+		input.Attributes = json.RawMessage("{}")
+	}
+
+	return input
+}
+
+// Retag adds and removes tags on several goods of a project at once.
+func (g goodUsecase) Retag(ctx context.Context, projectID int, ids []int, add, remove []string) ([]entity.Good, error) {
+	ctx, span := tracer.Start(ctx, "goodUsecase.Retag", trace.WithAttributes(attribute.Int("project.id", projectID), attribute.Int("goods", len(ids))))
+	defer span.End()
+
+	add, remove = entity.NormalizeTags(add), entity.NormalizeTags(remove)
+
+	fields := checkBulkIDs(ids)
+	fields = append(fields, entity.ValidateTags(add)...)
+
+	if len(add) == 0 && len(remove) == 0 {
+		fields = append(fields, entity.FieldError{Field: "add", Rule: "required_without", Param: "remove"})
+	}
+
+	if len(fields) != 0 {
+		return nil, entity.NewValidationError(fields...)
+	}
+
+	goods, err := g.repo.Retag(ctx, projectID, ids, add, remove)
+	if err != nil {
+		return nil, fmt.Errorf("trouble retagging goods: %w", err)
+	}
+
+	g.forget(ctx, goods)
+
+	return goods, nil
+}
+
+// Recategorize moves several goods of a project to a category; a zero
+// categoryID removes their category.
+func (g goodUsecase) Recategorize(ctx context.Context, projectID int, ids []int, categoryID int) ([]entity.Good, error) {
+	ctx, span := tracer.Start(ctx, "goodUsecase.Recategorize", trace.WithAttributes(attribute.Int("project.id", projectID), attribute.Int("goods", len(ids))))
+	defer span.End()
+
+	fields := checkBulkIDs(ids)
+	if categoryID < 0 {
+		fields = append(fields, entity.FieldError{Field: "category_id", Rule: "min", Param: "0"})
+	}
+
+	if len(fields) != 0 {
+		return nil, entity.NewValidationError(fields...)
+	}
+
+	goods, err := g.repo.Recategorize(ctx, projectID, ids, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("trouble recategorizing goods: %w", err)
+	}
+
+	g.forget(ctx, goods)
+
+	return goods, nil
 }
 
-func (g goodUsecase) List(ctx context.Context, limit, offset int) ([]entity.Good, error) {
-	length := int(math.Abs(float64(limit - offset)))
+// Move reassigns goods to another project, from priority on or at its end
+// when priority is nil. The goods must satisfy the target's attributes
+// schema and, when names are unique, not clash with its goods.
+func (g goodUsecase) Move(ctx context.Context, projectID int, ids []int, targetID int, priority *int) (entity.GoodsMove, error) {
+	ctx, span := tracer.Start(ctx, "goodUsecase.Move", trace.WithAttributes(
+		attribute.Int("project.id", projectID),
+		attribute.Int("target.id", targetID),
+		attribute.Int("goods", len(ids)),
+	))
+	defer span.End()
+
+	fields := checkBulkIDs(ids)
+
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			fields = append(fields, entity.FieldError{Field: "ids", Rule: "unique"})
+
+			break
+		}
+
+		seen[id] = true
+	}
+
+	if targetID == projectID {
+		fields = append(fields, entity.FieldError{Field: "project_id", Rule: "ne", Param: fmt.Sprint(projectID)})
+	}
+
+	if priority != nil && (*priority < entity.PriorityMin || *priority > entity.PriorityMax) {
+		fields = append(fields, entity.FieldError{Field: "priority", Rule: "range", Param: fmt.Sprintf("%d-%d", entity.PriorityMin, entity.PriorityMax)})
+	}
 
-	goods := make([]entity.Good, 0, length)
+	if len(fields) != 0 {
+		return entity.GoodsMove{}, entity.NewValidationError(fields...)
+	}
 
-	maxRows, err := g.repo.CountRows(ctx)
+	// The goods are checked against the target's rules once they are locked
+	check := func(ctx context.Context, good entity.Good) error {
+		if err := g.checkAttributes(ctx, targetID, good.Attributes); err != nil {
+			return err
+		}
+
+		if good.Removed {
+			return nil
+		}
+
+		return g.checkName(ctx, targetID, good.Name, good.ID)
+	}
+
+	move, err := g.repo.Move(ctx, projectID, ids, targetID, priority, check)
 	if err != nil {
-		return nil, fmt.Errorf("repository count rows error: %w", err)
+		return entity.GoodsMove{}, fmt.Errorf("trouble moving goods: %w", err)
 	}
 
-	if limit > maxRows {
-		limit = maxRows
+	// Shifted goods are in either project, so both start over
+	forgetProjects(ctx, g.cache, g.log, move.FromProjectID, move.ToProjectID)
+
+	return move, nil
+}
+
+// forget drops the cache of the projects holding changed goods.
+func (g goodUsecase) forget(ctx context.Context, goods []entity.Good) {
+	projectIDs := make([]int, 0, len(goods))
+	for _, good := range goods {
+		projectIDs = append(projectIDs, good.ProjectID)
 	}
 
-	for limit > 0 {
-		var good entity.Good
+	forgetProjects(ctx, g.cache, g.log, projectIDs...)
+}
 
-		key := fmt.Sprintf("goods_%d", offset)
+// forgetProjects moves each project to a new cache generation, which drops
+// every good and page cached for it at once. The write it follows has been
+// committed, so a failure is only logged; the stale entries run out with
+// their TTL.
+func forgetProjects(ctx context.Context, c cache.GoodCacheRepository, log *zap.Logger, projectIDs ...int) {
+	seen := make(map[int]bool, len(projectIDs))
+	distinct := make([]int, 0, len(projectIDs))
 
-		good, err := g.cache.Get(ctx, key)
-		if err != nil && !errors.Is(err, entity.ErrGoodNotFound) {
-			return nil, fmt.Errorf("cache error get: %w", err)
+	for _, projectID := range projectIDs {
+		if !seen[projectID] {
+			seen[projectID] = true
+			distinct = append(distinct, projectID)
 		}
+	}
+
+	if err := c.Bump(ctx, distinct...); err != nil {
+		log.Error("Cache not dropped", zap.Ints("project_ids", distinct), zap.Error(err))
+	}
+}
 
-		if errors.Is(err, entity.ErrGoodNotFound) {
-			good, err = g.repo.Get(ctx, offset)
-			if err != nil {
-				if errors.Is(err, entity.ErrGoodNotFound) {
-					return goods, entity.ErrGoodNotFound
-				}
-				return nil, fmt.Errorf("repository error get: %w", err)
-			}
-
-			if err := g.cache.Create(ctx, good, key, time.Minute); err != nil {
-				return nil, fmt.Errorf("cache error create: %w", err)
-			}
+func checkBulkIDs(ids []int) []entity.FieldError {
+	if len(ids) == 0 {
+		return []entity.FieldError{{Field: "ids", Rule: "required"}}
+	}
+
+	if len(ids) > bulkMaxGoods {
+		return []entity.FieldError{{Field: "ids", Rule: "max", Param: fmt.Sprint(bulkMaxGoods)}}
+	}
+
+	return nil
+}
+
+// List pages goods by id. Pages of a single project are cached under its
+// generation unless they depend on the time of the request.
+func (g goodUsecase) List(ctx context.Context, filter entity.ListFilter) ([]entity.Good, error) {
+	ctx, span := tracer.Start(ctx, "goodUsecase.List", trace.WithAttributes(attribute.Int("limit", filter.Limit), attribute.Int("offset", filter.Offset)))
+	defer span.End()
+
+	if err := checkListFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+
+	if filter.ProjectID == 0 || filter.VisibleAt != nil {
+		goods, err := g.repo.List(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("repository list error: %w", err)
 		}
 
-		goods = append(goods, good)
-		offset++
-		limit--
+		return goods, nil
+	}
+
+	generation, err := g.cache.Generation(ctx, filter.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("cache error generation: %w", err)
+	}
+
+	query, err := pageQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	goods, err := g.cache.GetPage(ctx, filter.ProjectID, generation, query)
+	if err == nil {
+		return goods, nil
+	} else if !errors.Is(err, entity.ErrGoodNotFound) {
+		return nil, fmt.Errorf("cache error get: %w", err)
+	}
+
+	goods, err = g.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("repository list error: %w", err)
+	}
+
+	if ttl := pageCacheTTL(goods, time.Now()); ttl > 0 {
+		if err := g.cache.SetPage(ctx, filter.ProjectID, generation, query, goods, ttl); err != nil {
+			return nil, fmt.Errorf("cache error set: %w", err)
+		}
 	}
 
 	return goods, nil
 }
 
+// checkListFilter normalizes and validates the filters shared by listing
+// and export.
+func checkListFilter(ctx context.Context, filter *entity.ListFilter) error {
+	// Confined callers only see, and only cache, their own goods
+	filter.OrganizationID = entity.OrganizationFromContext(ctx)
+	filter.Tags = entity.NormalizeTags(filter.Tags)
+
+	// Callers with roles per project see the goods of those projects. The
+	// query filters them, so pages stay full; one requested project either
+	// is among them, which keeps its cached pages shared, or is refused.
+	if principal, ok := entity.PrincipalFromContext(ctx); ok {
+		switch ids, all := principal.VisibleProjects(); {
+		case all:
+		case filter.ProjectID == 0:
+			filter.ProjectIDs = ids
+		case !principal.CanAccess(filter.ProjectID):
+			return fmt.Errorf("project #%d: %w", filter.ProjectID, entity.ErrForbidden)
+		}
+	}
+
+	if filter.Attributes != nil {
+		if fields := entity.ValidateAttributes(filter.Attributes); len(fields) != 0 {
+			return entity.NewQueryError(fields...)
+		}
+	}
+
+	return nil
+}
+
+// pageQuery spells out a filter so that equal filters name the same page.
+// Tags are compared case-insensitively, as the repository does.
+func pageQuery(filter entity.ListFilter) (string, error) {
+	tags := make([]string, len(filter.Tags))
+	for i, tag := range filter.Tags {
+		tags[i] = strings.ToLower(tag)
+	}
+
+	sort.Strings(tags)
+
+	var attributes any
+	if filter.Attributes != nil {
+		// Decoding and encoding again orders the keys
+		if err := json.Unmarshal(filter.Attributes, &attributes); err != nil {
+			return "", entity.NewQueryError(entity.FieldError{Field: "attributes", Rule: "json"})
+		}
+	}
+
+	query, err := json.Marshal(struct {
+		OrganizationID int      `json:"organization_id"`
+		Limit          int      `json:"limit"`
+		Offset         int      `json:"offset"`
+		Tags           []string `json:"tags"`
+		MatchAll       bool     `json:"match_all"`
+		CategoryID     int      `json:"category_id"`
+		Attributes     any      `json:"attributes"`
+	}{filter.OrganizationID, filter.Limit, filter.Offset, tags, filter.MatchAll, filter.CategoryID, attributes})
+	if err != nil {
+		return "", fmt.Errorf("trouble encoding page query: %w", err)
+	}
+
+	return string(query), nil
+}
+
+// Export hands the project's goods to fn in priority order, straight from
+// the database.
+func (g goodUsecase) Export(ctx context.Context, projectID int, filter entity.ExportFilter, fn func(entity.Good) error) error {
+	ctx, span := tracer.Start(ctx, "goodUsecase.Export", trace.WithAttributes(attribute.Int("project.id", projectID)))
+	defer span.End()
+
+	if filter.Offset < 1 || filter.Limit < 0 {
+		return entity.NewQueryError(
+			entity.FieldError{Field: "offset", Rule: "min", Param: "1"},
+			entity.FieldError{Field: "limit", Rule: "min", Param: "0"},
+		)
+	}
+
+	if err := checkListFilter(ctx, &filter.ListFilter); err != nil {
+		return err
+	}
+
+	if err := g.repo.Export(ctx, projectID, filter, fn); err != nil {
+		return fmt.Errorf("trouble exporting goods: %w", err)
+	}
+
+	return nil
+}
+
 func (g goodUsecase) Reprioritiize(ctx context.Context, priority, id, projectID int) ([]entity.Good, error) {
+	ctx, span := tracer.Start(ctx, "goodUsecase.Reprioritiize", trace.WithAttributes(attribute.Int("good.id", id), attribute.Int("project.id", projectID)))
+	defer span.End()
+
+	if fields := entity.ValidatePriority(priority); len(fields) != 0 {
+		return nil, entity.NewValidationError(fields...)
+	}
+
 	goods, err := g.repo.UpdatePriority(ctx, priority, id, projectID)
 	if err != nil {
 		return nil, fmt.Errorf("trouble getting a good: %w", err)
 	}
 
+	g.forget(ctx, goods)
+
+	return goods, nil
+}
+
+// goodCacheTTL keeps a good cached for a minute at most and never past the
+// next end of its visibility window. Zero means the boundary is too close
+// to be worth caching.
+func goodCacheTTL(good entity.Good, now time.Time) time.Duration {
+	ttl := time.Minute
+
+	if boundary, ok := good.NextBoundary(now); ok && boundary.Sub(now) < ttl {
+		ttl = boundary.Sub(now).Truncate(time.Second)
+	}
+
+	return ttl
+}
+
+// pageCacheTTL keeps a page no longer than any of its goods.
+func pageCacheTTL(goods []entity.Good, now time.Time) time.Duration {
+	ttl := time.Minute
+
 	for _, good := range goods {
-		key := fmt.Sprintf("goods_%d", good.ID)
-		_ = g.cache.Delete(ctx, key)
+		if goodTTL := goodCacheTTL(good, now); goodTTL < ttl {
+			ttl = goodTTL
+		}
 	}
 
-	return goods, nil
+	return ttl
 }
@@ -0,0 +1,622 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+
+	"go.uber.org/zap"
+)
+
+func TestSchemaCacheCompilesOncePerVersion(t *testing.T) {
+	ctx := context.Background()
+	cache := &schemaCache{schemas: make(map[int]compiledSchema)}
+
+	loads := 0
+	projects := fakeProjects{schema: json.RawMessage(`{"type":"object","required":["color"]}`), version: 1, loads: &loads}
+
+	first, err := cache.get(ctx, projects, 1)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	second, err := cache.get(ctx, projects, 1)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	if first == nil || first != second || loads != 1 {
+		t.Errorf("same version: loads = %d, same schema = %v, want one load of one schema", loads, first == second)
+	}
+
+	projects.schema = nil
+	projects.version = 2
+
+	removed, err := cache.get(ctx, projects, 1)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	if removed != nil || loads != 2 {
+		t.Errorf("new version: schema = %v, loads = %d, want no schema after a second load", removed, loads)
+	}
+
+	if _, err := cache.get(ctx, projects, 2); err != nil || loads != 3 {
+		t.Errorf("other project: err = %v, loads = %d, want a third load", err, loads)
+	}
+}
+
+func TestCheckAttributes(t *testing.T) {
+	uc := NewGoodUsecase(nil, fakeProjects{
+		schema: json.RawMessage(`{"type":"object","properties":{"size":{"enum":["S","M","L"]},"weight":{"type":"number","minimum":0}},"required":["size"]}`),
+	}, nil, nil, Rules{}, zap.NewNop()).(goodUsecase)
+
+	tests := []struct {
+		name   string
+		attrs  string
+		fields []entity.FieldError
+	}{
+		{name: "unchanged", attrs: ""},
+		{name: "valid", attrs: `{"size":"M","weight":1.5}`},
+		{name: "not an object", attrs: `[1]`, fields: []entity.FieldError{{Field: "attributes", Rule: "object"}}},
+		{
+			name:  "schema",
+			attrs: `{"weight":-1}`,
+			fields: []entity.FieldError{
+				{Field: "attributes.size", Rule: "required"},
+				{Field: "attributes.weight", Rule: "minimum", Param: "0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attrs json.RawMessage
+			if tt.attrs != "" {
+				attrs = json.RawMessage(tt.attrs)
+			}
+
+			err := uc.checkAttributes(context.Background(), 1, attrs)
+
+			if tt.fields == nil {
+				if err != nil {
+					t.Errorf("checkAttributes: %v", err)
+				}
+
+				return
+			}
+
+			var validation *entity.ValidationError
+			if !errors.As(err, &validation) {
+				t.Fatalf("err = %v, want a validation error", err)
+			}
+
+			if len(validation.Fields) != len(tt.fields) {
+				t.Fatalf("fields = %v, want %v", validation.Fields, tt.fields)
+			}
+
+			for i, field := range tt.fields {
+				if validation.Fields[i] != field {
+					t.Errorf("field %d = %v, want %v", i, validation.Fields[i], field)
+				}
+			}
+		})
+	}
+}
+
+func TestRollbackInput(t *testing.T) {
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	category := 3
+
+	tests := []struct {
+		name string
+		old  entity.Good
+		want entity.GoodInput
+	}{
+		{
+			name: "every field restored",
+			old: entity.Good{
+				Name:        "apple",
+				Description: "red",
+				CategoryID:  &category,
+				Tags:        []string{"fruit"},
+				Attributes:  json.RawMessage(`{"color":"red"}`),
+				Visibility:  entity.Visibility{VisibleFrom: &from},
+			},
+			want: entity.GoodInput{
+				Name:        "apple",
+				Description: ptr("red"),
+				Tags:        &[]string{"fruit"},
+				CategoryID:  ptr(3),
+				Attributes:  json.RawMessage(`{"color":"red"}`),
+				Visibility:  &entity.Visibility{VisibleFrom: &from},
+			},
+		},
+		{
+			name: "empty fields cleared",
+			old:  entity.Good{Name: "apple"},
+			want: entity.GoodInput{
+				Name:        "apple",
+				Description: ptr(""),
+				Tags:        &[]string{},
+				CategoryID:  ptr(0),
+				Attributes:  json.RawMessage(`{}`),
+				Visibility:  &entity.Visibility{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rollbackInput(tt.old); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rollbackInput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoodCacheTTL(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+
+		return &t
+	}
+
+	tests := []struct {
+		name   string
+		window entity.Visibility
+		want   time.Duration
+	}{
+		{name: "open window", want: time.Minute},
+		{name: "boundary far off", window: entity.Visibility{VisibleUntil: at(time.Hour)}, want: time.Minute},
+		{name: "opens soon", window: entity.Visibility{VisibleFrom: at(30 * time.Second)}, want: 30 * time.Second},
+		{name: "closes soon", window: entity.Visibility{VisibleFrom: at(-time.Hour), VisibleUntil: at(20 * time.Second)}, want: 20 * time.Second},
+		{name: "truncated to seconds", window: entity.Visibility{VisibleUntil: at(12*time.Second + 700*time.Millisecond)}, want: 12 * time.Second},
+		{name: "too close to cache", window: entity.Visibility{VisibleUntil: at(300 * time.Millisecond)}, want: 0},
+		{name: "opens and closes soon", window: entity.Visibility{VisibleFrom: at(10 * time.Second), VisibleUntil: at(40 * time.Second)}, want: 10 * time.Second},
+		{name: "window passed", window: entity.Visibility{VisibleFrom: at(-time.Hour), VisibleUntil: at(-time.Minute)}, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := goodCacheTTL(entity.Good{Visibility: tt.window}, now); got != tt.want {
+				t.Errorf("goodCacheTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	goods := []entity.Good{{}, {Visibility: entity.Visibility{VisibleUntil: at(15 * time.Second)}}}
+	if got := pageCacheTTL(goods, now); got != 15*time.Second {
+		t.Errorf("pageCacheTTL() = %v, want the shortest good TTL", got)
+	}
+}
+
+func TestGoodMove(t *testing.T) {
+	goods := map[int]entity.Good{
+		1: {ID: 1, ProjectID: 1, Name: "apple", Attributes: json.RawMessage(`{"size":"M"}`)},
+		2: {ID: 2, ProjectID: 1, Name: "pear", Attributes: json.RawMessage(`{}`)},
+		3: {ID: 3, ProjectID: 1, Name: "plum", Removed: true, Attributes: json.RawMessage(`{"size":"S"}`)},
+		4: {ID: 4, ProjectID: 3, Name: "fig", Attributes: json.RawMessage(`{"size":"L"}`)},
+	}
+
+	tests := []struct {
+		name      string
+		rules     Rules
+		schema    json.RawMessage
+		existing  map[string]int
+		ids       []int
+		targetID  int
+		priority  *int
+		wantErr   error
+		wantMoved []int
+	}{
+		{name: "moved", ids: []int{1, 2}, targetID: 2, wantMoved: []int{1, 2}},
+		{name: "moved to a priority", ids: []int{1}, targetID: 2, priority: ptr(1), wantMoved: []int{1}},
+		{name: "no ids", ids: []int{}, targetID: 2, wantErr: entity.ErrValidation},
+		{name: "duplicate ids", ids: []int{1, 1}, targetID: 2, wantErr: entity.ErrValidation},
+		{name: "same project", ids: []int{1}, targetID: 1, wantErr: entity.ErrValidation},
+		{name: "priority out of range", ids: []int{1}, targetID: 2, priority: ptr(0), wantErr: entity.ErrValidation},
+		{name: "good of another project", ids: []int{1, 4}, targetID: 2, wantErr: entity.ErrGoodNotFound},
+		{name: "missing good", ids: []int{9}, targetID: 2, wantErr: entity.ErrGoodNotFound},
+		{
+			name:     "name taken in the target",
+			rules:    Rules{UniqueNames: true},
+			existing: map[string]int{"pear": 7},
+			ids:      []int{1, 2},
+			targetID: 2,
+			wantErr:  entity.ErrGoodNameTaken,
+		},
+		{
+			name:      "removed good keeps a taken name",
+			rules:     Rules{UniqueNames: true},
+			existing:  map[string]int{"plum": 7},
+			ids:       []int{3},
+			targetID:  2,
+			wantMoved: []int{3},
+		},
+		{
+			name:     "attributes rejected by the target schema",
+			schema:   json.RawMessage(`{"type":"object","required":["size"]}`),
+			ids:      []int{1, 2},
+			targetID: 2,
+			wantErr:  entity.ErrValidation,
+		},
+		{
+			name:      "attributes accepted by the target schema",
+			schema:    json.RawMessage(`{"type":"object","required":["size"]}`),
+			ids:       []int{1, 3},
+			targetID:  2,
+			wantMoved: []int{1, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeGoods{goods: goods, existing: tt.existing}
+			cache := newFakeCache()
+			uc := NewGoodUsecase(repo, fakeProjects{schema: tt.schema}, nil, cache, tt.rules, zap.NewNop())
+
+			move, err := uc.Move(context.Background(), 1, tt.ids, tt.targetID, tt.priority)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Move() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(repo.moved, tt.wantMoved) {
+				t.Errorf("moved = %v, want %v", repo.moved, tt.wantMoved)
+			}
+
+			if tt.wantErr != nil {
+				if len(cache.bumps) != 0 {
+					t.Errorf("failed move dropped the cache of %v", cache.bumps)
+				}
+
+				return
+			}
+
+			if move.ToProjectID != tt.targetID || !reflect.DeepEqual(cache.bumps, []int{1, tt.targetID}) {
+				t.Errorf("Move() = %+v, bumps = %v, want both projects dropped", move, cache.bumps)
+			}
+		})
+	}
+}
+
+func TestPageQuery(t *testing.T) {
+	base := entity.ListFilter{
+		Limit:      10,
+		Offset:     20,
+		ProjectID:  1,
+		Tags:       []string{"fruit", "sale"},
+		CategoryID: 3,
+		Attributes: json.RawMessage(`{"color":"red","size":{"w":1,"h":2}}`),
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(*entity.ListFilter)
+		wantSame bool
+	}{
+		{name: "equal filter", modify: func(*entity.ListFilter) {}, wantSame: true},
+		{name: "tags reordered", modify: func(f *entity.ListFilter) { f.Tags = []string{"sale", "fruit"} }, wantSame: true},
+		{name: "tags in another case", modify: func(f *entity.ListFilter) { f.Tags = []string{"Fruit", "SALE"} }, wantSame: true},
+		{
+			name: "attributes reordered",
+			modify: func(f *entity.ListFilter) {
+				f.Attributes = json.RawMessage(`{ "size": {"h":2,"w":1}, "color": "red" }`)
+			},
+			wantSame: true,
+		},
+		{name: "limit", modify: func(f *entity.ListFilter) { f.Limit = 11 }},
+		{name: "offset", modify: func(f *entity.ListFilter) { f.Offset = 0 }},
+		{name: "tags", modify: func(f *entity.ListFilter) { f.Tags = []string{"fruit"} }},
+		{name: "match all", modify: func(f *entity.ListFilter) { f.MatchAll = true }},
+		{name: "category", modify: func(f *entity.ListFilter) { f.CategoryID = 4 }},
+		{name: "attributes", modify: func(f *entity.ListFilter) { f.Attributes = json.RawMessage(`{"color":"green"}`) }},
+		{name: "attributes cleared", modify: func(f *entity.ListFilter) { f.Attributes = nil }},
+		{name: "organization", modify: func(f *entity.ListFilter) { f.OrganizationID = 2 }},
+	}
+
+	want, err := pageQuery(base)
+	if err != nil {
+		t.Fatalf("pageQuery() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := base
+			tt.modify(&filter)
+
+			got, err := pageQuery(filter)
+			if err != nil {
+				t.Fatalf("pageQuery() error = %v", err)
+			}
+
+			if (got == want) != tt.wantSame {
+				t.Errorf("pageQuery() = %s, base %s, want same = %v", got, want, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestGoodCache(t *testing.T) {
+	confined := entity.WithPrincipal(context.Background(), entity.Principal{OrganizationID: 2, GlobalRole: entity.RoleViewer})
+
+	tests := []struct {
+		name string
+		// between runs after the first reads, with the context of the second
+		between   func(context.Context, GoodUsecase) error
+		second    context.Context
+		bumpErr   error
+		wantReads int
+	}{
+		{name: "cached", between: func(context.Context, GoodUsecase) error { return nil }, second: context.Background(), wantReads: 2},
+		{
+			name:      "bump drops the project",
+			between:   func(ctx context.Context, uc GoodUsecase) error { _, err := uc.Delete(ctx, 2, 1); return err },
+			second:    context.Background(),
+			wantReads: 4,
+		},
+		{
+			name:      "failed bump still writes",
+			between:   func(ctx context.Context, uc GoodUsecase) error { _, err := uc.Delete(ctx, 2, 1); return err },
+			second:    context.Background(),
+			bumpErr:   errors.New("connection refused"),
+			wantReads: 2,
+		},
+		{
+			name:      "organizations cached apart",
+			between:   func(context.Context, GoodUsecase) error { return nil },
+			second:    confined,
+			wantReads: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeGoods{goods: map[int]entity.Good{
+				1: {ID: 1, ProjectID: 1, Name: "apple"},
+				2: {ID: 2, ProjectID: 1, Name: "pear"},
+			}}
+			cache := newFakeCache()
+			uc := NewGoodUsecase(repo, fakeProjects{}, nil, cache, Rules{}, zap.NewNop())
+			filter := entity.ListFilter{ProjectID: 1, Limit: 10}
+
+			read := func(ctx context.Context) {
+				if _, err := uc.Get(ctx, 1, 1); err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+
+				if _, err := uc.List(ctx, filter); err != nil {
+					t.Fatalf("List() error = %v", err)
+				}
+			}
+
+			read(context.Background())
+
+			cache.bumpErr = tt.bumpErr
+			if err := tt.between(tt.second, uc); err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+
+			read(tt.second)
+
+			if repo.reads != tt.wantReads {
+				t.Errorf("repository reads = %d, want %d", repo.reads, tt.wantReads)
+			}
+		})
+	}
+}
+
+func TestGoodRetag(t *testing.T) {
+	tooMany := make([]string, entity.TagsPerGoodMax+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag%d", i)
+	}
+
+	tests := []struct {
+		name     string
+		ids      []int
+		add      []string
+		remove   []string
+		wantErr  error
+		wantTags map[int][]string
+	}{
+		{
+			name:     "added and removed",
+			ids:      []int{1, 2},
+			add:      []string{" Sweet ", "sweet"},
+			remove:   []string{"RED"},
+			wantTags: map[int][]string{1: {"Fruit", "Sweet"}, 2: {"Sweet"}},
+		},
+		{
+			name:     "removed only",
+			ids:      []int{1},
+			remove:   []string{"fruit", "red"},
+			wantTags: map[int][]string{1: {}, 2: nil},
+		},
+		{name: "nothing to change", ids: []int{1}, wantErr: entity.ErrValidation},
+		{name: "no ids", ids: []int{}, add: []string{"new"}, wantErr: entity.ErrValidation},
+		{name: "too many tags", ids: []int{1}, add: tooMany, wantErr: entity.ErrValidation},
+		{name: "good of another project", ids: []int{1, 3}, add: []string{"new"}, wantErr: entity.ErrGoodNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeGoods{goods: map[int]entity.Good{
+				1: {ID: 1, ProjectID: 1, Tags: []string{"Fruit", "red"}},
+				2: {ID: 2, ProjectID: 1},
+				3: {ID: 3, ProjectID: 2},
+			}}
+			cache := newFakeCache()
+			uc := NewGoodUsecase(repo, fakeProjects{}, nil, cache, Rules{}, zap.NewNop())
+
+			_, err := uc.Retag(context.Background(), 1, tt.ids, tt.add, tt.remove)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Retag() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(cache.bumps) != 0 {
+					t.Errorf("failed retag dropped the cache of %v", cache.bumps)
+				}
+
+				return
+			}
+
+			for id, want := range tt.wantTags {
+				if got := repo.goods[id].Tags; !reflect.DeepEqual(got, want) {
+					t.Errorf("good #%d tags = %q, want %q", id, got, want)
+				}
+			}
+
+			if !reflect.DeepEqual(cache.bumps, []int{1}) {
+				t.Errorf("bumps = %v, want the project dropped", cache.bumps)
+			}
+		})
+	}
+}
+
+func TestGoodRecategorize(t *testing.T) {
+	tests := []struct {
+		name         string
+		categoryID   int
+		wantErr      error
+		wantCategory *int
+	}{
+		{name: "category of the project", categoryID: 5, wantCategory: ptr(5)},
+		{name: "no category", categoryID: 0},
+		{name: "negative", categoryID: -1, wantErr: entity.ErrValidation},
+		{name: "category of another project", categoryID: 6, wantErr: entity.ErrCategoryNotFound},
+		{name: "unknown category", categoryID: 7, wantErr: entity.ErrCategoryNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeGoods{
+				goods:      map[int]entity.Good{1: {ID: 1, ProjectID: 1, CategoryID: ptr(6)}},
+				categories: map[int]int{5: 1, 6: 2},
+			}
+			cache := newFakeCache()
+			uc := NewGoodUsecase(repo, fakeProjects{}, nil, cache, Rules{}, zap.NewNop())
+
+			goods, err := uc.Recategorize(context.Background(), 1, []int{1}, tt.categoryID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Recategorize() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(cache.bumps) != 0 || *repo.goods[1].CategoryID != 6 {
+					t.Errorf("failed recategorize changed the good or dropped the cache of %v", cache.bumps)
+				}
+
+				return
+			}
+
+			if !reflect.DeepEqual(goods[0].CategoryID, tt.wantCategory) {
+				t.Errorf("category = %v, want %v", goods[0].CategoryID, tt.wantCategory)
+			}
+
+			if !reflect.DeepEqual(cache.bumps, []int{1}) {
+				t.Errorf("bumps = %v, want the project dropped", cache.bumps)
+			}
+		})
+	}
+}
+
+// TestGoodListVisibleProjects makes sure callers with roles per project get
+// full pages of the projects they see rather than pages thinned afterwards.
+func TestGoodListVisibleProjects(t *testing.T) {
+	goods := map[int]entity.Good{
+		1: {ID: 1, ProjectID: 1},
+		2: {ID: 2, ProjectID: 2},
+		3: {ID: 3, ProjectID: 2},
+		4: {ID: 4, ProjectID: 3},
+		5: {ID: 5, ProjectID: 1},
+	}
+
+	perProject := entity.Principal{Roles: map[int]entity.Role{1: entity.RoleViewer, 3: entity.RoleEditor}}
+
+	tests := []struct {
+		name           string
+		principal      *entity.Principal
+		projectID      int
+		wantErr        error
+		wantProjectIDs []int
+		wantGoods      []int
+	}{
+		{name: "unauthenticated", wantGoods: []int{1, 2, 3}},
+		{
+			name:      "global role",
+			principal: &entity.Principal{GlobalRole: entity.RoleViewer},
+			wantGoods: []int{1, 2, 3},
+		},
+		{
+			name:           "roles per project",
+			principal:      &perProject,
+			wantProjectIDs: []int{1, 3},
+			wantGoods:      []int{1, 4, 5},
+		},
+		{
+			name:      "one visible project",
+			principal: &perProject,
+			projectID: 3,
+			wantGoods: []int{4},
+		},
+		{
+			name:      "one hidden project",
+			principal: &perProject,
+			projectID: 2,
+			wantErr:   entity.ErrForbidden,
+		},
+		{
+			name:           "no roles",
+			principal:      &entity.Principal{},
+			wantProjectIDs: []int{},
+			wantGoods:      []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeGoods{goods: goods}
+			uc := NewGoodUsecase(repo, fakeProjects{}, nil, newFakeCache(), Rules{}, zap.NewNop())
+
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = entity.WithPrincipal(ctx, *tt.principal)
+			}
+
+			list, err := uc.List(ctx, entity.ListFilter{ProjectID: tt.projectID, Limit: 3})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("List() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(repo.listed) != 0 {
+					t.Errorf("refused list read the repository with %+v", repo.listed)
+				}
+
+				return
+			}
+
+			if got := repo.listed[0].ProjectIDs; !reflect.DeepEqual(got, tt.wantProjectIDs) {
+				t.Errorf("listed projects %v, want %v", got, tt.wantProjectIDs)
+			}
+
+			ids := make([]int, 0, len(list))
+			for _, good := range list {
+				ids = append(ids, good.ID)
+			}
+
+			if !reflect.DeepEqual(ids, tt.wantGoods) {
+				t.Errorf("List() = goods %v, want %v", ids, tt.wantGoods)
+			}
+		})
+	}
+}
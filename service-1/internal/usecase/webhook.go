@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/skantay/hezzl/internal/entity"
+	"github.com/skantay/hezzl/internal/repository/postgres"
+	"github.com/skantay/hezzl/pkg/metrics"
+	"github.com/skantay/hezzl/pkg/webhook"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// minSecretLength keeps caller-chosen secrets from being guessable
+	minSecretLength    = 16
+	deliveriesMaxLimit = 500
+)
+
+var webhookEvents = map[string]bool{
+	entity.ActionCreate:       true,
+	entity.ActionUpdate:       true,
+	entity.ActionRemove:       true,
+	entity.ActionRestore:      true,
+	entity.ActionReprioritize: true,
+	entity.ActionRollback:     true,
+	entity.ActionPublish:      true,
+	entity.ActionExpire:       true,
+	entity.ActionChangeset:    true,
+	entity.ActionMove:         true,
+}
+
+type WebhookUsecase interface {
+	Create(ctx context.Context, projectID int, rawURL, secret string, events []string) (entity.Webhook, error)
+	List(ctx context.Context, projectID int) ([]entity.Webhook, error)
+	Delete(ctx context.Context, id, projectID int) error
+	Deliveries(ctx context.Context, projectID, webhookID, limit, offset int) ([]entity.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id int64, projectID int) (entity.WebhookDelivery, error)
+	Run(ctx context.Context)
+}
+
+// WebhookOptions tunes delivery.
+type WebhookOptions struct {
+	Interval time.Duration
+	// Timeout bounds a single attempt
+	Timeout     time.Duration
+	Batch       int
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+type webhookUsecase struct {
+	repo   postgres.WebhookRepository
+	sender *webhook.Sender
+	opts   WebhookOptions
+	log    *zap.Logger
+}
+
+func NewWebhookUsecase(repo postgres.WebhookRepository, sender *webhook.Sender, opts WebhookOptions, log *zap.Logger) WebhookUsecase {
+	if opts.Interval <= 0 {
+		opts.Interval = 2 * time.Second
+	}
+
+	return webhookUsecase{
+		repo:   repo,
+		sender: sender,
+		opts:   opts,
+		log:    log,
+	}
+}
+
+func (w webhookUsecase) Create(ctx context.Context, projectID int, rawURL, secret string, events []string) (entity.Webhook, error) {
+	var fields []entity.FieldError
+
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fields = append(fields, entity.FieldError{Field: "url", Rule: "url"})
+	} else if addr, err := netip.ParseAddr(u.Hostname()); err == nil && w.sender.Check(addr) != nil {
+		// Host names are checked when dialled, as they may resolve anywhere
+		fields = append(fields, entity.FieldError{Field: "url", Rule: "public"})
+	}
+
+	if secret != "" && len(secret) < minSecretLength {
+		fields = append(fields, entity.FieldError{Field: "secret", Rule: "min", Param: fmt.Sprint(minSecretLength)})
+	}
+
+	for _, event := range events {
+		if !webhookEvents[event] {
+			fields = append(fields, entity.FieldError{Field: "events", Rule: "oneof", Param: event})
+		}
+	}
+
+	if len(fields) != 0 {
+		return entity.Webhook{}, entity.NewValidationError(fields...)
+	}
+
+	if secret == "" {
+		raw := make([]byte, 24)
+		if _, err := rand.Read(raw); err != nil {
+			return entity.Webhook{}, fmt.Errorf("trouble generating webhook secret: %w", err)
+		}
+
+		secret = hex.EncodeToString(raw)
+	}
+
+	if events == nil {
+		events = []string{}
+	}
+
+	hook, err := w.repo.Create(ctx, entity.Webhook{
+		ProjectID: projectID,
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    events,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return entity.Webhook{}, fmt.Errorf("trouble creating a webhook: %w", err)
+	}
+
+	return hook, nil
+}
+
+func (w webhookUsecase) List(ctx context.Context, projectID int) ([]entity.Webhook, error) {
+	hooks, err := w.repo.List(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("trouble listing webhooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+func (w webhookUsecase) Delete(ctx context.Context, id, projectID int) error {
+	if err := w.repo.Delete(ctx, id, projectID); err != nil {
+		return fmt.Errorf("trouble deleting a webhook: %w", err)
+	}
+
+	return nil
+}
+
+func (w webhookUsecase) Deliveries(ctx context.Context, projectID, webhookID, limit, offset int) ([]entity.WebhookDelivery, error) {
+	if limit < 1 || limit > deliveriesMaxLimit || offset < 0 {
+		return nil, entity.NewQueryError(
+			entity.FieldError{Field: "limit", Rule: "range", Param: fmt.Sprintf("1-%d", deliveriesMaxLimit)},
+			entity.FieldError{Field: "offset", Rule: "min", Param: "0"},
+		)
+	}
+
+	deliveries, err := w.repo.ListDeliveries(ctx, projectID, webhookID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("trouble listing deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (w webhookUsecase) Redeliver(ctx context.Context, id int64, projectID int) (entity.WebhookDelivery, error) {
+	delivery, err := w.repo.Redeliver(ctx, id, projectID)
+	if err != nil {
+		return entity.WebhookDelivery{}, fmt.Errorf("trouble redelivering: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// Run sends due deliveries every interval until ctx is done.
+func (w webhookUsecase) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.deliverDue(ctx); err != nil {
+				w.log.Error("Webhook delivery failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// deliverDue sends one claimed batch concurrently.
+func (w webhookUsecase) deliverDue(ctx context.Context) error {
+	due, err := w.repo.ClaimDue(ctx, w.opts.Batch, w.lease())
+	if err != nil {
+		return fmt.Errorf("trouble claiming deliveries: %w", err)
+	}
+
+	var wg sync.WaitGroup
+
+	for _, d := range due {
+		wg.Add(1)
+
+		go func(d entity.DueDelivery) {
+			defer wg.Done()
+
+			if err := w.repo.Record(ctx, w.attempt(ctx, d)); err != nil {
+				w.log.Error("Webhook delivery not recorded", zap.Int64("delivery_id", d.Delivery.ID), zap.Error(err))
+			}
+		}(d)
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+// lease is how long a claimed batch stays hidden from other replicas. It
+// outlasts the batch even if every attempt times out in turn, so a slow
+// batch is not claimed and sent a second time.
+func (w webhookUsecase) lease() time.Duration {
+	return w.opts.Timeout*time.Duration(w.opts.Batch) + w.opts.Interval
+}
+
+// attempt sends d once and returns the delivery updated with the outcome.
+func (w webhookUsecase) attempt(ctx context.Context, d entity.DueDelivery) entity.WebhookDelivery {
+	delivery := d.Delivery
+	delivery.Attempts++
+
+	code, err := w.sender.Send(ctx, webhook.Request{
+		URL:        d.Webhook.URL,
+		Secret:     d.Webhook.Secret,
+		Event:      delivery.Action,
+		DeliveryID: delivery.ID,
+		Body:       delivery.Payload,
+	})
+
+	delivery.ResponseCode = nil
+	if code != 0 {
+		delivery.ResponseCode = &code
+	}
+
+	now := time.Now()
+
+	if err == nil {
+		metrics.WebhookDeliveries.WithLabelValues("success").Inc()
+
+		delivery.Status = entity.DeliverySucceeded
+		delivery.LastError = nil
+		delivery.DeliveredAt = &now
+
+		return delivery
+	}
+
+	message := err.Error()
+	delivery.LastError = &message
+
+	if delivery.Attempts >= w.opts.MaxAttempts {
+		metrics.WebhookDeliveries.WithLabelValues("failure").Inc()
+
+		delivery.Status = entity.DeliveryFailed
+
+		return delivery
+	}
+
+	metrics.WebhookDeliveries.WithLabelValues("retry").Inc()
+
+	delivery.Status = entity.DeliveryPending
+	delivery.NextAttemptAt = now.Add(webhook.Backoff(delivery.Attempts, w.opts.BackoffBase, w.opts.BackoffMax))
+
+	return delivery
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_goods_projectid_lower_name;
+
+ALTER TABLE IF EXISTS goods DROP CONSTRAINT IF EXISTS goods_priority_check;
+ALTER TABLE IF EXISTS goods DROP CONSTRAINT IF EXISTS goods_description_check;
+ALTER TABLE IF EXISTS goods DROP CONSTRAINT IF EXISTS goods_name_check;
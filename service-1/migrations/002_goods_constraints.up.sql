@@ -0,0 +1,21 @@
+-- Domain constraints for goods, see entity.NameMaxLength and friends.
+-- NOT VALID keeps existing rows untouched while new writes are checked.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'goods_name_check') THEN
+        ALTER TABLE goods ADD CONSTRAINT goods_name_check
+            CHECK (name = btrim(name) AND char_length(name) BETWEEN 1 AND 255) NOT VALID;
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'goods_description_check') THEN
+        ALTER TABLE goods ADD CONSTRAINT goods_description_check
+            CHECK (char_length(description) <= 2000) NOT VALID;
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'goods_priority_check') THEN
+        ALTER TABLE goods ADD CONSTRAINT goods_priority_check
+            CHECK (priority BETWEEN 1 AND 1000000) NOT VALID;
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_goods_projectid_lower_name ON goods(project_id, lower(name)) WHERE NOT removed;
@@ -0,0 +1,16 @@
+-- Kept independent of goods so entries survive any later cleanup.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL,
+    good_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL,
+    client_ip TEXT NOT NULL,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_project_created ON audit_log(project_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_good_created ON audit_log(good_id, created_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS goods_category_idx;
+ALTER TABLE goods DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS good_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS categories;
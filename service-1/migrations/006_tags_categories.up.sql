@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES categories(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS categories_sibling_name_idx ON categories (project_id, COALESCE(parent_id, 0), lower(name));
+
+CREATE INDEX IF NOT EXISTS categories_parent_idx ON categories (parent_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS tags_project_name_idx ON tags (project_id, lower(name));
+
+CREATE TABLE IF NOT EXISTS good_tags (
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (good_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS good_tags_tag_idx ON good_tags (tag_id);
+
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS goods_category_idx ON goods (category_id);
@@ -0,0 +1,4 @@
+ALTER TABLE projects DROP COLUMN IF EXISTS attributes_schema;
+DROP INDEX IF EXISTS goods_attributes_idx;
+ALTER TABLE goods DROP CONSTRAINT IF EXISTS goods_attributes_check;
+ALTER TABLE goods DROP COLUMN IF EXISTS attributes;
@@ -0,0 +1,16 @@
+-- Free-form attributes of goods, see entity.AttributesMaxSize.
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'goods_attributes_check') THEN
+        ALTER TABLE goods ADD CONSTRAINT goods_attributes_check
+            CHECK (jsonb_typeof(attributes) = 'object');
+    END IF;
+END $$;
+
+-- jsonb_path_ops serves the containment filters of /goods/list
+CREATE INDEX IF NOT EXISTS goods_attributes_idx ON goods USING GIN (attributes jsonb_path_ops);
+
+-- Optional JSON Schema the attributes of a project's goods must match
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS attributes_schema JSONB;
@@ -0,0 +1,51 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Text search configuration used to stem the project's goods and queries
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS search_language regconfig NOT NULL DEFAULT 'russian';
+
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS search tsvector;
+
+-- Names weigh more than descriptions. The 'simple' configuration keeps words
+-- of other languages searchable, unstemmed.
+CREATE OR REPLACE FUNCTION goods_search_vector(lang regconfig, name text, description text) RETURNS tsvector
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT setweight(to_tsvector(lang, coalesce(name, '')), 'A') ||
+           setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+           setweight(to_tsvector(lang, coalesce(description, '')), 'B') ||
+           setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+$$;
+
+CREATE OR REPLACE FUNCTION goods_search_update() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.search := goods_search_vector(
+        (SELECT search_language FROM projects WHERE id = NEW.project_id), NEW.name, NEW.description);
+    RETURN NEW;
+END $$;
+
+DROP TRIGGER IF EXISTS goods_search_update ON goods;
+CREATE TRIGGER goods_search_update BEFORE INSERT OR UPDATE OF name, description, project_id ON goods
+    FOR EACH ROW EXECUTE FUNCTION goods_search_update();
+
+-- Changing a project's language re-stems its goods
+CREATE OR REPLACE FUNCTION projects_search_language_update() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    UPDATE goods SET search = goods_search_vector(NEW.search_language, name, description)
+    WHERE project_id = NEW.id;
+    RETURN NEW;
+END $$;
+
+DROP TRIGGER IF EXISTS projects_search_language_update ON projects;
+CREATE TRIGGER projects_search_language_update AFTER UPDATE OF search_language ON projects
+    FOR EACH ROW WHEN (OLD.search_language IS DISTINCT FROM NEW.search_language)
+    EXECUTE FUNCTION projects_search_language_update();
+
+UPDATE goods g SET search = goods_search_vector(p.search_language, g.name, g.description)
+FROM projects p
+WHERE p.id = g.project_id AND g.search IS NULL;
+
+CREATE INDEX IF NOT EXISTS goods_search_idx ON goods USING GIN (search);
+
+-- Typo tolerant matching of names
+CREATE INDEX IF NOT EXISTS goods_name_trgm_idx ON goods USING GIN (name gin_trgm_ops);
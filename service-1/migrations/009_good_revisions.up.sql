@@ -0,0 +1,28 @@
+-- Every change to a good stores its resulting state as the next revision.
+CREATE TABLE IF NOT EXISTS good_revisions (
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    project_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    good JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (good_id, revision)
+);
+
+-- Goods created before revisions were kept start from their current state
+INSERT INTO good_revisions(good_id, revision, project_id, action, actor, good)
+SELECT g.id, 1, g.project_id, 'create', '', jsonb_build_object(
+           'id', g.id,
+           'project_id', g.project_id,
+           'name', g.name,
+           'description', COALESCE(g.description, ''),
+           'priority', g.priority,
+           'removed', g.removed,
+           'created_at', g.created_at,
+           'category_id', g.category_id,
+           'tags', COALESCE((SELECT jsonb_agg(t.name ORDER BY t.name) FROM good_tags gt JOIN tags t ON t.id = gt.tag_id
+                             WHERE gt.good_id = g.id), '[]'),
+           'attributes', g.attributes)
+FROM goods g
+WHERE NOT EXISTS (SELECT 1 FROM good_revisions r WHERE r.good_id = g.id);
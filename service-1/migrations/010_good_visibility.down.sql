@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS visibility_schedule;
+DROP INDEX IF EXISTS goods_visible_until_idx;
+DROP INDEX IF EXISTS goods_visible_from_idx;
+ALTER TABLE goods DROP CONSTRAINT IF EXISTS goods_visibility_check;
+ALTER TABLE goods DROP COLUMN IF EXISTS visible_until;
+ALTER TABLE goods DROP COLUMN IF EXISTS visible_from;
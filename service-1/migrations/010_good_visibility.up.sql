@@ -0,0 +1,23 @@
+-- Optional window in which a good is shown, see entity.Visibility.
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS visible_from TIMESTAMPTZ;
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS visible_until TIMESTAMPTZ;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'goods_visibility_check') THEN
+        ALTER TABLE goods ADD CONSTRAINT goods_visibility_check
+            CHECK (visible_from IS NULL OR visible_until IS NULL OR visible_from < visible_until);
+    END IF;
+END $$;
+
+-- The scheduler looks for boundaries crossed since its last run
+CREATE INDEX IF NOT EXISTS goods_visible_from_idx ON goods (visible_from) WHERE visible_from IS NOT NULL;
+CREATE INDEX IF NOT EXISTS goods_visible_until_idx ON goods (visible_until) WHERE visible_until IS NOT NULL;
+
+-- A single row holding the time up to which boundaries were announced
+CREATE TABLE IF NOT EXISTS visibility_schedule (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    checked_at TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO visibility_schedule(checked_at) VALUES (NOW()) ON CONFLICT DO NOTHING;
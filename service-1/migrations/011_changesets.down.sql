@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS changeset_changes;
+DROP TABLE IF EXISTS changesets;
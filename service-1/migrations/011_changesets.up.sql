@@ -0,0 +1,25 @@
+-- Drafts of changes to a project's goods, see entity.Changeset.
+CREATE TABLE IF NOT EXISTS changesets (
+    id SERIAL PRIMARY KEY,
+    project_id INTEGER NOT NULL REFERENCES projects(id),
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'discarded')),
+    author TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS changesets_drafts_idx ON changesets (project_id) WHERE status = 'draft';
+
+-- Staged writes, applied in id order when the changeset is published
+CREATE TABLE IF NOT EXISTS changeset_changes (
+    id BIGSERIAL PRIMARY KEY,
+    changeset_id INTEGER NOT NULL REFERENCES changesets(id) ON DELETE CASCADE,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'remove', 'reprioritize')),
+    good_id INTEGER REFERENCES goods(id) ON DELETE CASCADE,
+    input JSONB,
+    priority INTEGER,
+    author TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS changeset_changes_changeset_idx ON changeset_changes (changeset_id, id);
@@ -0,0 +1,16 @@
+DROP POLICY IF EXISTS goods_tenant ON goods;
+ALTER TABLE goods NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE goods DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS projects_tenant ON projects;
+ALTER TABLE projects NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE projects DISABLE ROW LEVEL SECURITY;
+DROP TRIGGER IF EXISTS projects_organization_update ON projects;
+DROP FUNCTION IF EXISTS projects_organization_update();
+DROP TRIGGER IF EXISTS goods_organization_update ON goods;
+DROP FUNCTION IF EXISTS goods_organization_update();
+DROP INDEX IF EXISTS goods_organization_idx;
+ALTER TABLE goods DROP COLUMN IF EXISTS organization_id;
+DROP INDEX IF EXISTS projects_organization_idx;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE projects DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organizations;
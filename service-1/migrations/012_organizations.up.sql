@@ -0,0 +1,65 @@
+-- Tenants owning projects, see entity.Organization. Existing projects and
+-- API keys are given to a default organization.
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO organizations(id, name) VALUES (1, 'default') ON CONFLICT DO NOTHING;
+SELECT setval(pg_get_serial_sequence('organizations', 'id'), GREATEST((SELECT MAX(id) FROM organizations), 1));
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS organization_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS organization_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+
+CREATE INDEX IF NOT EXISTS projects_organization_idx ON projects (organization_id);
+
+-- Goods carry their project's organization so that row level security and
+-- tenant filters need no join
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS organization_id INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS goods_organization_idx ON goods (organization_id);
+
+CREATE OR REPLACE FUNCTION goods_organization_update() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.organization_id := (SELECT organization_id FROM projects WHERE id = NEW.project_id);
+    RETURN NEW;
+END $$;
+
+DROP TRIGGER IF EXISTS goods_organization_update ON goods;
+CREATE TRIGGER goods_organization_update BEFORE INSERT OR UPDATE OF project_id, organization_id ON goods
+    FOR EACH ROW EXECUTE FUNCTION goods_organization_update();
+
+-- Handing a project to another organization takes its goods along
+CREATE OR REPLACE FUNCTION projects_organization_update() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    UPDATE goods SET organization_id = NEW.organization_id WHERE project_id = NEW.id;
+    RETURN NULL;
+END $$;
+
+DROP TRIGGER IF EXISTS projects_organization_update ON projects;
+CREATE TRIGGER projects_organization_update AFTER UPDATE OF organization_id ON projects
+    FOR EACH ROW WHEN (OLD.organization_id IS DISTINCT FROM NEW.organization_id)
+    EXECUTE FUNCTION projects_organization_update();
+
+UPDATE goods g SET organization_id = p.organization_id
+FROM projects p
+WHERE p.id = g.project_id AND g.organization_id <> p.organization_id;
+
+-- Defense in depth: a transaction sees and writes only the projects and
+-- goods of the organization in app.organization_id, every organization's
+-- when it is '*', and none while it is missing or empty. See beginTx. Roles
+-- with BYPASSRLS, superusers included, are not restricted at all.
+ALTER TABLE projects ENABLE ROW LEVEL SECURITY;
+ALTER TABLE projects FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS projects_tenant ON projects;
+CREATE POLICY projects_tenant ON projects
+    USING (COALESCE(current_setting('app.organization_id', true), '') IN ('*', organization_id::text));
+
+ALTER TABLE goods ENABLE ROW LEVEL SECURITY;
+ALTER TABLE goods FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS goods_tenant ON goods;
+CREATE POLICY goods_tenant ON goods
+    USING (COALESCE(current_setting('app.organization_id', true), '') IN ('*', organization_id::text));
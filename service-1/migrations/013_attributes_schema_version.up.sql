@@ -0,0 +1,3 @@
+-- Bumped with every change of attributes_schema, so replicas can cache the
+-- compiled schema by project and version.
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS attributes_schema_version BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,29 @@
+DROP POLICY IF EXISTS audit_log_tenant ON audit_log;
+ALTER TABLE audit_log NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE audit_log DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS changeset_changes_tenant ON changeset_changes;
+ALTER TABLE changeset_changes NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE changeset_changes DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS changesets_tenant ON changesets;
+ALTER TABLE changesets NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE changesets DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS webhook_deliveries_tenant ON webhook_deliveries;
+ALTER TABLE webhook_deliveries NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS webhooks_tenant ON webhooks;
+ALTER TABLE webhooks NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE webhooks DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS categories_tenant ON categories;
+ALTER TABLE categories NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE categories DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tags_tenant ON tags;
+ALTER TABLE tags NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE tags DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS good_tags_tenant ON good_tags;
+ALTER TABLE good_tags NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE good_tags DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS good_revisions_tenant ON good_revisions;
+ALTER TABLE good_revisions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE good_revisions DISABLE ROW LEVEL SECURITY;
+
+DROP FUNCTION IF EXISTS app_project_visible(integer);
@@ -0,0 +1,13 @@
+-- The role an API key has on each of its projects. Keys issued before the
+-- column existed keep the admin role they were granted; new keys default
+-- to editor.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'admin';
+ALTER TABLE api_keys ALTER COLUMN role SET DEFAULT 'editor';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'api_keys_role_check') THEN
+        ALTER TABLE api_keys ADD CONSTRAINT api_keys_role_check
+            CHECK (role IN ('viewer', 'editor', 'admin'));
+    END IF;
+END $$;
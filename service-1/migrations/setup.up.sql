@@ -5,7 +5,8 @@ CREATE TABLE IF NOT EXISTS projects (
 );
 
 INSERT INTO projects(name, created_at)
-VALUES('Первая запись', NOW());
+SELECT 'Первая запись', NOW()
+WHERE NOT EXISTS (SELECT 1 FROM projects);
 
 CREATE TABLE IF NOT EXISTS goods (
     id SERIAL PRIMARY KEY,
@@ -18,4 +19,4 @@ CREATE TABLE IF NOT EXISTS goods (
     FOREIGN KEY (project_id) REFERENCES projects(id)
 );
 
-CREATE INDEX idx_goods_id_projectid_name ON goods(id, project_id, name);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_goods_id_projectid_name ON goods(id, project_id, name);
\ No newline at end of file
@@ -0,0 +1,231 @@
+// Package jsonschema validates JSON documents against JSON Schema, draft
+// 2020-12 unless the schema names another draft with $schema.
+//
+// It wraps github.com/santhosh-tekuri/jsonschema and flattens its errors to
+// one Error per failed keyword. Patterns use ECMAScript regular expressions
+// as the specification requires, formats are asserted, and $ref can only
+// point inside the schema: nothing is loaded from files or the network.
+package jsonschema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlclark/regexp2"
+	js "github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+)
+
+// matchTimeout bounds a single pattern match; ECMAScript patterns backtrack.
+const matchTimeout = 100 * time.Millisecond
+
+// resource names the schema being compiled. It is hierarchical so that
+// relative references resolve to other documents, which then fail to load.
+const resource = "https://schemas.hezzl.invalid/attributes.json"
+
+// Schema is a compiled schema, safe for concurrent use.
+type Schema struct {
+	schema *js.Schema
+}
+
+// Error is a single failed keyword. Path is the dotted location of the
+// value, with array indexes as numbers; it is empty for the document root.
+type Error struct {
+	Path  string
+	Rule  string
+	Param string
+}
+
+func (e Error) Error() string {
+	if e.Path == "" {
+		return e.Rule
+	}
+
+	return e.Path + ": " + e.Rule
+}
+
+// Compile parses a schema document.
+func Compile(data []byte) (*Schema, error) {
+	doc, err := js.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("schema is not JSON: %w", err)
+	}
+
+	c := js.NewCompiler()
+	c.DefaultDraft(js.Draft2020)
+	c.AssertFormat()
+	c.UseRegexpEngine(ecmaScript)
+	// Without schemes every reference outside the document fails
+	c.UseLoader(js.SchemeURLLoader{})
+
+	if err := c.AddResource(resource, doc); err != nil {
+		return nil, err
+	}
+
+	schema, err := c.Compile(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Schema{schema: schema}, nil
+}
+
+// Validate checks a JSON document and returns every failed keyword, sorted
+// by path. The error is only set when data is not JSON.
+func (s *Schema) Validate(data []byte) ([]Error, error) {
+	doc, err := js.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.schema.Validate(doc)
+	if err == nil {
+		return nil, nil
+	}
+
+	var validation *js.ValidationError
+	if !errors.As(err, &validation) {
+		return nil, err
+	}
+
+	var errs []Error
+	flatten(validation, &errs)
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Path != errs[j].Path {
+			return errs[i].Path < errs[j].Path
+		}
+
+		return errs[i].Rule < errs[j].Rule
+	})
+
+	return errs, nil
+}
+
+// flatten appends the failed keywords under e. A failed combinator is
+// reported as such, since the failures of its branches contradict each
+// other.
+func flatten(e *js.ValidationError, errs *[]Error) {
+	path := strings.Join(e.InstanceLocation, ".")
+
+	switch k := e.ErrorKind.(type) {
+	case *kind.Schema, *kind.Group, *kind.AllOf, *kind.Reference:
+		for _, cause := range e.Causes {
+			flatten(cause, errs)
+		}
+	case *kind.AnyOf:
+		*errs = append(*errs, Error{Path: path, Rule: "anyOf"})
+	case *kind.OneOf:
+		*errs = append(*errs, Error{Path: path, Rule: "oneOf"})
+	case *kind.Not:
+		*errs = append(*errs, Error{Path: path, Rule: "not"})
+	case *kind.FalseSchema:
+		*errs = append(*errs, Error{Path: path, Rule: "false"})
+	case *kind.Required:
+		for _, name := range k.Missing {
+			*errs = append(*errs, Error{Path: join(path, name), Rule: "required"})
+		}
+	case *kind.DependentRequired:
+		for _, name := range k.Missing {
+			*errs = append(*errs, Error{Path: join(path, name), Rule: "dependentRequired", Param: k.Prop})
+		}
+	case *kind.AdditionalProperties:
+		for _, name := range k.Properties {
+			*errs = append(*errs, Error{Path: join(path, name), Rule: "additionalProperties"})
+		}
+	default:
+		rule := "schema"
+		if keywords := k.KeywordPath(); len(keywords) != 0 {
+			rule = keywords[0]
+		}
+
+		*errs = append(*errs, Error{Path: path, Rule: rule, Param: param(k)})
+	}
+}
+
+// param is the expected value of a failed keyword, when it has a short one.
+func param(k js.ErrorKind) string {
+	switch k := k.(type) {
+	case *kind.Type:
+		return strings.Join(k.Want, " ")
+	case *kind.Format:
+		return k.Want
+	case *kind.Pattern:
+		return k.Want
+	case *kind.MinLength:
+		return strconv.Itoa(k.Want)
+	case *kind.MaxLength:
+		return strconv.Itoa(k.Want)
+	case *kind.MinItems:
+		return strconv.Itoa(k.Want)
+	case *kind.MaxItems:
+		return strconv.Itoa(k.Want)
+	case *kind.MinProperties:
+		return strconv.Itoa(k.Want)
+	case *kind.MaxProperties:
+		return strconv.Itoa(k.Want)
+	case *kind.Minimum:
+		return number(k.Want)
+	case *kind.Maximum:
+		return number(k.Want)
+	case *kind.ExclusiveMinimum:
+		return number(k.Want)
+	case *kind.ExclusiveMaximum:
+		return number(k.Want)
+	case *kind.MultipleOf:
+		return number(k.Want)
+	}
+
+	return ""
+}
+
+func number(r *big.Rat) string {
+	if r.IsInt() {
+		return r.RatString()
+	}
+
+	f, _ := r.Float64()
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// ecmaScript compiles patterns with ECMAScript semantics.
+func ecmaScript(pattern string) (js.Regexp, error) {
+	re, err := regexp2.Compile(pattern, regexp2.ECMAScript)
+	if err != nil {
+		return nil, err
+	}
+
+	re.MatchTimeout = matchTimeout
+
+	return ecmaRegexp{re}, nil
+}
+
+type ecmaRegexp struct {
+	re *regexp2.Regexp
+}
+
+func (r ecmaRegexp) String() string {
+	return r.re.String()
+}
+
+// MatchString treats a match that timed out as a mismatch.
+func (r ecmaRegexp) MatchString(s string) bool {
+	ok, err := r.re.MatchString(s)
+
+	return err == nil && ok
+}
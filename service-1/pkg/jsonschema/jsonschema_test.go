@@ -0,0 +1,198 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const productSchema = `{
+	"type": "object",
+	"required": ["sku", "price"],
+	"additionalProperties": false,
+	"properties": {
+		"sku": {"type": "string", "pattern": "^[A-Z]{3}-[0-9]+$"},
+		"price": {"type": "number", "exclusiveMinimum": 0},
+		"stock": {"type": "integer", "minimum": 0, "maximum": 100000},
+		"color": {"enum": ["red", "green", "blue"]},
+		"images": {"type": "array", "maxItems": 2, "items": {"type": "string", "format": "uri"}}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	schema, err := Compile([]byte(productSchema))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		want []Error
+	}{
+		{
+			name: "valid",
+			doc:  `{"sku": "ABC-1", "price": 9.99, "stock": 3, "color": "red", "images": ["https://cdn.example.com/1.png"]}`,
+		},
+		{
+			name: "missing required",
+			doc:  `{"sku": "ABC-1"}`,
+			want: []Error{{Path: "price", Rule: "required"}},
+		},
+		{
+			name: "wrong types",
+			doc:  `{"sku": 1, "price": "free", "stock": 1.5}`,
+			want: []Error{
+				{Path: "price", Rule: "type", Param: "number"},
+				{Path: "sku", Rule: "type", Param: "string"},
+				{Path: "stock", Rule: "type", Param: "integer"},
+			},
+		},
+		{
+			name: "bounds and formats",
+			doc:  `{"sku": "abc", "price": 0, "stock": 100001, "color": "pink", "images": ["a", "b", "c"], "extra": true}`,
+			want: []Error{
+				{Path: "color", Rule: "enum"},
+				{Path: "extra", Rule: "additionalProperties"},
+				{Path: "images", Rule: "maxItems", Param: "2"},
+				{Path: "images.0", Rule: "format", Param: "uri"},
+				{Path: "images.1", Rule: "format", Param: "uri"},
+				{Path: "images.2", Rule: "format", Param: "uri"},
+				{Path: "price", Rule: "exclusiveMinimum", Param: "0"},
+				{Path: "sku", Rule: "pattern", Param: "^[A-Z]{3}-[0-9]+$"},
+				{Path: "stock", Rule: "maximum", Param: "100000"},
+			},
+		},
+		{
+			name: "not an object",
+			doc:  `[1, 2]`,
+			want: []Error{{Rule: "type", Param: "object"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := schema.Validate([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileRejectsInvalidSchemas(t *testing.T) {
+	for _, doc := range []string{
+		`{"$ref": "#/$defs/price"}`,
+		`{"$ref": "https://example.com/schema.json"}`,
+		`{"$ref": "file:///etc/passwd"}`,
+		`{"properties": {"price": {"$ref": "price.json"}}}`,
+		`{"type": "decimal"}`,
+		`{"pattern": "("}`,
+		`"object"`,
+		`{"type": "object"`,
+	} {
+		if _, err := Compile([]byte(doc)); err == nil {
+			t.Errorf("Compile(%s) succeeded, want an error", doc)
+		}
+	}
+}
+
+func TestValidateReferencesAndCombinators(t *testing.T) {
+	schema, err := Compile([]byte(`{
+		"$defs": {"money": {"type": "number", "minimum": 0}},
+		"type": "object",
+		"properties": {
+			"price": {"$ref": "#/$defs/money"},
+			"size": {"anyOf": [{"enum": ["S", "M", "L"]}, {"type": "integer", "minimum": 1}]},
+			"code": {"oneOf": [{"type": "string", "pattern": "^[0-9]+$"}, {"type": "string", "maxLength": 2}]},
+			"tag": {"not": {"const": "sale"}},
+			"password": {"type": "string", "pattern": "^(?=.*[A-Z])(?=.*[0-9]).{8,}$"}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		want []Error
+	}{
+		{
+			name: "valid",
+			doc:  `{"price": 2.5, "size": "M", "code": "123", "tag": "new", "password": "Secret123"}`,
+		},
+		{
+			name: "reference",
+			doc:  `{"price": -1}`,
+			want: []Error{{Path: "price", Rule: "minimum", Param: "0"}},
+		},
+		{
+			name: "combinators",
+			doc:  `{"size": "XL", "code": "12", "tag": "sale"}`,
+			want: []Error{
+				{Path: "code", Rule: "oneOf"},
+				{Path: "size", Rule: "anyOf"},
+				{Path: "tag", Rule: "not"},
+			},
+		},
+		{
+			name: "ecmascript lookahead",
+			doc:  `{"password": "secret123"}`,
+			want: []Error{{Path: "password", Rule: "pattern", Param: "^(?=.*[A-Z])(?=.*[0-9]).{8,}$"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := schema.Validate([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBoundsBacktracking(t *testing.T) {
+	schema, err := Compile([]byte(`{"type": "string", "pattern": "^(a+)+$"}`))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+
+	start := time.Now()
+
+	got, err := schema.Validate([]byte(`"` + strings.Repeat("a", 40) + `!"`))
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	if len(got) != 1 || got[0].Rule != "pattern" {
+		t.Errorf("errors = %v, want a pattern failure", got)
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*matchTimeout {
+		t.Errorf("Validate took %s", elapsed)
+	}
+}
+
+func TestValidateRejectsMalformedDocuments(t *testing.T) {
+	schema, err := Compile([]byte(`{"type": "object"}`))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+
+	for _, doc := range []string{`{`, `{} {}`, ``} {
+		if _, err := schema.Validate([]byte(doc)); err == nil {
+			t.Errorf("Validate(%q) succeeded, want an error", doc)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// allowedAlgorithms excludes HMAC so a public key can never act as a secret.
+var allowedAlgorithms = map[string]bool{
+	string(jose.RS256): true, string(jose.RS384): true, string(jose.RS512): true,
+	string(jose.PS256): true, string(jose.PS384): true, string(jose.PS512): true,
+	string(jose.ES256): true, string(jose.ES384): true, string(jose.ES512): true,
+	string(jose.EdDSA): true,
+}
+
+type Options struct {
+	// JWKS is a local file path or an http(s) URL
+	JWKS     string
+	Issuer   string
+	Audience string
+	// Refresh is how often a remote JWKS may be refetched
+	Refresh time.Duration
+}
+
+// Verifier checks bearer tokens against a JSON Web Key Set.
+type Verifier struct {
+	opts   Options
+	client *http.Client
+
+	mu      sync.RWMutex
+	keys    jose.JSONWebKeySet
+	fetched time.Time
+}
+
+func NewVerifier(ctx context.Context, opts Options) (*Verifier, error) {
+	v := &Verifier{
+		opts:   opts,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+
+	if err := v.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Verify checks the signature and standard claims of token and decodes the
+// remaining claims into custom.
+func (v *Verifier) Verify(ctx context.Context, token string, custom any) (jwt.Claims, error) {
+	var claims jwt.Claims
+
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return claims, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if len(parsed.Headers) != 1 || !allowedAlgorithms[parsed.Headers[0].Algorithm] {
+		return claims, fmt.Errorf("%w: unsupported algorithm", ErrInvalidToken)
+	}
+
+	key, err := v.key(ctx, parsed.Headers[0].KeyID)
+	if err != nil {
+		return claims, err
+	}
+
+	if err := parsed.Claims(key.Key, &claims, custom); err != nil {
+		return claims, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	expected := jwt.Expected{Issuer: v.opts.Issuer, Time: time.Now()}
+	if v.opts.Audience != "" {
+		expected.Audience = jwt.Audience{v.opts.Audience}
+	}
+
+	if err := claims.ValidateWithLeeway(expected, time.Minute); err != nil {
+		return claims, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return claims, nil
+}
+
+// key finds the key by id and refetches a remote set once per refresh
+// interval when the id is unknown, which covers key rotation.
+func (v *Verifier) key(ctx context.Context, kid string) (jose.JSONWebKey, error) {
+	if key, ok := v.lookup(kid); ok {
+		return key, nil
+	}
+
+	// The attempt is recorded before it is made, so that a burst of tokens
+	// with unknown key ids, or an unreachable JWKS, costs one fetch per
+	// interval rather than one per request
+	v.mu.Lock()
+	stale := v.remote() && time.Since(v.fetched) > v.opts.Refresh
+	if stale {
+		v.fetched = time.Now()
+	}
+	v.mu.Unlock()
+
+	if stale {
+		// The token cannot be verified either way, which is the caller's
+		// problem rather than a server error
+		if err := v.load(ctx); err != nil {
+			return jose.JSONWebKey{}, fmt.Errorf("%w: unknown key id %q: %v", ErrInvalidToken, kid, err)
+		}
+
+		if key, ok := v.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	return jose.JSONWebKey{}, fmt.Errorf("%w: unknown key id %q", ErrInvalidToken, kid)
+}
+
+func (v *Verifier) lookup(kid string) (jose.JSONWebKey, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if kid == "" && len(v.keys.Keys) == 1 {
+		return v.keys.Keys[0], true
+	}
+
+	keys := v.keys.Key(kid)
+	if len(keys) == 0 {
+		return jose.JSONWebKey{}, false
+	}
+
+	return keys[0], true
+}
+
+func (v *Verifier) remote() bool {
+	return strings.HasPrefix(v.opts.JWKS, "http://") || strings.HasPrefix(v.opts.JWKS, "https://")
+}
+
+func (v *Verifier) load(ctx context.Context) error {
+	var (
+		data []byte
+		err  error
+	)
+
+	if v.remote() {
+		data, err = v.fetch(ctx)
+	} else {
+		data, err = os.ReadFile(v.opts.JWKS)
+	}
+	if err != nil {
+		return fmt.Errorf("jwks load error: %w", err)
+	}
+
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("jwks decode error: %w", err)
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetched = time.Now()
+	v.mu.Unlock()
+
+	return nil
+}
+
+func (v *Verifier) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.opts.JWKS, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
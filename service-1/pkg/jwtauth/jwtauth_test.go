@@ -0,0 +1,296 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+const (
+	issuer   = "https://issuer.example"
+	audience = "hezzl"
+)
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+// keySet encodes the public halves of keys under their ids.
+func keySet(t *testing.T, keys map[string]*ecdsa.PrivateKey) []byte {
+	t.Helper()
+
+	var set jose.JSONWebKeySet
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, jose.JSONWebKey{Key: &key.PublicKey, KeyID: kid, Algorithm: string(jose.ES256), Use: "sig"})
+	}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+// sign issues a token for claims; an empty kid leaves the header out.
+func sign(t *testing.T, alg jose.SignatureAlgorithm, key any, kid string, claims jwt.Claims) string {
+	t.Helper()
+
+	signingKey := jose.SigningKey{Algorithm: alg, Key: key}
+	if kid != "" {
+		signingKey.Key = jose.JSONWebKey{Key: key, KeyID: kid}
+	}
+
+	signer, err := jose.NewSigner(signingKey, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func validClaims() jwt.Claims {
+	now := time.Now()
+
+	return jwt.Claims{
+		Subject:  "user",
+		Issuer:   issuer,
+		Audience: jwt.Audience{audience},
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	a, b, stranger := newKey(t), newKey(t), newKey(t)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, keySet(t, map[string]*ecdsa.PrivateKey{"a": a, "b": b}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewVerifier(context.Background(), Options{JWKS: path, Issuer: issuer, Audience: audience})
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	with := func(change func(*jwt.Claims)) jwt.Claims {
+		claims := validClaims()
+		change(&claims)
+
+		return claims
+	}
+
+	// none is refused by the parser already; build it by hand
+	encode := func(v any) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	unsigned := encode(map[string]string{"alg": "none", "kid": "a"}) + "." + encode(validClaims()) + "."
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "first key", token: sign(t, jose.ES256, a, "a", validClaims())},
+		{name: "second key", token: sign(t, jose.ES256, b, "b", validClaims())},
+		{name: "key of another id", token: sign(t, jose.ES256, a, "b", validClaims()), wantErr: true},
+		{name: "unknown key id", token: sign(t, jose.ES256, a, "c", validClaims()), wantErr: true},
+		{name: "no key id among several keys", token: sign(t, jose.ES256, a, "", validClaims()), wantErr: true},
+		{name: "foreign key", token: sign(t, jose.ES256, stranger, "a", validClaims()), wantErr: true},
+		{name: "HMAC", token: sign(t, jose.HS256, []byte("a shared secret of enough length"), "a", validClaims()), wantErr: true},
+		{name: "none", token: unsigned, wantErr: true},
+		{name: "malformed", token: "not.a.token", wantErr: true},
+		{name: "other issuer", token: sign(t, jose.ES256, a, "a", with(func(c *jwt.Claims) { c.Issuer = "https://evil.example" })), wantErr: true},
+		{name: "other audience", token: sign(t, jose.ES256, a, "a", with(func(c *jwt.Claims) { c.Audience = jwt.Audience{"other"} })), wantErr: true},
+		{name: "no audience", token: sign(t, jose.ES256, a, "a", with(func(c *jwt.Claims) { c.Audience = nil })), wantErr: true},
+		{
+			name:    "expired",
+			token:   sign(t, jose.ES256, a, "a", with(func(c *jwt.Claims) { c.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Hour)) })),
+			wantErr: true,
+		},
+		{
+			name:  "expired within the leeway",
+			token: sign(t, jose.ES256, a, "a", with(func(c *jwt.Claims) { c.Expiry = jwt.NewNumericDate(time.Now().Add(-30 * time.Second)) })),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var custom map[string]any
+
+			claims, err := v.Verify(context.Background(), tt.token, &custom)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("Verify() error = %v, want %v", err, ErrInvalidToken)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			if claims.Subject != "user" {
+				t.Errorf("subject = %q, want %q", claims.Subject, "user")
+			}
+		})
+	}
+}
+
+func TestVerifySingleKeyWithoutID(t *testing.T) {
+	key := newKey(t)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, keySet(t, map[string]*ecdsa.PrivateKey{"only": key}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without an audience configured any audience is accepted
+	v, err := NewVerifier(context.Background(), Options{JWKS: path, Issuer: issuer})
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	var custom map[string]any
+	if _, err := v.Verify(context.Background(), sign(t, jose.ES256, key, "", validClaims()), &custom); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+// jwksServer serves a key set that the test may replace or break, counting
+// the requests.
+type jwksServer struct {
+	mu       sync.Mutex
+	set      []byte
+	failing  bool
+	requests int
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	if s.failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		return
+	}
+
+	w.Write(s.set)
+}
+
+func (s *jwksServer) update(change func(*jwksServer)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change(s)
+}
+
+func (s *jwksServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+func TestVerifyRefetchesRemoteKeys(t *testing.T) {
+	a, b := newKey(t), newKey(t)
+
+	jwks := &jwksServer{set: keySet(t, map[string]*ecdsa.PrivateKey{"a": a})}
+	server := httptest.NewServer(jwks)
+	defer server.Close()
+
+	v, err := NewVerifier(context.Background(), Options{JWKS: server.URL, Issuer: issuer, Audience: audience, Refresh: time.Hour})
+	if err != nil {
+		t.Fatalf("NewVerifier() error = %v", err)
+	}
+
+	// expire makes the next unknown key id due for a refetch
+	expire := func() {
+		v.mu.Lock()
+		v.fetched = time.Now().Add(-2 * time.Hour)
+		v.mu.Unlock()
+	}
+
+	rotated := sign(t, jose.ES256, b, "b", validClaims())
+
+	steps := []struct {
+		name         string
+		before       func()
+		wantErr      bool
+		wantRequests int
+	}{
+		{
+			name: "unknown key id within the interval",
+			before: func() {
+				jwks.update(func(s *jwksServer) { s.set = keySet(t, map[string]*ecdsa.PrivateKey{"a": a, "b": b}) })
+			},
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{name: "rotated key after the interval", before: expire, wantRequests: 2},
+		{
+			name: "unreachable key set",
+			before: func() {
+				jwks.update(func(s *jwksServer) { s.failing = true })
+				expire()
+				v.mu.Lock()
+				v.keys = jose.JSONWebKeySet{}
+				v.mu.Unlock()
+			},
+			wantErr:      true,
+			wantRequests: 3,
+		},
+		{name: "failed fetch counts as an attempt", before: func() {}, wantErr: true, wantRequests: 3},
+	}
+
+	for _, step := range steps {
+		step.before()
+
+		var custom map[string]any
+
+		_, err := v.Verify(context.Background(), rotated, &custom)
+		if step.wantErr && !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Verify() error = %v, want %v", step.name, err, ErrInvalidToken)
+		}
+
+		if !step.wantErr && err != nil {
+			t.Errorf("%s: Verify() error = %v", step.name, err)
+		}
+
+		if got := jwks.count(); got != step.wantRequests {
+			t.Errorf("%s: %d requests for the key set, want %d", step.name, got, step.wantRequests)
+		}
+	}
+}
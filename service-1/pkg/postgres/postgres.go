@@ -4,13 +4,16 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/XSAM/otelsql"
 	"github.com/skantay/hezzl/config"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 
 	_ "github.com/lib/pq"
 )
 
 func ConnectPostgres(cfg config.Config) (*sql.DB, error) {
-	db, err := sql.Open("postgres", fmt.Sprintf(`
+	// Every query is wrapped in a span when a tracer provider is installed
+	db, err := otelsql.Open("postgres", fmt.Sprintf(`
 		user=%s
 		password=%s
 		dbname=%s
@@ -23,7 +26,7 @@ func ConnectPostgres(cfg config.Config) (*sql.DB, error) {
 		cfg.Database.Postgres.Host,
 		cfg.Database.Postgres.Port,
 		cfg.Database.Postgres.SSLMode,
-	))
+	), otelsql.WithAttributes(semconv.DBSystemPostgreSQL))
 	if err != nil {
 		return nil, fmt.Errorf("sql open error: %w", err)
 	}
@@ -0,0 +1,167 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second up to Burst.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result describes the state of a bucket after a request was counted.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long to wait for the next token when not allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) Result
+}
+
+// tokenBucket takes one token from the bucket in KEYS[1]. The Redis clock is
+// used so that replicas with skewed clocks share one notion of time.
+var tokenBucket = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+
+return {allowed, math.floor(tokens), retry, math.ceil((burst - tokens) / rate * 1000)}
+`)
+
+type redisLimiter struct {
+	client   *redis.Client
+	fallback Limiter
+}
+
+// NewRedis keeps bucket state in Redis so limits hold across replicas and
+// falls back to a per-process limiter while Redis is unavailable.
+func NewRedis(client *redis.Client) Limiter {
+	return redisLimiter{client: client, fallback: NewMemory()}
+}
+
+func (r redisLimiter) Allow(ctx context.Context, key string, limit Limit) Result {
+	reply, err := tokenBucket.Run(r.client, []string{key}, limit.Rate, limit.Burst).Result()
+	if err != nil {
+		return r.fallback.Allow(ctx, key, limit)
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 4 {
+		return r.fallback.Allow(ctx, key, limit)
+	}
+
+	ints := make([]int64, len(values))
+	for i, v := range values {
+		ints[i], _ = v.(int64)
+	}
+
+	return Result{
+		Allowed:    ints[0] == 1,
+		Limit:      limit.Burst,
+		Remaining:  int(ints[1]),
+		RetryAfter: time.Duration(ints[2]) * time.Millisecond,
+		Reset:      time.Duration(ints[3]) * time.Millisecond,
+	}
+}
+
+type bucket struct {
+	tokens float64
+	ts     time.Time
+}
+
+type memoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+// NewMemory keeps bucket state in process memory.
+func NewMemory() Limiter {
+	return newMemory(time.Now)
+}
+
+func newMemory(now func() time.Time) *memoryLimiter {
+	return &memoryLimiter{buckets: make(map[string]*bucket), swept: now(), now: now}
+}
+
+func (m *memoryLimiter) Allow(ctx context.Context, key string, limit Limit) Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), ts: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.ts).Seconds()*limit.Rate)
+	b.ts = now
+
+	result := Result{Limit: limit.Burst}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.Rate)
+
+	return result
+}
+
+// sweep drops buckets idle for ten minutes to bound memory use.
+func (m *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.swept) < time.Minute {
+		return
+	}
+
+	for key, b := range m.buckets {
+		if now.Sub(b.ts) > 10*time.Minute {
+			delete(m.buckets, key)
+		}
+	}
+
+	m.swept = now
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
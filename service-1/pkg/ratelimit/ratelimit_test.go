@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// clock is a time source the tests move by hand.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 3}
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		key     string
+		want    Result
+	}{
+		{name: "full bucket", want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: 500 * time.Millisecond}},
+		{name: "second of the burst", want: Result{Allowed: true, Limit: 3, Remaining: 1, Reset: time.Second}},
+		{name: "last of the burst", want: Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 1500 * time.Millisecond}},
+		{name: "burst used up", want: Result{Limit: 3, RetryAfter: 500 * time.Millisecond, Reset: 1500 * time.Millisecond}},
+		{name: "other key has its own bucket", key: "other", want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: 500 * time.Millisecond}},
+		{name: "half a token refilled", advance: 250 * time.Millisecond, want: Result{Limit: 3, RetryAfter: 250 * time.Millisecond, Reset: 1250 * time.Millisecond}},
+		{name: "a token refilled", advance: 250 * time.Millisecond, want: Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 1500 * time.Millisecond}},
+		{name: "refill stops at the burst", advance: time.Minute, want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: 500 * time.Millisecond}},
+	}
+
+	c := &clock{now: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := newMemory(c.Now)
+
+	for _, step := range steps {
+		c.now = c.now.Add(step.advance)
+
+		key := step.key
+		if key == "" {
+			key = "client"
+		}
+
+		if got := limiter.Allow(context.Background(), key, limit); got != step.want {
+			t.Fatalf("%s: Allow() = %+v, want %+v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestRedisLimiterFallsBackToMemory(t *testing.T) {
+	// Nothing listens on port 1, so every script run fails
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond})
+	defer client.Close()
+
+	c := &clock{now: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := redisLimiter{client: client, fallback: newMemory(c.Now)}
+	limit := Limit{Rate: 1, Burst: 1}
+
+	if got := limiter.Allow(context.Background(), "client", limit); !got.Allowed {
+		t.Fatalf("first Allow() = %+v, want allowed by the fallback", got)
+	}
+
+	if got := limiter.Allow(context.Background(), "client", limit); got.Allowed || got.RetryAfter != time.Second {
+		t.Fatalf("second Allow() = %+v, want the fallback's bucket used up", got)
+	}
+
+	c.now = c.now.Add(time.Second)
+
+	if got := limiter.Allow(context.Background(), "client", limit); !got.Allowed {
+		t.Fatalf("Allow() after a second = %+v, want the fallback refilled", got)
+	}
+}
@@ -0,0 +1,181 @@
+// Package webhook signs and sends webhook deliveries.
+//
+// Receivers verify a delivery by computing HMAC-SHA256 over
+// "<timestamp>.<body>" with the shared secret and comparing it with the
+// SignatureHeader value, which has the form "sha256=<hex>".
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// ErrForbiddenAddress is returned for targets on internal networks.
+var ErrForbiddenAddress = errors.New("webhook target address is not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range, internal to providers.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Request is a single delivery attempt.
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID int64
+	Body       []byte
+}
+
+// Sign returns the SignatureHeader value for body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature matches body sent at timestamp.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+type Sender struct {
+	client  *http.Client
+	allowed []netip.Prefix
+}
+
+// NewSender returns a Sender whose attempts give up after timeout. It
+// refuses to connect to loopback, private, link-local and other internal
+// addresses unless they fall in one of the allowed networks.
+func NewSender(timeout time.Duration, allowed ...netip.Prefix) *Sender {
+	s := &Sender{allowed: allowed}
+
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		// Checking the address actually dialled also covers host names
+		// that resolve, or later rebind, to an internal address
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			addr, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+
+			return s.Check(addr)
+		},
+	}
+
+	s.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// No proxy: it would be dialled instead of the target
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 4,
+		},
+		// A redirect would resend the signed body to another host
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return s
+}
+
+// Check returns ErrForbiddenAddress when addr is internal and not allowed.
+func (s *Sender) Check(addr netip.Addr) error {
+	addr = addr.Unmap()
+
+	for _, prefix := range s.allowed {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() ||
+		sharedAddressSpace.Contains(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+
+	return nil
+}
+
+// Send posts the delivery and returns the response status code. Any status
+// outside 2xx is an error; the code is still returned when there is one.
+func (s *Sender) Send(ctx context.Context, r Request) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return 0, fmt.Errorf("trouble building webhook request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "hezzl-webhooks/1")
+	req.Header.Set(EventHeader, r.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(r.DeliveryID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(r.Secret, timestamp, r.Body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("trouble sending webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain a little so the connection can be reused
+	io.CopyN(io.Discard, resp.Body, 4<<10)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook receiver responded %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Backoff returns the wait before the given retry (1 for the first retry),
+// doubling from base and capped at max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	wait := base
+	for i := 1; i < attempt && wait < max; i++ {
+		wait *= 2
+	}
+
+	if wait > max {
+		wait = max
+	}
+
+	return wait
+}
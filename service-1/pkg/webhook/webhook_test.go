@@ -0,0 +1,153 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// loopback lets the tests reach their httptest receivers.
+var loopback = netip.MustParsePrefix("127.0.0.0/8")
+
+func TestSendSignsDelivery(t *testing.T) {
+	const secret = "s3cret"
+
+	body := []byte(`{"event":"update"}`)
+
+	received := make(chan *http.Request, 1)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		if err != nil || !Verify(secret, timestamp, payload, r.Header.Get(SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	code, err := NewSender(time.Second, loopback).Send(context.Background(), Request{
+		URL:        receiver.URL,
+		Secret:     secret,
+		Event:      "update",
+		DeliveryID: 42,
+		Body:       body,
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", code, http.StatusNoContent)
+	}
+
+	r := <-received
+
+	if got := r.Header.Get(EventHeader); got != "update" {
+		t.Errorf("%s = %q, want update", EventHeader, got)
+	}
+
+	if got := r.Header.Get(DeliveryHeader); got != "42" {
+		t.Errorf("%s = %q, want 42", DeliveryHeader, got)
+	}
+}
+
+func TestSendReportsReceiverFailure(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	code, err := NewSender(time.Second, loopback).Send(context.Background(), Request{URL: receiver.URL, Secret: "x", Body: []byte("{}")})
+	if err == nil {
+		t.Fatal("Send succeeded on a 503 response")
+	}
+
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestSendRefusesInternalAddresses(t *testing.T) {
+	called := false
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	_, err := NewSender(time.Second).Send(context.Background(), Request{URL: receiver.URL, Secret: "x", Body: []byte("{}")})
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("Send to %s: err = %v, want %v", receiver.URL, err, ErrForbiddenAddress)
+	}
+
+	if called {
+		t.Error("the receiver was reached")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	sender := NewSender(time.Second, netip.MustParsePrefix("10.1.0.0/16"))
+
+	for addr, allowed := range map[string]bool{
+		"93.184.216.34":      true,
+		"2606:2800:220:1::1": true,
+		"10.1.2.3":           true,
+		"10.2.0.1":           false,
+		"127.0.0.1":          false,
+		"::1":                false,
+		"::ffff:127.0.0.1":   false,
+		"0.0.0.0":            false,
+		"169.254.169.254":    false,
+		"fe80::1":            false,
+		"172.16.0.1":         false,
+		"192.168.1.1":        false,
+		"fd00::1":            false,
+		"100.64.0.1":         false,
+		"224.0.0.1":          false,
+	} {
+		err := sender.Check(netip.MustParseAddr(addr))
+		if got := err == nil; got != allowed {
+			t.Errorf("Check(%s) = %v, want allowed %v", addr, err, allowed)
+		}
+	}
+}
+
+func TestVerifyRejectsTamperedBody(t *testing.T) {
+	signature := Sign("secret", 1700000000, []byte(`{"a":1}`))
+
+	if Verify("secret", 1700000000, []byte(`{"a":2}`), signature) {
+		t.Error("Verify accepted a tampered body")
+	}
+
+	if Verify("other", 1700000000, []byte(`{"a":1}`), signature) {
+		t.Error("Verify accepted the wrong secret")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := 10*time.Second, time.Minute
+
+	for attempt, want := range map[int]time.Duration{
+		1: 10 * time.Second,
+		2: 20 * time.Second,
+		3: 40 * time.Second,
+		4: time.Minute,
+		9: time.Minute,
+	} {
+		if got := Backoff(attempt, base, max); got != want {
+			t.Errorf("Backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
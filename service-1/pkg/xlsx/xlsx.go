@@ -0,0 +1,140 @@
+// Package xlsx streams a single-sheet Office Open XML workbook.
+//
+// Rows are written straight into the zip stream with inline strings, so
+// memory use does not depend on the number of rows. Only strings, integers
+// and booleans are supported; that is all exports need.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var staticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// Writer writes rows of one worksheet. Close must be called to finish the
+// file.
+type Writer struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+}
+
+// NewWriter writes the workbook parts and opens the worksheet named sheet.
+func NewWriter(w io.Writer, sheet string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+
+	for _, part := range staticParts {
+		if err := writePart(zw, part.name, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	var name strings.Builder
+	if err := xml.EscapeText(&name, []byte(sheet)); err != nil {
+		return nil, fmt.Errorf("trouble escaping sheet name: %w", err)
+	}
+
+	workbook := xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	if err := writePart(zw, "xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+
+	part, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("trouble creating worksheet: %w", err)
+	}
+
+	sw := bufio.NewWriter(part)
+	sw.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	return &Writer{zw: zw, sheet: sw}, nil
+}
+
+// WriteRow appends a row. Cells may be string, int, int64 or bool.
+func (w *Writer) WriteRow(cells ...any) error {
+	w.sheet.WriteString("<row>")
+
+	for _, cell := range cells {
+		switch v := cell.(type) {
+		case string:
+			w.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(w.sheet, []byte(v)); err != nil {
+				return fmt.Errorf("trouble escaping cell: %w", err)
+			}
+			w.sheet.WriteString(`</t></is></c>`)
+		case int:
+			w.sheet.WriteString(`<c><v>` + strconv.Itoa(v) + `</v></c>`)
+		case int64:
+			w.sheet.WriteString(`<c><v>` + strconv.FormatInt(v, 10) + `</v></c>`)
+		case bool:
+			value := "0"
+			if v {
+				value = "1"
+			}
+			w.sheet.WriteString(`<c t="b"><v>` + value + `</v></c>`)
+		default:
+			return fmt.Errorf("unsupported cell type %T", cell)
+		}
+	}
+
+	_, err := w.sheet.WriteString("</row>")
+
+	return err
+}
+
+// Flush pushes buffered rows to the underlying writer.
+func (w *Writer) Flush() error {
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return w.zw.Flush()
+}
+
+// Close ends the worksheet and writes the zip directory.
+func (w *Writer) Close() error {
+	w.sheet.WriteString(`</sheetData></worksheet>`)
+
+	if err := w.sheet.Flush(); err != nil {
+		return fmt.Errorf("trouble writing worksheet: %w", err)
+	}
+
+	return w.zw.Close()
+}
+
+func writePart(zw *zip.Writer, name, content string) error {
+	part, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("trouble creating %s: %w", name, err)
+	}
+
+	if _, err := io.WriteString(part, content); err != nil {
+		return fmt.Errorf("trouble writing %s: %w", name, err)
+	}
+
+	return nil
+}
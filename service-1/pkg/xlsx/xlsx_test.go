@@ -0,0 +1,176 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// sheet is the part of a worksheet the tests read back.
+type sheet struct {
+	Rows []struct {
+		Cells []struct {
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readSheet(t *testing.T, data []byte) (map[string]bool, sheet) {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("not a zip file: %v", err)
+	}
+
+	parts := make(map[string]bool)
+
+	var ws sheet
+
+	for _, f := range zr.File {
+		parts[f.Name] = true
+
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+
+		// Every part must be well-formed XML
+		var doc struct{}
+		if err := xml.Unmarshal(content, &doc); err != nil {
+			t.Errorf("%s is not well-formed: %v", f.Name, err)
+		}
+
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			if err := xml.Unmarshal(content, &ws); err != nil {
+				t.Fatalf("worksheet: %v", err)
+			}
+		}
+	}
+
+	return parts, ws
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, "Goods & <more>")
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	if err := w.WriteRow("id", "name", "removed"); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+
+	if err := w.WriteRow(7, "<b>Tea & \"milk\"</b>", true); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+
+	if err := w.WriteRow(int64(-3), "=SUM(A1:A2)", false); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	parts, ws := readSheet(t, buf.Bytes())
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if !parts[name] {
+			t.Errorf("part %s is missing", name)
+		}
+	}
+
+	type cell struct{ Type, Text string }
+
+	var got [][]cell
+
+	for _, row := range ws.Rows {
+		var cells []cell
+		for _, c := range row.Cells {
+			text := c.Value
+			if c.Type == "inlineStr" {
+				text = c.Inline
+			}
+
+			cells = append(cells, cell{c.Type, text})
+		}
+
+		got = append(got, cells)
+	}
+
+	want := [][]cell{
+		{{"inlineStr", "id"}, {"inlineStr", "name"}, {"inlineStr", "removed"}},
+		{{"", "7"}, {"inlineStr", `<b>Tea & "milk"</b>`}, {"b", "1"}},
+		// Formulas stay text in inline strings
+		{{"", "-3"}, {"inlineStr", "=SUM(A1:A2)"}, {"b", "0"}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+}
+
+func TestWriterEmptySheet(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, "Goods")
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if _, ws := readSheet(t, buf.Bytes()); len(ws.Rows) != 0 {
+		t.Errorf("rows = %d, want 0", len(ws.Rows))
+	}
+}
+
+func TestWriteRowRejectsUnsupportedCells(t *testing.T) {
+	w, err := NewWriter(io.Discard, "Goods")
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	if err := w.WriteRow(1.5); err == nil {
+		t.Error("WriteRow accepted a float")
+	}
+}
+
+func TestFlushStreams(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, "Goods")
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	before := buf.Len()
+
+	if err := w.WriteRow("row"); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	if buf.Len() <= before {
+		t.Error("Flush wrote nothing")
+	}
+}
@@ -7,6 +7,29 @@ import (
 type Config struct {
 	Database Database `yaml:"database"`
 	Nats     Nats     `yaml:"nats"`
+	Server   Server   `yaml:"server"`
+	Tracing  Tracing  `yaml:"tracing"`
+	Log      Log      `yaml:"log"`
+}
+
+type Log struct {
+	// Format is either "json" or "console"
+	Format string `yaml:"format"`
+	Level  string `yaml:"level"`
+}
+
+type Tracing struct {
+	// Exporter is one of "otlp", "stdout" or "none"
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	ServiceName string  `yaml:"servicename"`
+	SampleRatio float64 `yaml:"sampleratio"`
+}
+
+type Server struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
 }
 
 type Nats struct {
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,8 +16,10 @@ import (
 	repository "github.com/skantay/service-2/internal/repository/clickhouse"
 	"github.com/skantay/service-2/internal/usecase"
 	"github.com/skantay/service-2/pkg/connClickhouse"
+	"github.com/skantay/service-2/pkg/logger"
+	"github.com/skantay/service-2/pkg/metrics"
 	"github.com/skantay/service-2/pkg/migrate"
-	"go.uber.org/zap"
+	"github.com/skantay/service-2/pkg/tracing"
 )
 
 func Run() error {
@@ -25,14 +28,18 @@ func Run() error {
 		return err
 	}
 
-	configLog := zap.NewDevelopmentConfig()
-	configLog.DisableStacktrace = true
-	log, err := configLog.Build()
+	log, err := logger.New(cfg)
 	if err != nil {
 		return fmt.Errorf("zap logger error: %w", err)
 	}
 	defer log.Sync()
 
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		return fmt.Errorf("tracing setup error: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	db, err := connClickhouse.ConnectClickhouse(cfg)
 	if err != nil {
 		return err
@@ -67,6 +74,16 @@ func Run() error {
 
 	ctrl := v.New(nc, log, service)
 
+	// Serving metrics
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", cfg.Server.Port), mux); err != nil {
+			log.Sugar().Errorf("metrics server error: %v", err)
+		}
+	}()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
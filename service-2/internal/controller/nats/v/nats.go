@@ -6,9 +6,20 @@ import (
 
 	"github.com/nats-io/nats.go"
 	"github.com/skantay/service-2/internal/usecase"
+	"github.com/skantay/service-2/pkg/metrics"
 	"go.uber.org/zap"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// requestIDHeader is set by service-1 to correlate logs across services
+const requestIDHeader = "X-Request-ID"
+
+var tracer = otel.Tracer("github.com/skantay/service-2/internal/controller/nats/v")
+
 type NC interface {
 	Serve(ctx context.Context) error
 }
@@ -24,15 +35,35 @@ func New(nc *nats.Conn, log *zap.Logger, ser usecase.Service) NC {
 }
 
 func (n natsServe) Serve(ctx context.Context) error {
-	_, err := n.nc.Subscribe("Goods.Collection", func(msg *nats.Msg) {
-		n.log.Sugar().Infof("got a message")
+	// service-1 publishes each organization's changes on its own subject
+	_, err := n.nc.Subscribe("org.*.Goods.Collection", func(msg *nats.Msg) {
+		metrics.MessagesConsumed.WithLabelValues(msg.Subject).Inc()
+
+		log := n.log.With(
+			zap.String("subject", msg.Subject),
+			zap.String("request_id", msg.Header.Get(requestIDHeader)),
+		)
 
-		if err := n.service.Good.Create(msg.Data); err != nil {
-			n.log.Error(err.Error())
+		log.Info("got a message")
+
+		// Continue the trace started by the publisher
+		msgCtx := otel.GetTextMapPropagator().Extract(context.Background(), headerCarrier(msg.Header))
+
+		msgCtx, span := tracer.Start(msgCtx, msg.Subject+" process",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				semconv.MessagingSystemKey.String("nats"),
+				semconv.MessagingDestinationName(msg.Subject),
+			))
+		defer span.End()
+
+		if err := n.service.Good.Create(msgCtx, msg.Data); err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			log.Error("failed to store goods", zap.Error(err))
 			return
 		}
 
-		n.log.Sugar().Infof("created a log")
+		log.Info("created a log")
 	})
 	if err != nil {
 		return err
@@ -43,3 +74,23 @@ func (n natsServe) Serve(ctx context.Context) error {
 	<-ctx.Done()
 	return ctx.Err()
 }
+
+// headerCarrier adapts nats.Header to propagation.TextMapCarrier.
+type headerCarrier nats.Header
+
+func (h headerCarrier) Get(key string) string {
+	return nats.Header(h).Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	nats.Header(h).Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
@@ -6,29 +6,45 @@ import (
 )
 
 type Good struct {
-	ID           int       `json:"id"`
-	ProjectID    int       `json:"project_id"`
-	Name         string    `json:"name"`
-	Description  string    `json:"description"`
-	Priority     int       `json:"priority"`
-	Removed      bool      `json:"removed"`
-	CreatedAt    time.Time `json:"-"`
-	CreatedAtStr string    `json:"created_at"`
+	ID        int `json:"id"`
+	ProjectID int `json:"project_id"`
+	// OrganizationID is the tenant owning the project in service-1
+	OrganizationID int       `json:"organization_id"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Priority       int       `json:"priority"`
+	Removed        bool      `json:"removed"`
+	CategoryID     *int      `json:"category_id"`
+	Tags           []string  `json:"tags"`
+	CreatedAt      time.Time `json:"-"`
+	CreatedAtStr   string    `json:"created_at"`
+
+	// Attributes are stored as JSON text
+	Attributes json.RawMessage `json:"attributes"`
+
+	// Visibility window; nil ends are open
+	VisibleFrom  *time.Time `json:"visible_from"`
+	VisibleUntil *time.Time `json:"visible_until"`
 }
 
 type Collection struct {
 	Goods []Good `json:"goods"`
+	// Actor is the subject that made the change in service-1
+	Actor string `json:"actor"`
 }
 
 func (c *Collection) UnmarshalJSON(data []byte) error {
 	type Alias Collection
 	aux := &struct {
 		Goods []Good `json:"goods"`
+		Actor string `json:"actor"`
 	}{}
 	if err := json.Unmarshal(data, &aux); err != nil {
 		return err
 	}
 
+	c.Actor = aux.Actor
+
 	for i := range aux.Goods {
 		aux.Goods[i].CreatedAt, _ = time.Parse(time.RFC3339Nano, aux.Goods[i].CreatedAtStr)
 		c.Goods = append(c.Goods, aux.Goods[i])
@@ -1,14 +1,25 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/skantay/service-2/internal/entity"
+	"github.com/skantay/service-2/pkg/metrics"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/skantay/service-2/internal/repository/clickhouse")
+
 type GoodRepository interface {
-	Create(collection entity.Collection) error
+	Create(ctx context.Context, collection entity.Collection) error
 }
 
 type goodRepository struct {
@@ -19,28 +30,75 @@ func New(db *sql.DB) GoodRepository {
 	return goodRepository{db}
 }
 
-func (g goodRepository) Create(collection entity.Collection) error {
-	batch, err := g.db.Begin()
+func (g goodRepository) Create(ctx context.Context, collection entity.Collection) error {
+	ctx, span := tracer.Start(ctx, "clickhouse INSERT goods",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemClickhouse,
+			semconv.DBOperation("INSERT"),
+			attribute.Int("batch.size", len(collection.Goods)),
+		))
+	defer span.End()
+
+	start := time.Now()
+
+	if err := g.create(ctx, collection); err != nil {
+		metrics.InsertFailures.Inc()
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	metrics.InsertBatchSize.Observe(float64(len(collection.Goods)))
+	metrics.InsertDuration.Observe(time.Since(start).Seconds())
+
+	return nil
+}
+
+func (g goodRepository) create(ctx context.Context, collection entity.Collection) error {
+	batch, err := g.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer batch.Rollback()
 
-	stmt, err := batch.Prepare("INSERT INTO default.goods(ID, ProjectID, Name, Description, Priority, Removed, CreatedAt) VALUES ($1, $2, $3, $4, $5, $6, $7)")
+	stmt, err := batch.PrepareContext(ctx, "INSERT INTO default.goods(ID, ProjectID, Name, Description, Priority, Removed, CreatedAt, Actor, Tags, CategoryID, Attributes, VisibleFrom, VisibleUntil, OrganizationID) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)")
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
 	for _, good := range collection.Goods {
-		_, err = stmt.Exec(
+		// Goods without a category are stored with CategoryID 0
+		var categoryID int
+		if good.CategoryID != nil {
+			categoryID = *good.CategoryID
+		}
+
+		tags := good.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+
+		attributes := string(good.Attributes)
+		if len(good.Attributes) == 0 || attributes == "null" {
+			attributes = "{}"
+		}
+
+		_, err = stmt.ExecContext(ctx,
 			good.ID,
 			good.ProjectID,
 			good.Name,
 			good.Description,
 			good.Priority,
 			good.Removed,
-			good.CreatedAt)
+			good.CreatedAt,
+			collection.Actor,
+			tags,
+			categoryID,
+			attributes,
+			good.VisibleFrom,
+			good.VisibleUntil,
+			good.OrganizationID)
 		if err != nil {
 			return fmt.Errorf("failed to execute statement for collection of goods: %w", err)
 		}
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -17,7 +18,7 @@ func NewService(good GoodUsecase) Service {
 }
 
 type GoodUsecase interface {
-	Create(data []byte) error
+	Create(ctx context.Context, data []byte) error
 }
 
 type goodUsecase struct {
@@ -28,7 +29,7 @@ func New(repo repository.GoodRepository) GoodUsecase {
 	return goodUsecase{repo}
 }
 
-func (g goodUsecase) Create(data []byte) error {
+func (g goodUsecase) Create(ctx context.Context, data []byte) error {
 	var collection entity.Collection
 
 	err := json.Unmarshal(data, &collection)
@@ -36,7 +37,5 @@ func (g goodUsecase) Create(data []byte) error {
 		return fmt.Errorf("json error: %w", err)
 	}
 
-	fmt.Println(collection.Goods[0].CreatedAt)
-
-	return g.repo.Create(collection)
+	return g.repo.Create(ctx, collection)
 }
@@ -0,0 +1 @@
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS Actor String DEFAULT '';
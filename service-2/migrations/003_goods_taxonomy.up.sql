@@ -0,0 +1 @@
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS Tags Array(String) DEFAULT [], ADD COLUMN IF NOT EXISTS CategoryID Int32 DEFAULT 0;
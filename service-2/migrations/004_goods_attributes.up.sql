@@ -0,0 +1 @@
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS Attributes String DEFAULT '{}';
@@ -0,0 +1 @@
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS VisibleFrom Nullable(DateTime('UTC')), ADD COLUMN IF NOT EXISTS VisibleUntil Nullable(DateTime('UTC'));
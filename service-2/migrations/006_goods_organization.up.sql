@@ -0,0 +1 @@
+ALTER TABLE goods ADD COLUMN IF NOT EXISTS OrganizationID Int32 DEFAULT 1;
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/skantay/service-2/config"
+
+	"go.uber.org/zap"
+)
+
+// New builds a JSON production logger when the format is "json" and a
+// human readable development logger otherwise.
+func New(cfg config.Config) (*zap.Logger, error) {
+	var configLog zap.Config
+
+	switch cfg.Log.Format {
+	case "json":
+		configLog = zap.NewProductionConfig()
+	case "", "console":
+		configLog = zap.NewDevelopmentConfig()
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Log.Format)
+	}
+
+	configLog.DisableStacktrace = true
+
+	if cfg.Log.Level != "" {
+		level, err := zap.ParseAtomicLevel(cfg.Log.Level)
+		if err != nil {
+			return nil, fmt.Errorf("log level error: %w", err)
+		}
+
+		configLog.Level = level
+	}
+
+	return configLog.Build()
+}
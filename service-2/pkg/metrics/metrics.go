@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "service2"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	// NATS
+	MessagesConsumed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "nats",
+		Name:      "messages_consumed_total",
+		Help:      "Number of NATS messages consumed by subject.",
+	}, []string{"subject"})
+
+	// ClickHouse
+	InsertBatchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "clickhouse",
+		Name:      "insert_batch_size",
+		Help:      "Number of goods written per insert batch.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	})
+
+	InsertDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "clickhouse",
+		Name:      "insert_duration_seconds",
+		Help:      "Latency of insert batches.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	InsertFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "clickhouse",
+		Name:      "insert_failures_total",
+		Help:      "Number of failed insert batches.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		MessagesConsumed,
+		InsertBatchSize,
+		InsertDuration,
+		InsertFailures,
+	)
+}
+
+// Handler serves the registered metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -4,35 +4,68 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 )
 
+// Numbered migrations such as 002_goods_actor.up.sql are applied after
+// the setup script in ascending order and reverted before it in descending
+// order. Every script must be idempotent since all of them run on startup,
+// and hold a single statement since ClickHouse rejects multi-statement queries.
+const dir = "./migrations"
+
 func MigrateUp(db *sql.DB) error {
-	// Migrate up file
-	data, err := os.ReadFile("./migrations/setup.up.sql")
+	files, err := numbered("up")
 	if err != nil {
-		return fmt.Errorf("read file error: %w", err)
+		return err
 	}
 
-	// Migrating up
-	_, err = db.Exec(string(data))
-	if err != nil {
-		return fmt.Errorf("database setup migration error: %w", err)
+	for _, file := range append([]string{filepath.Join(dir, "setup.up.sql")}, files...) {
+		if err := apply(db, file); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 func MigrateDown(db *sql.DB) error {
-	// Migrate down file
-	data, err := os.ReadFile("./migrations/setup.down.sql")
+	files, err := numbered("down")
 	if err != nil {
-		return fmt.Errorf("read file error: %w", err)
+		return err
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+
+	for _, file := range append(files, filepath.Join(dir, "setup.down.sql")) {
+		if err := apply(db, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func numbered(direction string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "[0-9]*."+direction+".sql"))
+	if err != nil {
+		return nil, fmt.Errorf("glob migrations error: %w", err)
 	}
 
-	// Migrating down
-	_, err = db.Exec(string(data))
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func apply(db *sql.DB, file string) error {
+	data, err := os.ReadFile(file)
 	if err != nil {
-		return fmt.Errorf("database setup migration error: %w", err)
+		return fmt.Errorf("read file error: %w", err)
 	}
+
+	if _, err := db.Exec(string(data)); err != nil {
+		return fmt.Errorf("database migration %s error: %w", filepath.Base(file), err)
+	}
+
 	return nil
 }